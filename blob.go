@@ -0,0 +1,110 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// Blob is a custom GraphQL type for binary data, which is serialized as a base64 encoded string
+// with padding. It has to be added to a schema via "scalar Blob" or "scalar Base64" since it is
+// not a predeclared GraphQL type.
+type Blob []byte
+
+// ImplementsGraphQLType maps this custom Go type
+// to the graphql scalar type in the schema.
+func (Blob) ImplementsGraphQLType(name string) bool {
+	return name == "Blob" || name == "Base64"
+}
+
+// UnmarshalGraphQL is a custom unmarshaler for Blob
+//
+// This function will be called whenever you use the
+// blob scalar as an input
+func (b *Blob) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case string:
+		data, err := base64.StdEncoding.DecodeString(input)
+		if err != nil {
+			return fmt.Errorf("invalid base64 for Blob: %s", err)
+		}
+		*b = data
+		return nil
+	case []byte:
+		*b = input
+		return nil
+	default:
+		return fmt.Errorf("wrong type for Blob: %T", input)
+	}
+}
+
+// MarshalJSON is a custom marshaler for Blob
+//
+// This function will be called whenever you
+// query for fields that use the Blob type
+func (b Blob) MarshalJSON() ([]byte, error) {
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(b))+2)
+	out[0] = '"'
+	base64.StdEncoding.Encode(out[1:], b)
+	out[len(out)-1] = '"'
+	return out, nil
+}
+
+// BlobReader is like Blob, but reads the data of a resolver from an io.Reader. The data is encoded
+// while it is read, so large payloads are not held in memory twice. If the reader is an io.Closer,
+// it is closed after it was read. A nil Reader is serialized as an empty string.
+type BlobReader struct {
+	io.Reader
+}
+
+// ImplementsGraphQLType maps this custom Go type
+// to the graphql scalar type in the schema.
+func (BlobReader) ImplementsGraphQLType(name string) bool {
+	return name == "Blob" || name == "Base64"
+}
+
+// UnmarshalGraphQL decodes the input like Blob and reads the decoded data.
+func (r *BlobReader) UnmarshalGraphQL(input interface{}) error {
+	var b Blob
+	if err := b.UnmarshalGraphQL(input); err != nil {
+		return err
+	}
+	r.Reader = bytes.NewReader(b)
+	return nil
+}
+
+// MarshalJSON reads the data and returns it as a base64 encoded JSON string. The executor uses
+// WriteJSON instead, which does not buffer the data.
+func (r BlobReader) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteJSON reads the data and writes it to w as a base64 encoded JSON string.
+func (r BlobReader) WriteJSON(w io.Writer) (err error) {
+	if c, ok := r.Reader.(io.Closer); ok {
+		defer func() {
+			if cErr := c.Close(); err == nil {
+				err = cErr
+			}
+		}()
+	}
+	if _, err := w.Write([]byte{'"'}); err != nil {
+		return err
+	}
+	if r.Reader != nil {
+		enc := base64.NewEncoder(base64.StdEncoding, w)
+		if _, err := io.Copy(enc, r.Reader); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write([]byte{'"'})
+	return err
+}
@@ -0,0 +1,52 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go/internal/exec"
+)
+
+// CacheScope is the scope of a cache control policy.
+type CacheScope string
+
+const (
+	// CacheScopePublic allows the response to be stored by shared caches.
+	CacheScopePublic CacheScope = "PUBLIC"
+	// CacheScopePrivate allows the response to be stored only by the cache of the client.
+	CacheScopePrivate CacheScope = "PRIVATE"
+)
+
+// CachePolicy is the cache control policy of a response, computed from the @cacheControl hints of
+// all fields that were resolved. See CacheControl.
+type CachePolicy struct {
+	MaxAge int
+	Scope  CacheScope
+	// Entities holds the entities touched by the resolvers with TouchEntity, sorted by type name
+	// and ID, which can be used as tags to invalidate the cached response.
+	Entities []EntityTag
+}
+
+// EntityTag identifies an entity that was touched while resolving a response.
+type EntityTag = exec.EntityTag
+
+// TouchEntity records that the resolver with the given context read the entity with the given type
+// and ID, which adds it to the Entities of the cache policy of the response. It may be called from
+// concurrent resolvers and does nothing if the schema was created without the CacheControl option.
+func TouchEntity(ctx context.Context, typeName, id string) {
+	if c, ok := exec.CacheControlFromContext(ctx); ok {
+		c.AddEntity(typeName, id)
+	}
+}
+
+// HeaderValue returns the value for an HTTP Cache-Control header, e.g. "max-age=60, private". It
+// returns an empty string if the response must not be cached.
+func (p *CachePolicy) HeaderValue() string {
+	if p == nil || p.MaxAge <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("max-age=%d, %s", p.MaxAge, map[CacheScope]string{
+		CacheScopePublic:  "public",
+		CacheScopePrivate: "private",
+	}[p.Scope])
+}
@@ -0,0 +1,219 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"text/scanner"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/trace"
+)
+
+// QueryDocument is a parsed query document, or one built with NewQueryDocument. It can be executed with ExecDocument more than once
+// without parsing the query again, or walked with WalkOperation.
+type QueryDocument struct {
+	doc *query.Document
+}
+
+// ParseQuery parses the query string into a document, without validating it against a schema.
+func ParseQuery(queryString string) (*QueryDocument, *errors.QueryError) {
+	doc, err := query.Parse(queryString)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryDocument{doc: doc}, nil
+}
+
+// ExecDocument validates the parsed document and executes the given operation of it with the
+// schema's resolver, like Exec does for a query string. The document is validated on every call.
+// If the document contains more than one operation, the operation name must be given.
+func (s *Schema) ExecDocument(ctx context.Context, doc *QueryDocument, operationName string, variables map[string]interface{}) *Response {
+	return s.execDocument(ctx, doc, operationName, variables, true)
+}
+
+// ExecTrustedDocument is like ExecDocument, but skips the validation of the document. It must only
+// be used for documents that were already validated against the schema, e.g. with Validate before
+// they were stored, since executing an invalid document may panic or return wrong results.
+func (s *Schema) ExecTrustedDocument(ctx context.Context, doc *QueryDocument, operationName string, variables map[string]interface{}) *Response {
+	return s.execDocument(ctx, doc, operationName, variables, false)
+}
+
+func (s *Schema) execDocument(ctx context.Context, doc *QueryDocument, operationName string, variables map[string]interface{}, validate bool) *Response {
+	if s.res.Resolver == (reflect.Value{}) {
+		panic("schema created without resolver, can not exec")
+	}
+	phases := trace.QueryPhases{Start: time.Now()}
+	resp, _ := s.executeDocument(ctx, phases, "", doc.doc, operationName, variables, s.res, false, validate)
+	return resp
+}
+
+// QueryOperation is an operation of a document built with NewQueryDocument, e.g. by a tool that
+// generates queries instead of writing them as strings.
+type QueryOperation struct {
+	// Type is "query", "mutation" or "subscription". It defaults to "query".
+	Type string
+	// Name is the name of the operation, which may be empty if it is the only one of the document.
+	Name string
+	// Variables are the definitions of the variables of the operation in GraphQL syntax, e.g.
+	// "$episode: Episode = JEDI".
+	Variables []string
+	// Selections are the fields and inline fragments selected on the root type.
+	Selections []QuerySelection
+}
+
+// QuerySelection is a *QueryField or a *QueryInlineFragment.
+type QuerySelection interface {
+	isQuerySelection()
+}
+
+// QueryField selects a field.
+type QueryField struct {
+	// Alias is the name of the field in the response. It defaults to Name.
+	Alias string
+	Name  string
+	// Arguments maps the names of the arguments of the field to their values in GraphQL syntax,
+	// e.g. "JEDI", "$episode" or `{stars: 5}`.
+	Arguments map[string]string
+	// Directives are the directives of the field in GraphQL syntax, e.g. "@include(if: $details)".
+	Directives []string
+	// Selections are the fields and inline fragments selected on the type of the field.
+	Selections []QuerySelection
+}
+
+// QueryInlineFragment selects fields on the values of the type On, or on all values if On is
+// empty.
+type QueryInlineFragment struct {
+	On         string
+	Directives []string
+	Selections []QuerySelection
+}
+
+func (*QueryField) isQuerySelection()          {}
+func (*QueryInlineFragment) isQuerySelection() {}
+
+// NewQueryDocument builds a document of the given operations, like ParseQuery does for a query
+// string, without validating it against a schema. Values, variable definitions and directives are
+// given in GraphQL syntax and a syntax error in one of them is returned. Since the document has no
+// source, the errors of its validation and execution have no locations.
+func NewQueryDocument(ops ...*QueryOperation) (*QueryDocument, *errors.QueryError) {
+	doc := &query.Document{}
+	for _, op := range ops {
+		o := &query.Operation{Name: common.Ident{Name: op.Name}}
+		switch op.Type {
+		case "", "query":
+			o.Type = query.Query
+		case "mutation":
+			o.Type = query.Mutation
+		case "subscription":
+			o.Type = query.Subscription
+		default:
+			return nil, errors.Errorf("unknown operation type %q", op.Type)
+		}
+		for _, v := range op.Variables {
+			err := parseSnippet(v, func(l *common.Lexer) {
+				l.ConsumeToken('$')
+				o.Vars = append(o.Vars, common.ParseInputValue(l))
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		sels, err := newSelections(op.Selections)
+		if err != nil {
+			return nil, err
+		}
+		o.Selections = sels
+		doc.Operations = append(doc.Operations, o)
+	}
+	return &QueryDocument{doc: doc}, nil
+}
+
+func newSelections(sels []QuerySelection) ([]query.Selection, *errors.QueryError) {
+	var res []query.Selection
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *QueryField:
+			f := &query.Field{Name: common.Ident{Name: sel.Name}, Alias: common.Ident{Name: sel.Alias}}
+			if sel.Alias == "" {
+				f.Alias = f.Name
+			}
+			// The arguments are sorted by name, so that the document does not depend on the order
+			// of the map.
+			names := make([]string, 0, len(sel.Arguments))
+			for name := range sel.Arguments {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				err := parseSnippet(sel.Arguments[name], func(l *common.Lexer) {
+					f.Arguments = append(f.Arguments, common.Argument{
+						Name:  common.Ident{Name: name},
+						Value: common.ParseLiteral(l, false),
+					})
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			dirs, err := newDirectives(sel.Directives)
+			if err != nil {
+				return nil, err
+			}
+			f.Directives = dirs
+			if f.Selections, err = newSelections(sel.Selections); err != nil {
+				return nil, err
+			}
+			res = append(res, f)
+
+		case *QueryInlineFragment:
+			f := &query.InlineFragment{}
+			if sel.On != "" {
+				f.On = common.TypeName{Ident: common.Ident{Name: sel.On}}
+			}
+			dirs, err := newDirectives(sel.Directives)
+			if err != nil {
+				return nil, err
+			}
+			f.Directives = dirs
+			if f.Selections, err = newSelections(sel.Selections); err != nil {
+				return nil, err
+			}
+			res = append(res, f)
+
+		default:
+			return nil, errors.Errorf("unknown selection %T", sel)
+		}
+	}
+	return res, nil
+}
+
+func newDirectives(dirs []string) (common.DirectiveList, *errors.QueryError) {
+	var res common.DirectiveList
+	for _, d := range dirs {
+		err := parseSnippet(d, func(l *common.Lexer) {
+			res = append(res, common.ParseDirectives(l)...)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// parseFragment parses the source of a part of a document with parse, which must consume all of
+// it.
+func parseSnippet(src string, parse func(l *common.Lexer)) *errors.QueryError {
+	l := common.NewLexer(src, false)
+	return l.CatchSyntaxError(func() {
+		l.ConsumeWhitespace()
+		parse(l)
+		if l.Peek() != scanner.EOF {
+			l.SyntaxError(fmt.Sprintf("unexpected %q in %q", l.Peek(), src))
+		}
+	})
+}
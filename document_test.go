@@ -0,0 +1,158 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+)
+
+func TestExecDocument(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+
+	doc, err := graphql.ParseQuery(`query Hero($episode: Episode) { hero(episode: $episode) { name friends { name } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"JEDI":   `{"hero":{"name":"R2-D2","friends":[{"name":"Luke Skywalker"},{"name":"Han Solo"},{"name":"Leia Organa"}]}}`,
+		"EMPIRE": `{"hero":{"name":"Luke Skywalker","friends":[{"name":"Han Solo"},{"name":"Leia Organa"},{"name":"C-3PO"},{"name":"R2-D2"}]}}`,
+	}
+	// The document is executed more than once without parsing it again.
+	for _, episode := range []string{"JEDI", "EMPIRE"} {
+		resp := schema.ExecDocument(context.Background(), doc, "", map[string]interface{}{"episode": episode})
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if got := string(resp.Data); got != want[episode] {
+			t.Errorf("got %s, want %s", got, want[episode])
+		}
+	}
+
+	doc, err = graphql.ParseQuery(`{ hero { name unknown } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := schema.ExecDocument(context.Background(), doc, "", nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Rule != "FieldsOnCorrectType" {
+		t.Errorf("expected a FieldsOnCorrectType error, got %v", resp.Errors)
+	}
+
+	if _, err := graphql.ParseQuery(`{ hero { name }`); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestExecTrustedDocument(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+
+	doc, err := graphql.ParseQuery(`query Hero($episode: Episode) { hero(episode: $episode) { name } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := schema.Validate(`query Hero($episode: Episode) { hero(episode: $episode) { name } }`); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	resp := schema.ExecTrustedDocument(context.Background(), doc, "Hero", map[string]interface{}{"episode": "EMPIRE"})
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	var data struct {
+		Hero struct{ Name string }
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Hero.Name != "Luke Skywalker" {
+		t.Errorf("got hero %q, want Luke Skywalker", data.Hero.Name)
+	}
+}
+
+func TestNewQueryDocument(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+
+	doc, err := graphql.NewQueryDocument(&graphql.QueryOperation{
+		Name:      "Hero",
+		Variables: []string{"$episode: Episode = JEDI", "$withFriends: Boolean!"},
+		Selections: []graphql.QuerySelection{
+			&graphql.QueryField{
+				Name:      "hero",
+				Arguments: map[string]string{"episode": "$episode"},
+				Selections: []graphql.QuerySelection{
+					&graphql.QueryField{Name: "name"},
+					&graphql.QueryField{
+						Alias:      "buddies",
+						Name:       "friends",
+						Directives: []string{"@include(if: $withFriends)"},
+						Selections: []graphql.QuerySelection{
+							&graphql.QueryInlineFragment{
+								On:         "Droid",
+								Selections: []graphql.QuerySelection{&graphql.QueryField{Name: "primaryFunction"}},
+							},
+						},
+					},
+				},
+			},
+			&graphql.QueryField{
+				Alias:      "empire",
+				Name:       "hero",
+				Arguments:  map[string]string{"episode": "EMPIRE"},
+				Selections: []graphql.QuerySelection{&graphql.QueryField{Name: "name"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The built document is executed like the equivalent query string.
+	want := schema.Exec(context.Background(), `
+		query Hero($episode: Episode = JEDI, $withFriends: Boolean!) {
+			hero(episode: $episode) {
+				name
+				buddies: friends @include(if: $withFriends) {
+					... on Droid { primaryFunction }
+				}
+			}
+			empire: hero(episode: EMPIRE) { name }
+		}`, "", map[string]interface{}{"withFriends": true})
+	if len(want.Errors) != 0 {
+		t.Fatal(want.Errors)
+	}
+	resp := schema.ExecDocument(context.Background(), doc, "Hero", map[string]interface{}{"withFriends": true})
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	if string(resp.Data) != string(want.Data) {
+		t.Errorf("got %s, want %s", resp.Data, want.Data)
+	}
+
+	// The built document is validated like a parsed one.
+	doc, err = graphql.NewQueryDocument(&graphql.QueryOperation{
+		Selections: []graphql.QuerySelection{&graphql.QueryField{Name: "unknown"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = schema.ExecDocument(context.Background(), doc, "", nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Rule != "FieldsOnCorrectType" {
+		t.Errorf("expected a FieldsOnCorrectType error, got %v", resp.Errors)
+	}
+
+	_, err = graphql.NewQueryDocument(&graphql.QueryOperation{
+		Selections: []graphql.QuerySelection{&graphql.QueryField{
+			Name:      "hero",
+			Arguments: map[string]string{"episode": "EMPIRE)"},
+		}},
+	})
+	if err == nil {
+		t.Error("expected a syntax error")
+	}
+	_, err = graphql.NewQueryDocument(&graphql.QueryOperation{Type: "update"})
+	if err == nil {
+		t.Error("expected an error for the unknown operation type")
+	}
+}
@@ -11,6 +11,28 @@ type QueryError struct {
 	Rule          string                 `json:"-"`
 	ResolverError error                  `json:"-"`
 	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+	// Phase is the phase of the request in which the error occurred, one of PhaseParse,
+	// PhaseValidate and PhaseExecute. It is empty for errors that are not returned by the
+	// execution of a request.
+	Phase string `json:"-"`
+}
+
+// Phases of a request, see QueryError.Phase. Errors of the parse and validate phases mean that the
+// request was not executed.
+const (
+	PhaseParse    = "parse"
+	PhaseValidate = "validate"
+	PhaseExecute  = "execute"
+)
+
+// SetPhase sets the phase of the errors that have none yet and returns errs.
+func SetPhase(errs []*QueryError, phase string) []*QueryError {
+	for _, err := range errs {
+		if err != nil && err.Phase == "" {
+			err.Phase = phase
+		}
+	}
+	return errs
 }
 
 type Location struct {
@@ -40,3 +62,12 @@ func (err *QueryError) Error() string {
 }
 
 var _ error = &QueryError{}
+
+// PartialError is implemented by resolver errors that do not fail the field. If IsPartial returns
+// true, the error is added to the errors of the response, but the field is still resolved from the
+// value returned by the resolver, or from Data if it is not nil.
+type PartialError interface {
+	error
+	IsPartial() bool
+	Data() interface{}
+}
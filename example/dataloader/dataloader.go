@@ -0,0 +1,158 @@
+// Package dataloader shows how a graphql.BatchScheduler loads the data of a field for all elements
+// of a list at once, instead of calling the backend once per element.
+package dataloader
+
+import (
+	"context"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+const Schema = `
+	schema {
+		query: Query
+	}
+
+	type Query {
+		posts: [Post!]!
+	}
+
+	type Post {
+		id: ID!
+		title: String!
+		author: User!
+	}
+
+	type User {
+		id: ID!
+		name: String!
+	}
+`
+
+// UserStore is the backend of the users, which counts the calls made to it.
+type UserStore struct {
+	mu    sync.Mutex
+	users map[graphql.ID]*User
+	calls int
+}
+
+// NewUserStore returns a store of the given users.
+func NewUserStore(users ...*User) *UserStore {
+	s := &UserStore{users: make(map[graphql.ID]*User)}
+	for _, u := range users {
+		s.users[u.id] = u
+	}
+	return s
+}
+
+// Load returns the users with the given IDs in one call.
+func (s *UserStore) Load(ids []graphql.ID) map[graphql.ID]*User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	users := make(map[graphql.ID]*User, len(ids))
+	for _, id := range ids {
+		if u, ok := s.users[id]; ok {
+			users[id] = u
+		}
+	}
+	return users
+}
+
+// Calls returns the number of calls made to the store.
+func (s *UserStore) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+type loadedUsersKey struct{}
+
+// Loader is a graphql.BatchScheduler that loads the authors of all posts of a list with one call
+// to the store. The resolvers of Post.author then find their author in the context.
+type Loader struct {
+	Store *UserStore
+}
+
+// Schedule implements graphql.BatchScheduler.
+func (l *Loader) Schedule(ctx context.Context, key graphql.BatchKey, parents []interface{}, resolve func(ctx context.Context)) {
+	if key.ParentType != "Post" || key.Field != "author" {
+		resolve(ctx)
+		return
+	}
+	ids := make([]graphql.ID, 0, len(parents))
+	seen := make(map[graphql.ID]bool)
+	for _, p := range parents {
+		id := p.(*Post).authorID
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	resolve(context.WithValue(ctx, loadedUsersKey{}, l.Store.Load(ids)))
+}
+
+// Resolver is the root resolver.
+type Resolver struct {
+	posts []*Post
+}
+
+// NewResolver returns the root resolver of the given posts.
+func NewResolver(posts ...*Post) *Resolver {
+	return &Resolver{posts: posts}
+}
+
+func (r *Resolver) Posts() []*Post {
+	return r.posts
+}
+
+// Post is the resolver of a post.
+type Post struct {
+	id       graphql.ID
+	title    string
+	authorID graphql.ID
+	store    *UserStore
+}
+
+// NewPost returns a post by the author with the given ID, who is looked up in store.
+func NewPost(id graphql.ID, title string, authorID graphql.ID, store *UserStore) *Post {
+	return &Post{id: id, title: title, authorID: authorID, store: store}
+}
+
+func (p *Post) ID() graphql.ID {
+	return p.id
+}
+
+func (p *Post) Title() string {
+	return p.title
+}
+
+// Author returns the author that the Loader loaded, or loads it on its own if the post was not
+// resolved through a batch.
+func (p *Post) Author(ctx context.Context) *User {
+	users, ok := ctx.Value(loadedUsersKey{}).(map[graphql.ID]*User)
+	if !ok {
+		users = p.store.Load([]graphql.ID{p.authorID})
+	}
+	return users[p.authorID]
+}
+
+// User is the resolver of a user.
+type User struct {
+	id   graphql.ID
+	name string
+}
+
+// NewUser returns a user.
+func NewUser(id graphql.ID, name string) *User {
+	return &User{id: id, name: name}
+}
+
+func (u *User) ID() graphql.ID {
+	return u.id
+}
+
+func (u *User) Name() string {
+	return u.name
+}
@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// Federation makes the schema usable as a subgraph of an Apollo Federation gateway. The
+// directives @key, @external, @requires, @provides and @extends may be used in the schema, and
+// the query type gets the fields _service, which returns the schema as written, and _entities,
+// which resolves the types with a @key directive from their representations.
+//
+// The entities of a type are resolved by a method of the root resolver named
+// "Resolve<Type>Reference", e.g. ResolveUserReference for the type User. It takes an optional
+// context.Context and the representation as a map[string]interface{}, and returns the resolver of
+// the entity and an optional error.
+func Federation() SchemaOpt {
+	return func(s *Schema) {
+		s.federation = true
+	}
+}
+
+const federationSchema = `
+	scalar _Any
+	scalar _FieldSet
+
+	directive @key(fields: _FieldSet!) on OBJECT | INTERFACE
+	directive @external on FIELD_DEFINITION
+	directive @requires(fields: _FieldSet!) on FIELD_DEFINITION
+	directive @provides(fields: _FieldSet!) on FIELD_DEFINITION
+	directive @extends on OBJECT | INTERFACE
+
+	type _Service {
+		sdl: String
+	}
+`
+
+// federatedSchemaString returns schemaString with the types, directives and fields that
+// federation adds to a schema.
+func federatedSchemaString(schemaString string, useStringDescriptions bool) (string, error) {
+	s := schema.New()
+	if err := s.Parse(schemaString+federationSchema, useStringDescriptions); err != nil {
+		return "", err
+	}
+	query, ok := s.EntryPoints["query"]
+	if !ok {
+		return "", fmt.Errorf("graphql: federation requires a query type")
+	}
+
+	var entities []string
+	for name, t := range s.Types {
+		if o, ok := t.(*schema.Object); ok && o.Directives.Get("key") != nil {
+			entities = append(entities, name)
+		}
+	}
+	sort.Strings(entities)
+
+	var b strings.Builder
+	b.WriteString(schemaString)
+	b.WriteString(federationSchema)
+	if len(entities) > 0 {
+		fmt.Fprintf(&b, "\n\tunion _Entity = %s\n", strings.Join(entities, " | "))
+	}
+	fmt.Fprintf(&b, "\n\textend type %s {\n\t\t_service: _Service!\n", query.TypeName())
+	if len(entities) > 0 {
+		b.WriteString("\t\t_entities(representations: [_Any!]!): [_Entity]!\n")
+	}
+	b.WriteString("\t}\n")
+	return b.String(), nil
+}
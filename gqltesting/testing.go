@@ -89,6 +89,17 @@ func checkErrors(t *testing.T, want, got []*errors.QueryError) {
 	sortErrors(want)
 	sortErrors(got)
 
+	// The phase of an error is only compared if the test expects one.
+	if len(got) == len(want) {
+		for i, err := range got {
+			if want[i].Phase == "" && err.Phase != "" {
+				e := *err
+				e.Phase = ""
+				got[i] = &e
+			}
+		}
+	}
+
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("unexpected error: got %+v, want %+v", got, want)
 	}
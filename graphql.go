@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/common"
 	"github.com/graph-gophers/graphql-go/internal/exec"
+	"github.com/graph-gophers/graphql-go/internal/exec/packer"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
@@ -26,6 +30,7 @@ func ParseSchema(schemaString string, resolver interface{}, opts ...SchemaOpt) (
 	s := &Schema{
 		schema:           schema.New(),
 		maxParallelism:   10,
+		maxIntrospection: 10,
 		tracer:           trace.OpenTracingTracer{},
 		validationTracer: trace.NoopValidationTracer{},
 		logger:           &log.DefaultLogger{},
@@ -34,14 +39,28 @@ func ParseSchema(schemaString string, resolver interface{}, opts ...SchemaOpt) (
 		opt(s)
 	}
 
-	if err := s.schema.Parse(schemaString, s.useStringDescriptions); err != nil {
+	parsed := schemaString
+	if s.federation {
+		var err error
+		if parsed, err = federatedSchemaString(schemaString, s.useStringDescriptions); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.schema.Parse(parsed, s.useStringDescriptions); err != nil {
+		return nil, err
+	}
+	if err := s.bindScalarCoercions(); err != nil {
 		return nil, err
 	}
 	if err := s.validateSchema(); err != nil {
 		return nil, err
 	}
 
-	r, err := resolvable.ApplyResolver(s.schema, resolver)
+	var federation *resolvable.Federation
+	if s.federation {
+		federation = &resolvable.Federation{SDL: schemaString}
+	}
+	r, err := resolvable.ApplyFederatedResolver(s.schema, resolver, federation)
 	if err != nil {
 		return nil, err
 	}
@@ -66,12 +85,41 @@ type Schema struct {
 
 	maxDepth              int
 	maxCost               int
+	maxIntrospection      int
 	maxParallelism        int
+	globalLimiter         chan struct{}
 	tracer                trace.Tracer
 	validationTracer      trace.ValidationTracer
 	logger                log.Logger
 	useStringDescriptions bool
 	disableIntrospection  bool
+	reportDepth           bool
+	cacheControl          bool
+	defaultMaxAge         int
+	allowUnusedFragments  bool
+	deprecationWarnings   bool
+	nonFiniteFloatsAsNull bool
+	includeErrorLocations bool
+	federation            bool
+	timingTree            bool
+	operationInErrors     bool
+	nonNullError          func(path []interface{}, typeName, fieldName string) *errors.QueryError
+	resolverErrorMapper   func(err error) *errors.QueryError
+	directiveHandlers     map[string]selected.DirectiveHandler
+	authorization         *selected.Authorization
+	scalarCoercions       map[string]schema.ScalarCoercion
+	validationObserver    func(ValidationFailure)
+
+	maxDepthExemptIntrospection bool
+	maxMultiplierProduct        int
+	maxFieldCount               int
+	maxFragmentExpansion        int
+	maxAliases                  int
+	maxTokens                   int
+	actualCost                  bool
+	maxActualCost               int
+	executionTimeout            time.Duration
+	batchScheduler              BatchScheduler
 }
 
 // SchemaOpt is an option to pass to ParseSchema or MustParseSchema.
@@ -87,6 +135,54 @@ func UseStringDescriptions() SchemaOpt {
 	}
 }
 
+// StrictCoercion rejects lossy coercions of input values, which are applied silently otherwise:
+// Int values for Float arguments, non-string values for string types and unknown fields of input
+// objects.
+func StrictCoercion() SchemaOpt {
+	return func(s *Schema) {
+		s.schema.StrictCoercion = true
+	}
+}
+
+// MaxStringLength specifies the maximum length in bytes of strings passed to scalar arguments and
+// input fields, e.g. String or ID, both as literals and as variables. Arguments and input fields can
+// override it with a directive declared as `directive @maxLength(max: Int!) on ARGUMENT_DEFINITION |
+// INPUT_FIELD_DEFINITION`. The default is 0 which disables the limit.
+func MaxStringLength(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.schema.MaxStringLength = n
+	}
+}
+
+// MaxInputDepth specifies the maximum nesting depth of input objects in the arguments of a field,
+// where an input object passed as an argument has a depth of 1. It protects recursive input types
+// against values that are nested deeply to force deep recursion while they are coerced. Maps in the
+// values of custom scalars are counted too. The default is 0 which disables the limit.
+func MaxInputDepth(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.schema.MaxInputDepth = n
+	}
+}
+
+// MaxAliases specifies the maximum number of distinct aliases a field of an object type may be
+// selected with in a selection set, counted after expanding fragments. It protects against queries
+// that alias an expensive field many times. The default is 0 which disables the check.
+func MaxAliases(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxAliases = n
+	}
+}
+
+// MaxTokens specifies the maximum number of lexical tokens in a query. The lexer stops at the
+// first token over the limit, so a huge query fails with a syntax error before it is parsed or
+// validated. Commas, comments and whitespace are not counted. The default is 0 which disables the
+// limit.
+func MaxTokens(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxTokens = n
+	}
+}
+
 // UseFieldResolvers specifies whether to use struct field resolvers
 func UseFieldResolvers() SchemaOpt {
 	return func(s *Schema) {
@@ -101,6 +197,92 @@ func MaxDepth(n int) SchemaOpt {
 	}
 }
 
+// MaxIntrospectionFields specifies the maximum number of times an operation may select the
+// introspection fields __schema and __type, which protects against scraping the schema with many
+// aliased __type selections in a single request. The default is 10. A value of 0 or less disables
+// the limit.
+func MaxIntrospectionFields(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxIntrospection = n
+	}
+}
+
+// MaxDepthExemptIntrospection exempts the introspection fields __schema, __type and __typename
+// from the MaxDepth check, so that the deeply nested introspection queries of tools like GraphiQL
+// are not rejected.
+func MaxDepthExemptIntrospection() SchemaOpt {
+	return func(s *Schema) {
+		s.maxDepthExemptIntrospection = true
+	}
+}
+
+// MaxMultiplierProduct specifies the maximum product of the @cost multipliers on any path of a
+// query, which rejects queries that nest several paginated lists with large page sizes. The
+// default is 0 which disables the check.
+func MaxMultiplierProduct(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxMultiplierProduct = n
+	}
+}
+
+// MaxCost specifies the maximum cost of a query, as estimated from the @cost directives of the
+// schema. Queries exceeding it are rejected with an error whose extensions contain the cost, the
+// limit and the path of the most expensive root field. The default is 0 which disables max cost
+// checking.
+//
+// The @cost directive of a field of an object type takes precedence over the one of the same field
+// of an interface that the type implements, which is only used if the object's field has none.
+// Fields without either take the complexity of a @cost directive on their object or interface
+// type, or else on the schema definition, e.g. "schema @cost(complexity: 1) { ... }". The
+// fields of a fragment on an interface or union that is selected on an object type, e.g. through a
+// fragment on the object type, are priced as fields of the object type.
+func MaxCost(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxCost = n
+	}
+}
+
+// ActualCost enables the accounting of the actual cost of every executed query, which is the sum of
+// the @cost complexity of every field resolution. Unlike the estimated cost, which multiplies the
+// cost of the fields below a list with the multiplier arguments of the list field, the actual cost
+// counts them once per resolved item. It is reported as the "actualCost" response extension, e.g.
+// to charge a rate limit after the execution. Fields of deferred fragments are not included.
+func ActualCost() SchemaOpt {
+	return func(s *Schema) {
+		s.actualCost = true
+	}
+}
+
+// MaxActualCost specifies the maximum actual cost of a query, see ActualCost. Since the actual cost
+// is only known after the resolution, a query exceeding it is executed, but its data is discarded
+// and replaced by an error whose extensions contain the cost and the limit. It complements MaxCost
+// for fields whose cost can not be estimated from their arguments. The default is 0 which disables
+// the check.
+func MaxActualCost(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxActualCost = n
+	}
+}
+
+// MaxFragmentExpansion specifies the maximum number of selections an operation may expand to when
+// all of its fragments are expanded, which rejects "fragment bombs" that are small documents with
+// fragments spreading other fragments many times. The check runs before the other validation
+// rules. The default is 0 which disables the check.
+func MaxFragmentExpansion(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxFragmentExpansion = n
+	}
+}
+
+// MaxFieldCount specifies the maximum number of fields an operation may select, counted after
+// expanding fragments and merging fields with the same response key. It protects against wide
+// queries that select many cheap fields. The default is 0 which disables the check.
+func MaxFieldCount(n int) SchemaOpt {
+	return func(s *Schema) {
+		s.maxFieldCount = n
+	}
+}
+
 // MaxParallelism specifies the maximum number of resolvers per request allowed to run in parallel. The default is 10.
 func MaxParallelism(n int) SchemaOpt {
 	return func(s *Schema) {
@@ -108,6 +290,17 @@ func MaxParallelism(n int) SchemaOpt {
 	}
 }
 
+// MaxGlobalParallelism specifies the maximum number of resolvers allowed to run in parallel across all requests of the schema. The default is 0 which disables the limit.
+func MaxGlobalParallelism(n int) SchemaOpt {
+	return func(s *Schema) {
+		if n <= 0 {
+			s.globalLimiter = nil
+			return
+		}
+		s.globalLimiter = make(chan struct{}, n)
+	}
+}
+
 // Tracer is used to trace queries and fields. It defaults to trace.OpenTracingTracer.
 func Tracer(tracer trace.Tracer) SchemaOpt {
 	return func(s *Schema) {
@@ -129,6 +322,245 @@ func Logger(logger log.Logger) SchemaOpt {
 	}
 }
 
+// ReportDepth adds the depth of the executed operation to the response extensions under the
+// "depth" key. The depth is computed the same way as for MaxDepth, so the introspection fields are
+// not counted if MaxDepthExemptIntrospection is set.
+func ReportDepth() SchemaOpt {
+	return func(s *Schema) {
+		s.reportDepth = true
+	}
+}
+
+// OperationInErrors adds the name and type of the executed operation to the extensions of errors,
+// under the "operation" key, e.g. {"operation": {"name": "GetUser", "type": "query"}}. The name is
+// omitted for anonymous operations. Validation errors get it if the operation can be determined.
+func OperationInErrors() SchemaOpt {
+	return func(s *Schema) {
+		s.operationInErrors = true
+	}
+}
+
+// TimingTree enables the collection of a timing tree for every response, which is returned in
+// Response.Timing. Unlike the flat list of resolvers of ApolloTracer, the tree mirrors the
+// selections of the operation, which makes it convenient to analyze in tests and profiling tools.
+func TimingTree() SchemaOpt {
+	return func(s *Schema) {
+		s.timingTree = true
+	}
+}
+
+// CacheControl enables the computation of a cache policy for every response, which is returned in
+// Response.CachePolicy. Fields are annotated with a directive declared in the schema as
+//
+//	enum CacheControlScope { PUBLIC PRIVATE }
+//	directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+//
+// The max age of the response is the minimum max age of all resolved fields and its scope is
+// private if any field is private. A hint on a field takes precedence over a hint on the type it
+// returns. Scalar and enum fields without a hint inherit the policy of their parent, while root
+// fields and fields returning composite types without a hint use defaultMaxAge.
+func CacheControl(defaultMaxAge int) SchemaOpt {
+	return func(s *Schema) {
+		s.cacheControl = true
+		s.defaultMaxAge = defaultMaxAge
+	}
+}
+
+// AllowUnusedFragments downgrades the validation error for fragments that are never spread to a
+// warning. Queries with unused fragments are then executed and the warnings are returned in the
+// "warnings" entry of the response extensions.
+func AllowUnusedFragments() SchemaOpt {
+	return func(s *Schema) {
+		s.allowUnusedFragments = true
+	}
+}
+
+// DeprecationWarnings adds a warning for every use of a deprecated field, argument, input field or
+// enum value in a query to the "warnings" entry of the response extensions. The query is executed
+// as usual. See ValidateWithWarnings for the warnings.
+func DeprecationWarnings() SchemaOpt {
+	return func(s *Schema) {
+		s.deprecationWarnings = true
+	}
+}
+
+// WithExecutionTimeout limits the time that the execution of a query or mutation waits for its
+// resolvers. When it expires, the fields that are still pending resolve to null with an error and
+// the response contains the data that was resolved in time, unlike a cancelled context, which fails
+// the whole request. The contexts of the pending resolvers are cancelled, but the resolvers are left
+// running in the background, so they should return once their context is done. Until then, they
+// keep their slots of MaxParallelism and MaxGlobalParallelism. The default is 0 which disables the
+// timeout.
+func WithExecutionTimeout(d time.Duration) SchemaOpt {
+	return func(s *Schema) {
+		s.executionTimeout = d
+	}
+}
+
+// BatchKey identifies the resolutions of a field that a BatchScheduler receives as one batch.
+type BatchKey = exec.BatchKey
+
+// BatchScheduler coalesces the resolutions of a field across the elements of a list, see
+// UseBatchScheduler.
+type BatchScheduler = exec.BatchScheduler
+
+// UseBatchScheduler passes the asynchronous fields of the elements of each list to the scheduler in
+// batches, one for each field, arguments and path, instead of resolving every element on its own.
+// The scheduler can then load the data of all elements at once, e.g. the authors of all posts of a
+// list, before it lets the resolvers run, which turns N+1 backend calls into 2.
+func UseBatchScheduler(scheduler BatchScheduler) SchemaOpt {
+	return func(s *Schema) {
+		s.batchScheduler = scheduler
+	}
+}
+
+// NonFiniteFloatsAsNull serializes NaN and infinite values returned by resolvers of Float fields as
+// null, since they can not be represented in JSON. An error is still reported if the field is
+// non-null. By default such values are reported as errors.
+func NonFiniteFloatsAsNull() SchemaOpt {
+	return func(s *Schema) {
+		s.nonFiniteFloatsAsNull = true
+	}
+}
+
+// IncludeErrorLocations adds the line and column of the field in the query to the errors returned
+// or panicked by resolvers, which is useful for debugging. By default only the path is set.
+func IncludeErrorLocations() SchemaOpt {
+	return func(s *Schema) {
+		s.includeErrorLocations = true
+	}
+}
+
+// NonNullError customizes the error that is added to the response when a field of a non-null type
+// resolves to null without an error of its resolver. The factory receives the path of the null
+// value and the parent type and name of the field that it belongs to. If the factory returns nil,
+// or the returned error has no path, the default error or path is used.
+func NonNullError(factory func(path []interface{}, typeName, fieldName string) *errors.QueryError) SchemaOpt {
+	return func(s *Schema) {
+		s.nonNullError = factory
+	}
+}
+
+// ResolverErrorMapper converts the errors returned by resolvers into the errors of the response,
+// e.g. to set an extensions code for the error types of an application without every resolver
+// returning a *errors.QueryError. The executor sets the path and location of the field unless the
+// mapper sets them. If the mapper returns nil, the error is converted as without the mapper.
+func ResolverErrorMapper(mapper func(err error) *errors.QueryError) SchemaOpt {
+	return func(s *Schema) {
+		s.resolverErrorMapper = mapper
+	}
+}
+
+// DirectiveHandler decides whether a selection is skipped, based on the coerced arguments of a
+// directive applied to it in the query. Errors are added to the response and do not skip the
+// selection.
+//
+// The arguments are coerced according to the declaration of the directive, with variables resolved
+// and omitted arguments set to their default: scalars are int32, float64, string or bool, enum
+// values are strings, lists are []interface{}, also for a single value given for a list, and input
+// objects are map[string]interface{}.
+type DirectiveHandler func(args map[string]interface{}) (skip bool, err error)
+
+// SelectionDirective registers a handler for the directive with the given name, which is called
+// for every field, inline fragment and fragment spread the directive is applied to. The directive
+// must be declared in the schema with the locations it may be used at, e.g.
+//
+//	directive @onlyIf(flag: String!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+//
+// Registering a handler for "skip" or "include" replaces the built-in behavior of the directive.
+func SelectionDirective(name string, handler DirectiveHandler) SchemaOpt {
+	return func(s *Schema) {
+		if s.directiveHandlers == nil {
+			s.directiveHandlers = make(map[string]selected.DirectiveHandler)
+		}
+		s.directiveHandlers[name] = selected.DirectiveHandler(handler)
+	}
+}
+
+// AuthorizationPolicy decides what happens to the fields that a request is not authorized to query,
+// see FieldAuthorization.
+type AuthorizationPolicy = selected.AuthorizationPolicy
+
+const (
+	// DropUnauthorized removes unauthorized fields from the response and adds an error for each of
+	// them.
+	DropUnauthorized = selected.DropUnauthorized
+	// NullUnauthorized resolves unauthorized fields to null with an error at their path, without
+	// calling their resolvers. Like other field errors, a null of a non-null field propagates to
+	// its parent.
+	NullUnauthorized = selected.NullUnauthorized
+)
+
+// FieldAuthorization guards the fields with the directive of the given name, which must be declared
+// in the schema with a "role" argument, e.g.
+//
+//	directive @requiresRole(role: String!) on FIELD_DEFINITION
+//
+// A guarded field can only be queried by requests whose context has the role, see WithRoles. The
+// directive of an interface field guards the field of every implementation as well. The fields
+// that a request may not query are handled according to policy.
+func FieldAuthorization(directive string, policy AuthorizationPolicy) SchemaOpt {
+	return func(s *Schema) {
+		s.authorization = &selected.Authorization{Directive: directive, Policy: policy}
+	}
+}
+
+type rolesKey struct{}
+
+// WithRoles returns a copy of ctx with the roles of the caller, which are checked for the fields
+// guarded by FieldAuthorization in the requests executed with it.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// rolesOf returns the roles of the caller of a request with the given context.
+func rolesOf(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// ScalarCoercion converts the values of a scalar type. CoerceInput is called with the value of an
+// argument, input field or variable, e.g. a string or a float64 decoded from JSON, and returns the
+// Go value passed to the resolver. CoerceOutput is called with the value returned by a resolver and
+// returns a value that is serialized with encoding/json.
+type ScalarCoercion interface {
+	CoerceInput(value interface{}) (interface{}, error)
+	CoerceOutput(value interface{}) (interface{}, error)
+}
+
+// CustomScalar binds a coercion to the scalar type with the given name. Resolvers of the scalar may
+// then return any Go type accepted by CoerceOutput, and arguments of the scalar must have the type
+// returned by CoerceInput.
+func CustomScalar(name string, coercion ScalarCoercion) SchemaOpt {
+	return func(s *Schema) {
+		if s.scalarCoercions == nil {
+			s.scalarCoercions = make(map[string]schema.ScalarCoercion)
+		}
+		s.scalarCoercions[name] = coercion
+	}
+}
+
+// ValidationFailure describes a query that was rejected by validation.
+type ValidationFailure struct {
+	// Query is the query string as given by the client.
+	Query string
+	// OperationName is the name of the operation, if it could be determined.
+	OperationName string
+	// OperationType is "query", "mutation" or "subscription", if the operation could be determined.
+	OperationType string
+	// Errors are the validation errors. They are shared with the response and must not be modified.
+	Errors []*errors.QueryError
+}
+
+// ValidationObserver registers a function that is called whenever a query fails validation, e.g. to
+// count malformed queries. It is called synchronously before the errors are returned to the
+// client.
+func ValidationObserver(observer func(ValidationFailure)) SchemaOpt {
+	return func(s *Schema) {
+		s.validationObserver = observer
+	}
+}
+
 // DisableIntrospection disables introspection queries.
 func DisableIntrospection() SchemaOpt {
 	return func(s *Schema) {
@@ -136,6 +568,80 @@ func DisableIntrospection() SchemaOpt {
 	}
 }
 
+type introspectionKey struct{}
+
+// WithIntrospection returns a copy of ctx that enables or disables introspection queries for the
+// requests executed with it, overriding the DisableIntrospection schema option.
+func WithIntrospection(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, introspectionKey{}, enabled)
+}
+
+// introspectionDisabled reports whether introspection is disabled for a request with the given
+// context.
+func (s *Schema) introspectionDisabled(ctx context.Context) bool {
+	if enabled, ok := ctx.Value(introspectionKey{}).(bool); ok {
+		return !enabled
+	}
+	return s.disableIntrospection
+}
+
+type visibleFieldsKey struct{}
+
+// WithVisibleFields returns a copy of ctx that hides the fields for which visible returns false
+// from the introspection queries of the requests executed with it. The types that are no longer
+// reachable from the root types are omitted from __schema.types and __type as well, while the
+// built-in introspection types are always included. The fields can still be queried.
+func WithVisibleFields(ctx context.Context, visible func(typeName, fieldName string) bool) context.Context {
+	return context.WithValue(ctx, visibleFieldsKey{}, visible)
+}
+
+// visibleFields returns the field filter of a request with the given context, or nil.
+func visibleFields(ctx context.Context) func(typeName, fieldName string) bool {
+	visible, _ := ctx.Value(visibleFieldsKey{}).(func(typeName, fieldName string) bool)
+	return visible
+}
+
+// FieldContext describes the field that a resolver is called for.
+type FieldContext = exec.FieldInfo
+
+// FieldFromContext returns the field that a resolver is called for, given the context passed to
+// the resolver. Only resolvers that take a context.Context get it.
+func FieldFromContext(ctx context.Context) (*FieldContext, bool) {
+	return exec.FieldInfoFromContext(ctx)
+}
+
+// TimingNode is a node of the timing tree of a response, see TimingTree.
+type TimingNode = exec.TimingNode
+
+// SelectedFields describes the subfields that a query selects on the value of a field. Resolvers
+// receive it by taking a parameter of this type after their arguments, e.g. to read only the
+// needed columns from a database:
+//
+//	func (r *Resolver) Search(ctx context.Context, args struct{ Text string }, fields graphql.SelectedFields) ([]*SearchResult, error)
+//
+// For a union or interface field, the fields of inline fragments and fragment spreads on its
+// member or implementing types are listed in ByType under the name of the type, e.g. the query
+// "search { ... on Human { name height } ... on Droid { name } }" results in the ByType entries
+// Human: [name, height] and Droid: [name] with no Fields.
+type SelectedFields = resolvable.SelectedFields
+
+// SelectedField is a field selected in a query, see SelectedFields.
+type SelectedField = resolvable.SelectedField
+
+// FieldDirective is a directive applied to a field in a query, with its arguments coerced
+// according to the declaration of the directive.
+type FieldDirective = selected.Directive
+
+// FieldDirectives returns the directives applied to the field that a resolver is called for, given
+// the context passed to the resolver. Only resolvers that take a context.Context get them.
+func FieldDirectives(ctx context.Context) []FieldDirective {
+	f, ok := exec.FieldInfoFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return f.Directives
+}
+
 // Response represents a typical response of a GraphQL server. It may be encoded to JSON directly or
 // it may be further processed to a custom response type, for example to include custom error data.
 // Errors are intentionally serialized first based on the advice in https://github.com/facebook/graphql/commit/7b40390d48680b15cb93e02d46ac5eb249689876#diff-757cea6edf0288677a9eea4cfc801d87R107
@@ -143,16 +649,334 @@ type Response struct {
 	Errors     []*errors.QueryError   `json:"errors,omitempty"`
 	Data       json.RawMessage        `json:"data,omitempty"`
 	Extensions map[string]interface{} `json:"extensions,omitempty"`
+
+	// Label and Path identify the deferred fragment or the streamed list item whose result a
+	// subsequent response of ExecIncremental holds in Data or Items. The path is empty, but not
+	// nil, for the root object. HasNext is set on all responses of ExecIncremental.
+	Items   json.RawMessage `json:"items,omitempty"`
+	Label   string          `json:"label,omitempty"`
+	Path    []interface{}   `json:"path,omitempty"`
+	HasNext *bool           `json:"hasNext,omitempty"`
+
+	// CachePolicy is the cache control policy of the response. It is only set when the schema
+	// was created with the CacheControl option and the operation was executed.
+	CachePolicy *CachePolicy `json:"-"`
+
+	// Timing is the root of the timing tree of the response. It is only set when the schema was
+	// created with the TimingTree option and the operation was executed.
+	Timing *TimingNode `json:"-"`
+}
+
+// MarshalJSON encodes the response like its struct tags describe, except that the empty path of a
+// subsequent response of ExecIncremental for the root object is kept, which omitempty would drop.
+func (r Response) MarshalJSON() ([]byte, error) {
+	type response Response
+	if r.Path == nil || len(r.Path) != 0 {
+		return json.Marshal(response(r))
+	}
+	return json.Marshal(struct {
+		Errors     []*errors.QueryError   `json:"errors,omitempty"`
+		Data       json.RawMessage        `json:"data,omitempty"`
+		Extensions map[string]interface{} `json:"extensions,omitempty"`
+		Items      json.RawMessage        `json:"items,omitempty"`
+		Label      string                 `json:"label,omitempty"`
+		Path       []interface{}          `json:"path"`
+		HasNext    *bool                  `json:"hasNext,omitempty"`
+	}{r.Errors, r.Data, r.Extensions, r.Items, r.Label, r.Path, r.HasNext})
+}
+
+// QueryTypeName returns the name of the query root type of the schema.
+func (s *Schema) QueryTypeName() string {
+	return s.rootTypeName("query")
+}
+
+// MutationTypeName returns the name of the mutation root type of the schema, or an empty string if
+// the schema offers no mutations.
+func (s *Schema) MutationTypeName() string {
+	return s.rootTypeName("mutation")
+}
+
+// SubscriptionTypeName returns the name of the subscription root type of the schema, or an empty
+// string if the schema offers no subscriptions.
+func (s *Schema) SubscriptionTypeName() string {
+	return s.rootTypeName("subscription")
+}
+
+func (s *Schema) rootTypeName(operation string) string {
+	if t, ok := s.schema.EntryPoints[operation]; ok {
+		return t.TypeName()
+	}
+	return ""
 }
 
 // Validate validates the given query with the schema.
 func (s *Schema) Validate(queryString string) []*errors.QueryError {
-	doc, qErr := query.Parse(queryString)
+	doc, qErr := s.parse(queryString)
 	if qErr != nil {
 		return []*errors.QueryError{qErr}
 	}
 
-	return validation.Validate(s.schema, doc, nil, s.maxDepth, s.maxCost)
+	errs, _ := s.validate(doc, nil)
+	s.observeValidation(doc, queryString, "", errs)
+	return errs
+}
+
+// ValidateWithWarnings validates the given query with the schema like Validate and additionally
+// returns warnings that do not fail the query. There is a warning for every use of a deprecated
+// field, argument, input field or enum value, with the deprecation reason in the message and the
+// location of the use, and for the errors that the schema options downgrade, e.g. unused fragments
+// with AllowUnusedFragments. The rule of a warning is e.g. "DeprecatedField" or
+// "DeprecatedArgument".
+func (s *Schema) ValidateWithWarnings(queryString string) (errs []*errors.QueryError, warnings []*errors.QueryError) {
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return []*errors.QueryError{qErr}, nil
+	}
+
+	errs, warnings = s.validate(doc, nil)
+	if !s.deprecationWarnings {
+		warnings = append(warnings, validation.DeprecationWarnings(s.schema, doc)...)
+	}
+	s.observeValidation(doc, queryString, "", errs)
+	return errs, warnings
+}
+
+// parse parses the query string with the token limit of the schema.
+func (s *Schema) parse(queryString string) (*query.Document, *errors.QueryError) {
+	return query.ParseMaxTokens(queryString, s.maxTokens)
+}
+
+// validate validates the document and separates the errors that the schema options downgraded
+// to warnings.
+func (s *Schema) validate(doc *query.Document, variables map[string]interface{}) (errs []*errors.QueryError, warnings []*errors.QueryError) {
+	if s.maxFragmentExpansion > 0 {
+		// The expansion is checked first, since other rules expand fragments.
+		if errs := validation.ValidateMaxFragmentExpansion(doc, s.maxFragmentExpansion); len(errs) != 0 {
+			return errs, nil
+		}
+	}
+	maxDepth := s.maxDepth
+	var all []*errors.QueryError
+	if maxDepth > 0 && s.maxDepthExemptIntrospection {
+		// The depth is checked separately, so that introspection fields can be exempted.
+		all = validation.ValidateMaxDepth(s.schema, doc, maxDepth, true)
+		for _, err := range all {
+			if err.Rule == "MaxDepthExceeded" {
+				return all, nil
+			}
+		}
+		maxDepth = 0
+	}
+	all = append(all, validation.Validate(s.schema, doc, variables, maxDepth, s.maxCost)...)
+	if s.maxIntrospection > 0 {
+		all = append(all, validation.ValidateIntrospectionFields(doc, s.maxIntrospection)...)
+	}
+	if s.maxFieldCount > 0 {
+		all = append(all, validation.ValidateMaxFieldCount(s.schema, doc, s.maxFieldCount)...)
+	}
+	if s.maxAliases > 0 {
+		all = append(all, validation.ValidateMaxAliases(s.schema, doc, s.maxAliases)...)
+	}
+	for _, err := range all {
+		if s.allowUnusedFragments && err.Rule == "NoUnusedFragments" {
+			warnings = append(warnings, err)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	// Like the cost, the multiplier product is only checked for valid documents, as the
+	// estimation relies on the validity.
+	if s.maxMultiplierProduct > 0 && len(errs) == 0 {
+		errs = validation.ValidateMultiplierProduct(s.schema, doc, variables, s.maxMultiplierProduct)
+	}
+	if s.deprecationWarnings {
+		warnings = append(warnings, validation.DeprecationWarnings(s.schema, doc)...)
+	}
+	return errs, warnings
+}
+
+// observeValidation calls the validation observer, if any, with the errors of a failed validation.
+func (s *Schema) observeValidation(doc *query.Document, queryString string, operationName string, errs []*errors.QueryError) {
+	if s.validationObserver == nil || len(errs) == 0 {
+		return
+	}
+	failure := ValidationFailure{
+		Query:         queryString,
+		OperationName: operationName,
+		Errors:        append([]*errors.QueryError(nil), errs...),
+	}
+	if op, err := getOperation(doc, operationName); err == nil {
+		failure.OperationName = op.Name.Name
+		failure.OperationType = strings.ToLower(string(op.Type))
+	}
+	s.validationObserver(failure)
+}
+
+// ValidateVariables validates the given variables against the variable types declared by the given
+// operation of the query, without executing it. Scalar values are coerced like the executor coerces
+// them, so that e.g. a string given for an Int, a value rejected by a CustomScalar or a string
+// longer than MaxStringLength is reported. If the query contains more than one operation, the
+// operation name must be given.
+func (s *Schema) ValidateVariables(queryString string, operationName string, variables map[string]interface{}) []*errors.QueryError {
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return []*errors.QueryError{qErr}
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return []*errors.QueryError{errors.Errorf("%s", err)}
+	}
+
+	return validation.ValidateVariables(s.schema, op, variables, s.coerceScalar)
+}
+
+// coerceScalar returns the error of the executor for a value of the scalar type t, given for the
+// variable or input field decl. Strings are limited to the maximum length of decl, like they are
+// limited for the arguments that the variable is passed to.
+func (s *Schema) coerceScalar(decl *common.InputValue, t *schema.Scalar, value interface{}) error {
+	b := packer.NewBuilder()
+	b.StrictCoercion = s.schema.StrictCoercion
+	b.MaxStringLength = s.schema.MaxStringLength
+	p, err := b.MakePlainPacker(&common.InputValue{Name: decl.Name, Type: t, Directives: decl.Directives})
+	if err != nil {
+		return err
+	}
+	if err := b.Finish(); err != nil {
+		return err
+	}
+	_, err = p.Pack(value)
+	return err
+}
+
+// FragmentTypes returns, for each field of an interface or union type selected by the given
+// operation of the query, the names of the concrete types that its fragments select fields of.
+// The fields are keyed by their response path, with the aliases separated by dots. Fragments that
+// are skipped by @skip or @include with the given variables are not taken into account. If the
+// query contains more than one operation, the operation name must be given.
+func (s *Schema) FragmentTypes(queryString string, operationName string, variables map[string]interface{}) (map[string][]string, error) {
+	if s.res.Resolver == (reflect.Value{}) {
+		panic("schema created without resolver, can not get fragment types")
+	}
+
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	if errs, _ := s.validate(doc, variables); len(errs) != 0 {
+		return nil, errs[0]
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return nil, err
+	}
+
+	if variables == nil {
+		variables = make(map[string]interface{}, len(op.Vars))
+	}
+	for _, v := range op.Vars {
+		if _, ok := variables[v.Name.Name]; !ok && v.Default != nil {
+			variables[v.Name.Name] = v.Default.Value(nil)
+		}
+	}
+
+	r := &selected.Request{
+		Doc:               doc,
+		Vars:              variables,
+		Schema:            s.schema,
+		DirectiveHandlers: s.directiveHandlers,
+	}
+	sels := selected.ApplyOperation(r, s.res, op)
+	if len(r.Errs) != 0 {
+		return nil, r.Errs[0]
+	}
+	return selected.FragmentTypes(sels), nil
+}
+
+// Depth returns the depth of the given operation of the query, as it is checked by MaxDepth, i.e.
+// without the introspection fields if MaxDepthExemptIntrospection is set. If the query contains more
+// than one operation, the operation name must be given.
+func (s *Schema) Depth(queryString string, operationName string) (int, error) {
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return 0, qErr
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return 0, err
+	}
+
+	return validation.OperationDepth(doc, op, s.maxDepthExemptIntrospection), nil
+}
+
+// EstimateQueryCost parses and validates the query and returns the estimated cost of the given
+// operation, as computed from the @cost directives of the schema, without enforcing any limit. It
+// can be used to log the cost of requests or to apply budgets that change at runtime. If the query
+// contains more than one operation, the operation name must be given. The first validation error
+// is returned, but the limits of the schema options, like MaxDepth and MaxQueryCost, are not
+// checked.
+func EstimateQueryCost(s *Schema, queryString string, operationName string, variables map[string]interface{}) (int, error) {
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return 0, qErr
+	}
+	if errs := validation.Validate(s.schema, doc, variables, 0, 0); len(errs) != 0 {
+		return 0, errs[0]
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return 0, err
+	}
+
+	cost, errs := validation.EstimateCost(s.schema, doc, op, variables)
+	if len(errs) != 0 {
+		return 0, errs[0]
+	}
+	return cost, nil
+}
+
+// EstimateOperationCosts is like EstimateQueryCost, but returns the estimated costs of all
+// operations of the query, keyed by operation name. An anonymous operation is keyed by the empty
+// string.
+func EstimateOperationCosts(s *Schema, queryString string, variables map[string]interface{}) (map[string]int, error) {
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return nil, qErr
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("no operations in query document")
+	}
+	if errs := validation.Validate(s.schema, doc, variables, 0, 0); len(errs) != 0 {
+		return nil, errs[0]
+	}
+
+	// The validation rejects operations with the same name, so that each one has its own key.
+	costs := make(map[string]int, len(doc.Operations))
+	for _, op := range doc.Operations {
+		cost, errs := validation.EstimateCost(s.schema, doc, op, variables)
+		if len(errs) != 0 {
+			return nil, errs[0]
+		}
+		costs[op.Name.Name] = cost
+	}
+	return costs, nil
+}
+
+// CostCoverage returns the coordinates (e.g. "Query.users") of all fields of the schema that are
+// not annotated with @cost, neither directly nor through an implemented interface, their type or
+// the schema definition. It can be used to ensure that new fields get a cost annotation.
+func (s *Schema) CostCoverage() []string {
+	return validation.CostCoverage(s.schema, false)
+}
+
+// CompositeCostCoverage is like CostCoverage, but only reports fields that return an object,
+// interface or union type, or a list of them.
+func (s *Schema) CompositeCostCoverage() []string {
+	return validation.CostCoverage(s.schema, true)
 }
 
 // Exec executes the given query with the schema's resolver. It panics if the schema was created
@@ -166,36 +990,63 @@ func (s *Schema) Exec(ctx context.Context, queryString string, operationName str
 }
 
 func (s *Schema) exec(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema) *Response {
-	doc, qErr := query.Parse(queryString)
+	resp, _ := s.execute(ctx, queryString, operationName, variables, res, false)
+	return resp
+}
+
+// execute executes the query and returns the request, which is nil if the execution did not start.
+// If incremental is set, fragments with the @defer directive and list items streamed with the
+// @stream directive are left to r.ExecuteDeferred.
+func (s *Schema) execute(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema, incremental bool) (resp *Response, r *exec.Request) {
+	phases := trace.QueryPhases{Start: time.Now()}
+	doc, qErr := s.parse(queryString)
 	if qErr != nil {
-		return &Response{Errors: []*errors.QueryError{qErr}}
+		return &Response{Errors: errors.SetPhase([]*errors.QueryError{qErr}, errors.PhaseParse)}, nil
 	}
+	phases.Parsing = time.Since(phases.Start)
 
-	validationFinish := s.validationTracer.TraceValidation()
-	errs := validation.Validate(s.schema, doc, variables, s.maxDepth, s.maxCost)
-	validationFinish(errs)
-	if len(errs) != 0 {
-		return &Response{Errors: errs}
+	return s.executeDocument(ctx, phases, queryString, doc, operationName, variables, res, incremental, true)
+}
+
+// executeDocument validates the parsed document, unless validate is false, and executes the given
+// operation of it.
+func (s *Schema) executeDocument(ctx context.Context, phases trace.QueryPhases, queryString string, doc *query.Document, operationName string, variables map[string]interface{}, res *resolvable.Schema, incremental bool, validate bool) (resp *Response, r *exec.Request) {
+	var warnings []*errors.QueryError
+	if validate {
+		validationFinish := s.validationTracer.TraceValidation()
+		var errs []*errors.QueryError
+		errs, warnings = s.validate(doc, variables)
+		validationFinish(errs)
+		phases.Validation = time.Since(phases.Start) - phases.Parsing
+		s.observeValidation(doc, queryString, operationName, errs)
+		if len(errs) != 0 {
+			s.addOperationToErrors(doc, operationName, errs)
+			return &Response{Errors: errors.SetPhase(errs, errors.PhaseValidate)}, nil
+		}
 	}
 
 	op, err := getOperation(doc, operationName)
 	if err != nil {
-		return &Response{Errors: []*errors.QueryError{errors.Errorf("%s", err)}}
+		return &Response{Errors: errors.SetPhase([]*errors.QueryError{errors.Errorf("%s", err)}, errors.PhaseValidate)}, nil
 	}
 
-	// If the optional "operationName" POST parameter is not provided then
-	// use the query's operation name for improved tracing.
-	if operationName == "" {
-		operationName = op.Name.Name
-	}
+	return s.executeOperation(ctx, phases, queryString, doc, op, variables, warnings, res, incremental, nil)
+}
+
+// executeOperation executes a parsed and validated operation. If plan is not nil, it is used
+// instead of applying the selections of the operation.
+func (s *Schema) executeOperation(ctx context.Context, phases trace.QueryPhases, queryString string, doc *query.Document, op *query.Operation, variables map[string]interface{}, warnings []*errors.QueryError, res *resolvable.Schema, incremental bool, plan *selected.Plan) (resp *Response, r *exec.Request) {
+	// The query's operation name is used for improved tracing, also if the optional
+	// "operationName" POST parameter is not provided.
+	operationName := op.Name.Name
 
 	// Subscriptions are not valid in Exec. Use schema.Subscribe() instead.
 	if op.Type == query.Subscription {
-		return &Response{Errors: []*errors.QueryError{&errors.QueryError{Message: "graphql-ws protocol header is missing"}}}
+		return &Response{Errors: []*errors.QueryError{{Message: "graphql-ws protocol header is missing", Phase: errors.PhaseValidate}}}, nil
 	}
 	if op.Type == query.Mutation {
 		if _, ok := s.schema.EntryPoints["mutation"]; !ok {
-			return &Response{Errors: []*errors.QueryError{{Message: "no mutations are offered by the schema"}}}
+			return &Response{Errors: []*errors.QueryError{{Message: "no mutations are offered by the schema", Phase: errors.PhaseValidate}}}, nil
 		}
 	}
 
@@ -209,33 +1060,117 @@ func (s *Schema) exec(ctx context.Context, queryString string, operationName str
 		}
 	}
 
-	r := &exec.Request{
+	r = &exec.Request{
 		Request: selected.Request{
 			Doc:                  doc,
 			Vars:                 variables,
 			Schema:               s.schema,
-			DisableIntrospection: s.disableIntrospection,
+			DisableIntrospection: s.introspectionDisabled(ctx),
+			FieldVisible:         visibleFields(ctx),
+			DirectiveHandlers:    s.directiveHandlers,
+			Incremental:          incremental,
+			Authorization:        s.authorization,
+			Roles:                rolesOf(ctx),
 		},
-		Limiter: make(chan struct{}, s.maxParallelism),
-		Tracer:  s.tracer,
-		Logger:  s.logger,
+		Limiter:               make(chan struct{}, s.maxParallelism),
+		GlobalLimiter:         s.globalLimiter,
+		Tracer:                s.tracer,
+		Logger:                s.logger,
+		NonFiniteFloatsAsNull: s.nonFiniteFloatsAsNull,
+		IncludeErrorLocations: s.includeErrorLocations,
+		NonNullError:          s.nonNullError,
+		ResolverErrorMapper:   s.resolverErrorMapper,
+		ExecutionTimeout:      s.executionTimeout,
+		BatchScheduler:        s.batchScheduler,
+		Plan:                  plan,
+	}
+	if s.cacheControl {
+		r.CacheControl = &exec.CacheControl{DefaultMaxAge: s.defaultMaxAge}
+	}
+	if s.timingTree {
+		r.Timing = &exec.Timing{}
+	}
+	if s.actualCost || s.maxActualCost > 0 {
+		r.ActualCost = &exec.ActualCost{}
+	}
+	if s.operationInErrors {
+		r.ErrorExtensions = operationExtensions(op)
 	}
 	varTypes := make(map[string]*introspection.Type)
 	for _, v := range op.Vars {
 		t, err := common.ResolveType(v.Type, s.schema.Resolve)
 		if err != nil {
-			return &Response{Errors: []*errors.QueryError{err}}
+			return &Response{Errors: errors.SetPhase([]*errors.QueryError{err}, errors.PhaseValidate)}, nil
 		}
 		varTypes[v.Name.Name] = introspection.WrapType(t)
 	}
-	traceCtx, finish := s.tracer.TraceQuery(ctx, queryString, operationName, variables, varTypes)
+	if r.CacheControl != nil {
+		ctx = exec.WithCacheControl(ctx, r.CacheControl)
+	}
+	traceCtx, finish := s.tracer.TraceQuery(trace.ContextWithQueryPhases(ctx, phases), queryString, operationName, variables, varTypes)
 	data, errs := r.Execute(traceCtx, res, op)
 	finish(errs)
 
-	return &Response{
+	resp = &Response{
 		Data:   data,
-		Errors: errs,
+		Errors: errors.SetPhase(errs, errors.PhaseExecute),
+	}
+	if s.reportDepth {
+		resp.Extensions = map[string]interface{}{"depth": validation.OperationDepth(doc, op, s.maxDepthExemptIntrospection)}
+	}
+	if r.ActualCost != nil {
+		cost := r.ActualCost.Total()
+		if s.actualCost {
+			if resp.Extensions == nil {
+				resp.Extensions = make(map[string]interface{})
+			}
+			resp.Extensions["actualCost"] = cost
+		}
+		if s.maxActualCost > 0 && cost > s.maxActualCost {
+			resp.Data = nil
+			resp.Errors = []*errors.QueryError{{
+				Message:    fmt.Sprintf("The actual query cost is too high. Permitted: %d, was: %d", s.maxActualCost, cost),
+				Extensions: map[string]interface{}{"actualCost": cost, "maxActualCost": s.maxActualCost},
+				Phase:      errors.PhaseExecute,
+			}}
+		}
+	}
+	if len(warnings) != 0 {
+		if resp.Extensions == nil {
+			resp.Extensions = make(map[string]interface{})
+		}
+		resp.Extensions["warnings"] = warnings
+	}
+	if et, ok := s.tracer.(trace.ExtensionsTracer); ok {
+		for k, v := range et.Extensions(traceCtx) {
+			if resp.Extensions == nil {
+				resp.Extensions = make(map[string]interface{})
+			}
+			resp.Extensions[k] = v
+		}
+	}
+	if r.CacheControl != nil {
+		maxAge, private := r.CacheControl.Result()
+		resp.CachePolicy = &CachePolicy{MaxAge: maxAge, Scope: CacheScopePublic, Entities: r.CacheControl.Entities()}
+		if private {
+			resp.CachePolicy.Scope = CacheScopePrivate
+		}
+	}
+	if r.Timing != nil {
+		resp.Timing = r.Timing.Tree()
+	}
+	return resp, r
+}
+
+func (s *Schema) bindScalarCoercions() error {
+	for name, c := range s.scalarCoercions {
+		t, ok := s.schema.Types[name].(*schema.Scalar)
+		if !ok {
+			return fmt.Errorf("can not bind coercion to %q: not a scalar type of the schema", name)
+		}
+		t.Coercion = c
 	}
+	return nil
 }
 
 func (s *Schema) validateSchema() error {
@@ -254,9 +1189,199 @@ func (s *Schema) validateSchema() error {
 	if err := validateRootOp(s.schema, "subscription", false); err != nil {
 		return err
 	}
+	if err := s.validateAuthorization(); err != nil {
+		return err
+	}
+	return validateCostDirectives(s.schema)
+}
+
+// validateAuthorization checks that the directive of FieldAuthorization is declared with a "role"
+// argument of type String.
+func (s *Schema) validateAuthorization() error {
+	if s.authorization == nil {
+		return nil
+	}
+	name := s.authorization.Directive
+	d, ok := s.schema.Directives[name]
+	if !ok {
+		return fmt.Errorf("graphql: directive @%s of FieldAuthorization is not declared in the schema", name)
+	}
+	role := d.Args.Get("role")
+	if role == nil {
+		return fmt.Errorf("graphql: directive @%s of FieldAuthorization has no \"role\" argument", name)
+	}
+	t := role.Type
+	if nn, ok := t.(*common.NonNull); ok {
+		t = nn.OfType
+	}
+	if t.String() != "String" {
+		return fmt.Errorf("graphql: argument \"role\" of directive @%s must be a String, got %s", name, role.Type)
+	}
 	return nil
 }
 
+// validateCostDirectives checks that the @cost directives of the schema can not make cost
+// estimates negative or refer to blank or non-numeric multipliers.
+func validateCostDirectives(s *schema.Schema) error {
+	validateFields := func(typeName string, fields schema.FieldList) error {
+		for _, f := range fields {
+			if err := validateCostDirective(typeName+"."+f.Name, f.Directives); err != nil {
+				return err
+			}
+			if err := validateCostInputValues(typeName+"."+f.Name, f.Args); err != nil {
+				return err
+			}
+			if err := validateCostMultipliers(typeName+"."+f.Name, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := validateCostDirective("schema", s.SchemaDirectives); err != nil {
+		return err
+	}
+	// The types are checked in the order of their names, so that the same error is returned for a
+	// schema with more than one invalid directive every time.
+	names := make([]string, 0, len(s.Types))
+	for name := range s.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch t := s.Types[name].(type) {
+		case *schema.Scalar:
+			if err := validateCostDirective(name, t.Directives); err != nil {
+				return err
+			}
+		case *schema.Object:
+			if err := validateCostDirective(name, t.Directives); err != nil {
+				return err
+			}
+			if err := validateFields(name, t.Fields); err != nil {
+				return err
+			}
+		case *schema.Interface:
+			if err := validateCostDirective(name, t.Directives); err != nil {
+				return err
+			}
+			if err := validateFields(name, t.Fields); err != nil {
+				return err
+			}
+		case *schema.Union:
+			if err := validateCostDirective(name, t.Directives); err != nil {
+				return err
+			}
+		case *schema.Enum:
+			if err := validateCostDirective(name, t.Directives); err != nil {
+				return err
+			}
+			for _, v := range t.Values {
+				if err := validateCostDirective(name+"."+v.Name, v.Directives); err != nil {
+					return err
+				}
+			}
+		case *schema.InputObject:
+			if err := validateCostDirective(name, t.Directives); err != nil {
+				return err
+			}
+			if err := validateCostInputValues(name, t.Values); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateCostInputValues(parent string, values common.InputValueList) error {
+	for _, v := range values {
+		if err := validateCostDirective(parent+"."+v.Name.Name, v.Directives); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateCostDirective(location string, directives common.DirectiveList) error {
+	d := directives.Get("cost")
+	if d == nil {
+		return nil
+	}
+	for _, name := range []string{"complexity", "assumedSize"} {
+		if lit, ok := d.Args.Get(name); ok && lit != nil {
+			if v, ok := lit.Value(map[string]interface{}{}).(int32); ok && v < 0 {
+				return fmt.Errorf("@cost on %s: %s must not be negative, got %d", location, name, v)
+			}
+		}
+	}
+	if lit, ok := d.Args.Get("multipliers"); ok && lit != nil {
+		multipliers, _ := lit.Value(map[string]interface{}{}).([]interface{})
+		for _, m := range multipliers {
+			if name, ok := m.(string); ok && strings.TrimSpace(name) == "" {
+				return fmt.Errorf("@cost on %s: multiplier names must not be blank", location)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCostMultipliers checks that the multipliers of the @cost directive of a field refer to
+// arguments, or fields of input object arguments, of type Int or Float. Multipliers that refer to
+// unknown arguments are ignored by the cost estimation and not checked.
+func validateCostMultipliers(location string, f *schema.Field) error {
+	d := f.Directives.Get("cost")
+	if d == nil {
+		return nil
+	}
+	lit, ok := d.Args.Get("multipliers")
+	if !ok || lit == nil {
+		return nil
+	}
+	multipliers, _ := lit.Value(map[string]interface{}{}).([]interface{})
+	for _, m := range multipliers {
+		path, ok := m.(string)
+		if !ok {
+			continue
+		}
+		t := multiplierType(f.Args, path)
+		if t == nil {
+			continue
+		}
+		if nn, ok := t.(*common.NonNull); ok {
+			t = nn.OfType
+		}
+		if scalar, ok := t.(*schema.Scalar); ok && (scalar.Name == "Int" || scalar.Name == "Float") {
+			continue
+		}
+		return fmt.Errorf("@cost on %s: multiplier %q refers to an argument of type %q, but must be Int or Float", location, path, t)
+	}
+	return nil
+}
+
+// multiplierType returns the type of the argument, or of the field of an input object argument,
+// that a multiplier path like "page.first" refers to, or nil if there is none.
+func multiplierType(args common.InputValueList, path string) common.Type {
+	var t common.Type
+	for i, name := range strings.Split(path, ".") {
+		if i > 0 {
+			if nn, ok := t.(*common.NonNull); ok {
+				t = nn.OfType
+			}
+			obj, ok := t.(*schema.InputObject)
+			if !ok {
+				return nil
+			}
+			args = obj.Values
+		}
+		v := args.Get(name)
+		if v == nil {
+			return nil
+		}
+		t = v.Type
+	}
+	return t
+}
+
 func validateRootOp(s *schema.Schema, name string, mandatory bool) error {
 	t, ok := s.EntryPoints[name]
 	if !ok {
@@ -291,3 +1416,28 @@ func getOperation(document *query.Document, operationName string) (*query.Operat
 	}
 	return op, nil
 }
+
+// operationExtensions returns the error extensions that identify op, see OperationInErrors.
+func operationExtensions(op *query.Operation) map[string]interface{} {
+	info := map[string]interface{}{"type": strings.ToLower(string(op.Type))}
+	if op.Name.Name != "" {
+		info["name"] = op.Name.Name
+	}
+	return map[string]interface{}{"operation": info}
+}
+
+// addOperationToErrors adds the operation to the extensions of validation errors if the
+// OperationInErrors option is set and the operation can be determined.
+func (s *Schema) addOperationToErrors(doc *query.Document, operationName string, errs []*errors.QueryError) {
+	if !s.operationInErrors {
+		return
+	}
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return
+	}
+	ext := operationExtensions(op)
+	for _, err := range errs {
+		err.Extensions = selected.MergeExtensions(err.Extensions, ext)
+	}
+}
@@ -1,16 +1,29 @@
 package graphql_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/graph-gophers/graphql-go"
 	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/example/dataloader"
 	"github.com/graph-gophers/graphql-go/example/starwars"
 	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/graph-gophers/graphql-go/trace"
 )
 
 type helloWorldResolver1 struct{}
@@ -1355,6 +1368,105 @@ func TestVariables(t *testing.T) {
 	})
 }
 
+type paletteInput struct {
+	Primary string
+	Colors  *[]string
+	Inner   *paletteInput
+}
+
+type paletteResolver struct{}
+
+func (*paletteResolver) Paint(args struct {
+	Palette *paletteInput
+	Color   *string
+	Colors  *[]string
+}) string {
+	return "painted"
+}
+
+func TestNestedEnumVariables(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		enum Color {
+			RED
+			GREEN
+		}
+
+		input Palette {
+			primary: Color!
+			colors: [Color!]
+			inner: Palette
+		}
+
+		type Query {
+			paint(palette: Palette, color: Color, colors: [Color!]): String!
+		}
+	`, &paletteResolver{})
+
+	variableError := func(message string) []*gqlerrors.QueryError {
+		return []*gqlerrors.QueryError{{
+			Message:   message,
+			Locations: []gqlerrors.Location{{Line: 1, Column: 7}},
+			Rule:      "VariablesOfCorrectType",
+		}}
+	}
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query:  `query($p: Palette, $c: Color, $l: [Color!]) { paint(palette: $p, color: $c, colors: $l) }`,
+			Variables: map[string]interface{}{
+				"p": map[string]interface{}{
+					"primary": "RED",
+					"colors":  "GREEN",
+					"inner":   map[string]interface{}{"primary": "GREEN", "colors": []interface{}{"RED", "GREEN"}},
+				},
+				"c": "GREEN",
+				"l": []interface{}{"RED"},
+			},
+			ExpectedResult: `{"paint": "painted"}`,
+		},
+		{
+			Schema:         schema,
+			Query:          `query($c: Color) { paint(color: $c) }`,
+			Variables:      map[string]interface{}{"c": "BLUE"},
+			ExpectedErrors: variableError("Variable \"c\" has invalid value BLUE.\nExpected type \"Color\", found BLUE."),
+		},
+		{
+			Schema:         schema,
+			Query:          `query($l: [Color!]) { paint(colors: $l) }`,
+			Variables:      map[string]interface{}{"l": []interface{}{"RED", "BLUE"}},
+			ExpectedErrors: variableError("Variable \"l\" has invalid value BLUE at \"l[1]\".\nExpected type \"Color\", found BLUE."),
+		},
+		{
+			Schema:         schema,
+			Query:          `query($p: Palette) { paint(palette: $p) }`,
+			Variables:      map[string]interface{}{"p": map[string]interface{}{"primary": "BLUE"}},
+			ExpectedErrors: variableError("Variable \"p\" has invalid value BLUE at \"p.primary\".\nExpected type \"Color\", found BLUE."),
+		},
+		{
+			Schema: schema,
+			Query:  `query($p: Palette) { paint(palette: $p) }`,
+			Variables: map[string]interface{}{"p": map[string]interface{}{
+				"primary": "RED",
+				"inner":   map[string]interface{}{"primary": "GREEN", "colors": []interface{}{"GREEN", "BLUE"}},
+			}},
+			ExpectedErrors: variableError("Variable \"p\" has invalid value BLUE at \"p.inner.colors[1]\".\nExpected type \"Color\", found BLUE."),
+		},
+		{
+			Schema: schema,
+			Query:  `query($p: Palette) { paint(palette: $p) }`,
+			Variables: map[string]interface{}{"p": map[string]interface{}{
+				"primary": "RED",
+				"inner":   map[string]interface{}{"colors": []interface{}{"GREEN"}},
+			}},
+			ExpectedErrors: variableError("Variable \"p\" has invalid value null at \"p.inner.primary\".\nExpected type \"Color!\", found null."),
+		},
+	})
+}
+
 func TestSkipDirective(t *testing.T) {
 	gqltesting.RunTests(t, []*gqltesting.Test{
 		{
@@ -1599,6 +1711,90 @@ func TestDeprecatedDirective(t *testing.T) {
 	})
 }
 
+type deprecatedEnumResolver struct{}
+
+func (r *deprecatedEnumResolver) Echo(args struct{ Size string }) string {
+	return args.Size
+}
+
+func (r *deprecatedEnumResolver) Default() string {
+	return "HUGE"
+}
+
+func TestDeprecatedEnumValues(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			echo(size: Size!): Size!
+			default: Size!
+		}
+
+		enum Size {
+			SMALL
+			LARGE
+			HUGE @deprecated(reason: "Use LARGE.")
+		}
+	`, &deprecatedEnumResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					__type(name: "Size") {
+						defaultValues: enumValues {
+							name
+						}
+						activeValues: enumValues(includeDeprecated: false) {
+							name
+						}
+						allValues: enumValues(includeDeprecated: true) {
+							name
+							isDeprecated
+							deprecationReason
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"__type": {
+						"defaultValues": [{"name": "SMALL"}, {"name": "LARGE"}],
+						"activeValues": [{"name": "SMALL"}, {"name": "LARGE"}],
+						"allValues": [
+							{"name": "SMALL", "isDeprecated": false, "deprecationReason": null},
+							{"name": "LARGE", "isDeprecated": false, "deprecationReason": null},
+							{"name": "HUGE", "isDeprecated": true, "deprecationReason": "Use LARGE."}
+						]
+					}
+				}
+			`,
+		},
+		{
+			// Deprecated values are still valid input and output.
+			Schema: schema,
+			Query: `
+				query($size: Size!) {
+					literal: echo(size: HUGE)
+					variable: echo(size: $size)
+					default
+				}
+			`,
+			Variables: map[string]interface{}{"size": "HUGE"},
+			ExpectedResult: `
+				{
+					"literal": "HUGE",
+					"variable": "HUGE",
+					"default": "HUGE"
+				}
+			`,
+		},
+	})
+}
+
 type testBadEnumResolver struct{}
 
 func (r *testBadEnumResolver) Hero() *testBadEnumCharacterResolver {
@@ -2489,6 +2685,8 @@ func TestIntrospection(t *testing.T) {
 									"description": "Marks an element of a GraphQL schema as no longer supported.",
 									"locations": [
 										"FIELD_DEFINITION",
+										"ARGUMENT_DEFINITION",
+										"INPUT_FIELD_DEFINITION",
 										"ENUM_VALUE"
 									],
 									"args": [
@@ -2524,6 +2722,14 @@ func TestIntrospection(t *testing.T) {
 										}
 									]
 								},
+								{
+									"name": "oneOf",
+									"description": "Indicates exactly one field must be supplied and this field must not be ` + "`" + `null` + "`" + `.",
+									"locations": [
+										"INPUT_OBJECT"
+									],
+									"args": []
+								},
 								{
 									"name": "skip",
 									"description": "Directs the executor to skip this field or fragment when the ` + "`" + `if` + "`" + ` argument is true.",
@@ -3265,6 +3471,160 @@ func TestInputArguments_failSchemaParsing(t *testing.T) {
 	}
 }
 
+type signatureContextLast struct{}
+
+func (r *signatureContextLast) Hello(args struct{ Name string }, ctx context.Context) string {
+	return "Hello " + args.Name + "!"
+}
+
+type signatureMissingArgs struct{}
+
+func (r *signatureMissingArgs) Hello(ctx context.Context) string {
+	return "Hello!"
+}
+
+type signatureUnexpectedArgs struct{}
+
+func (r *signatureUnexpectedArgs) Hello(args struct{ Name string }) string {
+	return "Hello " + args.Name + "!"
+}
+
+type signatureTooManyResults struct{}
+
+func (r *signatureTooManyResults) Hello() (string, bool, error) {
+	return "Hello!", true, nil
+}
+
+type signatureWrongError struct{}
+
+func (r *signatureWrongError) Hello() (string, bool) {
+	return "Hello!", true
+}
+
+const signatureSupported = "\n\tsupported signatures, each returning T, (T, error) or (T, *errors.QueryError):"
+
+func TestResolverSignature_failSchemaParsing(t *testing.T) {
+	withArgs := `
+		schema {
+			query: Query
+		}
+		type Query {
+			hello(name: String!): String!
+		}
+	`
+	withoutArgs := `
+		schema {
+			query: Query
+		}
+		type Query {
+			hello: String!
+		}
+	`
+	argsSignatures := signatureSupported +
+		"\n\t\tfunc(context.Context, args, graphql.SelectedFields)" +
+		"\n\t\tfunc(context.Context, args)" +
+		"\n\t\tfunc(args, graphql.SelectedFields)" +
+		"\n\t\tfunc(args)"
+	noArgsSignatures := signatureSupported +
+		"\n\t\tfunc(context.Context, graphql.SelectedFields)" +
+		"\n\t\tfunc(context.Context)" +
+		"\n\t\tfunc(graphql.SelectedFields)" +
+		"\n\t\tfunc()"
+
+	for name, tt := range map[string]struct {
+		resolver interface{}
+		schema   string
+		want     string
+	}{
+		"context after the arguments": {
+			resolver: &signatureContextLast{},
+			schema:   withArgs,
+			want: `resolver of field "Query.hello" has an unsupported signature func(struct { Name string }, context.Context) string: context.Context must be the first parameter` +
+				argsSignatures + "\n\tused by (*graphql_test.signatureContextLast).Hello",
+		},
+		"missing arguments": {
+			resolver: &signatureMissingArgs{},
+			schema:   withArgs,
+			want: `resolver of field "Query.hello" has an unsupported signature func(context.Context) string: missing the parameter for the field arguments` +
+				argsSignatures + "\n\tused by (*graphql_test.signatureMissingArgs).Hello",
+		},
+		"arguments for a field without arguments": {
+			resolver: &signatureUnexpectedArgs{},
+			schema:   withoutArgs,
+			want: `resolver of field "Query.hello" has an unsupported signature func(struct { Name string }) string: unexpected parameter of type struct { Name string }, the field has no arguments` +
+				noArgsSignatures + "\n\tused by (*graphql_test.signatureUnexpectedArgs).Hello",
+		},
+		"too many results": {
+			resolver: &signatureTooManyResults{},
+			schema:   withoutArgs,
+			want: `resolver of field "Query.hello" has an unsupported signature func() (string, bool, error): too many return values` +
+				noArgsSignatures + "\n\tused by (*graphql_test.signatureTooManyResults).Hello",
+		},
+		"second result is not an error": {
+			resolver: &signatureWrongError{},
+			schema:   withoutArgs,
+			want: `resolver of field "Query.hello" has an unsupported signature func() (string, bool): must have "error" or "*errors.QueryError" as its last return value` +
+				noArgsSignatures + "\n\tused by (*graphql_test.signatureWrongError).Hello",
+		},
+	} {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := graphql.ParseSchema(tt.schema, tt.resolver)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got: %v\nwant: %s", err, tt.want)
+			}
+		})
+	}
+}
+
+type signatureVariants struct{}
+
+func (r *signatureVariants) A(ctx context.Context, args struct{ Name string }, fields graphql.SelectedFields) (string, error) {
+	return "a " + args.Name, nil
+}
+
+func (r *signatureVariants) B(ctx context.Context, args struct{ Name string }) (string, error) {
+	return "b " + args.Name, nil
+}
+
+func (r *signatureVariants) C(args struct{ Name string }) string {
+	return "c " + args.Name
+}
+
+func (r *signatureVariants) D(ctx context.Context) (string, *gqlerrors.QueryError) {
+	return "d", nil
+}
+
+func (r *signatureVariants) E(fields graphql.SelectedFields) string {
+	return "e"
+}
+
+func (r *signatureVariants) F() string {
+	return "f"
+}
+
+func TestResolverSignatureVariants(t *testing.T) {
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema: graphql.MustParseSchema(`
+			schema {
+				query: Query
+			}
+			type Query {
+				a(name: String!): String!
+				b(name: String!): String!
+				c(name: String!): String!
+				d: String!
+				e: String!
+				f: String!
+			}
+		`, &signatureVariants{}),
+		Query:          `{ a(name: "x") b(name: "y") c(name: "z") d e f }`,
+		ExpectedResult: `{"a": "a x", "b": "b y", "c": "c z", "d": "d", "e": "e", "f": "f"}`,
+	})
+}
+
 func TestComposedFragments(t *testing.T) {
 	gqltesting.RunTests(t, []*gqltesting.Test{
 		{
@@ -3711,6 +4071,93 @@ func TestPanicAmbiguity(t *testing.T) {
 	graphql.MustParseSchema(schema, &ambiguousResolver{}, graphql.UseFieldResolvers())
 }
 
+type searchQueryResolver struct{}
+
+func (r *searchQueryResolver) Search() []*searchResultResolver {
+	return nil
+}
+
+type searchResultResolver struct{}
+
+func (r *searchResultResolver) ToHuman() (*searchHumanResolver, bool) {
+	return &searchHumanResolver{}, true
+}
+
+type searchHumanResolver struct{}
+
+func (r *searchHumanResolver) Name() string {
+	return "Luke"
+}
+
+type searchDroidResolver struct{}
+
+func (r *searchDroidResolver) Name() string {
+	return "R2-D2"
+}
+
+type invalidSearchResultResolver struct {
+	searchResultResolver
+}
+
+func (r *invalidSearchResultResolver) ToDroid(name string) (*searchDroidResolver, bool) {
+	return &searchDroidResolver{}, true
+}
+
+type invalidSearchQueryResolver struct{}
+
+func (r *invalidSearchQueryResolver) Search() []*invalidSearchResultResolver {
+	return nil
+}
+
+func TestTypeAssertionMethods(t *testing.T) {
+	const schema = `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			search: [SearchResult!]!
+		}
+
+		union SearchResult = Human | Droid
+
+		type Human {
+			name: String!
+		}
+
+		type Droid {
+			name: String!
+		}
+	`
+
+	for _, tc := range []struct {
+		name     string
+		resolver interface{}
+		wantErr  string
+	}{
+		{
+			name:     "missing method",
+			resolver: &searchQueryResolver{},
+			wantErr:  `*graphql_test.searchResultResolver does not resolve "SearchResult": missing method "ToDroid" to convert to "Droid"`,
+		},
+		{
+			name:     "invalid signature",
+			resolver: &invalidSearchQueryResolver{},
+			wantErr:  `*graphql_test.invalidSearchResultResolver does not resolve "SearchResult": method "ToDroid" should take no arguments and return a value and a bool indicating success`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := graphql.ParseSchema(schema, tc.resolver)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), tc.wantErr) {
+				t.Errorf("got error %q, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 func TestSchema_Exec_without_resolver(t *testing.T) {
 	t.Parallel()
 
@@ -3801,3 +4248,5975 @@ func TestSubscriptions_In_Exec(t *testing.T) {
 		},
 	})
 }
+
+type concurrencyResolver struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (r *concurrencyResolver) Slow(ctx context.Context) (int32, error) {
+	r.mu.Lock()
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return 1, nil
+}
+
+func TestMaxGlobalParallelism(t *testing.T) {
+	r := &concurrencyResolver{}
+	s := graphql.MustParseSchema(`
+		type Query {
+			slow: Int!
+		}
+	`, r, graphql.MaxParallelism(10), graphql.MaxGlobalParallelism(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := s.Exec(context.Background(), `{ a: slow b: slow c: slow d: slow }`, "", nil)
+			if len(res.Errors) != 0 {
+				t.Error(res.Errors)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if r.max != 2 {
+		t.Fatalf("expected 2 resolvers to run in parallel across requests, got %d", r.max)
+	}
+}
+
+func TestReportDepth(t *testing.T) {
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.ReportDepth())
+
+	query := `
+		query {
+			hero {
+				name
+				friends {
+					name
+				}
+			}
+		}
+	`
+	res := s.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if res.Extensions["depth"] != 3 {
+		t.Fatalf("expected depth 3 in extensions, got %v", res.Extensions["depth"])
+	}
+
+	depth, err := s.Depth(query, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth != 3 {
+		t.Fatalf("expected depth 3, got %d", depth)
+	}
+
+	// The introspection fields are not counted if they are exempted from MaxDepth.
+	s = graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.ReportDepth(), graphql.MaxDepth(3), graphql.MaxDepthExemptIntrospection())
+	query = `{ hero { __typename name } __schema { types { fields { name } } } }`
+	res = s.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if res.Extensions["depth"] != 2 {
+		t.Fatalf("expected depth 2 in extensions, got %v", res.Extensions["depth"])
+	}
+	if depth, err := s.Depth(query, ""); err != nil || depth != 2 {
+		t.Fatalf("expected depth 2, got %d (%v)", depth, err)
+	}
+}
+
+type petResolver interface {
+	Name() string
+	ToDog() (*dogResolver, bool)
+}
+
+type dogResolver struct {
+	name string
+}
+
+func (r *dogResolver) Name() string {
+	return r.name
+}
+
+func (r *dogResolver) ToDog() (*dogResolver, bool) {
+	return r, true
+}
+
+type pointerToInterfaceResolver struct{}
+
+func (r *pointerToInterfaceResolver) Iface() petResolver {
+	return &dogResolver{name: "iface"}
+}
+
+func (r *pointerToInterfaceResolver) PtrToIface() *petResolver {
+	var p petResolver = &dogResolver{name: "ptr"}
+	return &p
+}
+
+func (r *pointerToInterfaceResolver) Concrete() *dogResolver {
+	return &dogResolver{name: "concrete"}
+}
+
+func (r *pointerToInterfaceResolver) NonNullPtrToIface() *petResolver {
+	var p petResolver = &dogResolver{name: "nonNull"}
+	return &p
+}
+
+func (r *pointerToInterfaceResolver) NilPtr() *petResolver {
+	return nil
+}
+
+func (r *pointerToInterfaceResolver) PtrToNilIface() *petResolver {
+	var p petResolver
+	return &p
+}
+
+func TestPointerToInterface(t *testing.T) {
+	t.Parallel()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: graphql.MustParseSchema(`
+				type Query {
+					iface: Pet
+					ptrToIface: Pet
+					concrete: Pet
+					nonNullPtrToIface: Pet!
+					nilPtr: Pet
+					ptrToNilIface: Pet
+				}
+
+				interface Pet {
+					name: String!
+				}
+
+				type Dog implements Pet {
+					name: String!
+				}
+			`, &pointerToInterfaceResolver{}),
+			Query: `
+				{
+					iface { name ... on Dog { __typename } }
+					ptrToIface { name ... on Dog { __typename } }
+					concrete { name ... on Dog { __typename } }
+					nonNullPtrToIface { name }
+					nilPtr { name }
+					ptrToNilIface { name }
+				}
+			`,
+			ExpectedResult: `
+				{
+					"iface": {"name": "iface", "__typename": "Dog"},
+					"ptrToIface": {"name": "ptr", "__typename": "Dog"},
+					"concrete": {"name": "concrete", "__typename": "Dog"},
+					"nonNullPtrToIface": {"name": "nonNull"},
+					"nilPtr": null,
+					"ptrToNilIface": null
+				}
+			`,
+		},
+	})
+}
+
+type cacheControlResolver struct{}
+
+func (r *cacheControlResolver) Public() *cacheControlUserResolver { return &cacheControlUserResolver{} }
+func (r *cacheControlResolver) Private() *cacheControlUserResolver {
+	return &cacheControlUserResolver{}
+}
+func (r *cacheControlResolver) Version() string { return "v1" }
+
+type cacheControlUserResolver struct{}
+
+func (r *cacheControlUserResolver) Name() string  { return "Alice" }
+func (r *cacheControlUserResolver) Email() string { return "alice@example.com" }
+
+func TestCacheControl(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		enum CacheControlScope { PUBLIC PRIVATE }
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			public: User @cacheControl(maxAge: 120)
+			private: User @cacheControl(maxAge: 60, scope: PRIVATE)
+			version: String!
+		}
+
+		type User @cacheControl(maxAge: 300) {
+			name: String!
+			email: String! @cacheControl(maxAge: 30)
+		}
+	`, &cacheControlResolver{}, graphql.CacheControl(600))
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		header string
+	}{
+		{
+			name:   "default max age for root fields",
+			query:  `{ version }`,
+			header: "max-age=600, public",
+		},
+		{
+			name:   "field hint takes precedence over type hint",
+			query:  `{ public { name } }`,
+			header: "max-age=120, public",
+		},
+		{
+			name:   "minimum max age of all fields",
+			query:  `{ public { name email } }`,
+			header: "max-age=30, public",
+		},
+		{
+			name:   "private scope",
+			query:  `{ public { name } private { name } }`,
+			header: "max-age=60, private",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := s.Exec(context.Background(), tc.query, "", nil)
+			if len(res.Errors) != 0 {
+				t.Fatal(res.Errors)
+			}
+			if got := res.CachePolicy.HeaderValue(); got != tc.header {
+				t.Errorf("expected header %q, got %q", tc.header, got)
+			}
+		})
+	}
+
+	res := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}).Exec(context.Background(), `{ hero { name } }`, "", nil)
+	if res.CachePolicy != nil {
+		t.Errorf("expected no cache policy without the CacheControl option, got %+v", res.CachePolicy)
+	}
+}
+
+func TestAllowUnusedFragments(t *testing.T) {
+	query := `
+		query {
+			hero {
+				name
+			}
+		}
+
+		fragment unused on Character {
+			id
+		}
+	`
+
+	res := starwarsSchema.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 1 || res.Errors[0].Rule != "NoUnusedFragments" {
+		t.Fatalf("expected a NoUnusedFragments error, got %v", res.Errors)
+	}
+
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.AllowUnusedFragments())
+	res = s.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if string(res.Data) != `{"hero":{"name":"R2-D2"}}` {
+		t.Errorf("unexpected data %s", res.Data)
+	}
+	warnings, _ := res.Extensions["warnings"].([]*gqlerrors.QueryError)
+	if len(warnings) != 1 || warnings[0].Message != `Fragment "unused" is never used.` {
+		t.Errorf("expected a warning for the unused fragment, got %v", res.Extensions["warnings"])
+	}
+}
+
+type deprecationWarningsResolver struct{}
+
+func (r *deprecationWarningsResolver) Items(args struct {
+	First  *int32
+	Limit  *int32
+	Filter *struct {
+		Name  *string
+		Owner *string
+	}
+	Sort *string
+}) []*deprecationWarningsItem {
+	return []*deprecationWarningsItem{{}}
+}
+
+type deprecationWarningsItem struct{}
+
+func (i *deprecationWarningsItem) ID() graphql.ID {
+	return "1"
+}
+
+func (i *deprecationWarningsItem) Title() string {
+	return "title"
+}
+
+func (i *deprecationWarningsItem) Name() string {
+	return "name"
+}
+
+func TestDeprecationWarnings(t *testing.T) {
+	const sdl = `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items(first: Int, limit: Int @deprecated(reason: "Use first."), filter: Filter, sort: Sort): [Item!]!
+		}
+
+		input Filter {
+			name: String
+			owner: String @deprecated
+		}
+
+		enum Sort {
+			NAME
+			TITLE @deprecated(reason: "Sort by NAME.")
+		}
+
+		type Item {
+			id: ID!
+			title: String! @deprecated(reason: "Use name.")
+			name: String!
+		}
+	`
+	query := `
+		query {
+			items(limit: 1, filter: {owner: "me"}, sort: TITLE) {
+				id
+				...itemFields
+			}
+			more: items(first: 1) {
+				...itemFields
+			}
+		}
+
+		fragment itemFields on Item {
+			title
+		}
+	`
+
+	s := graphql.MustParseSchema(sdl, &deprecationWarningsResolver{})
+	errs, warnings := s.ValidateWithWarnings(query)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	want := []*gqlerrors.QueryError{
+		{
+			Message:   `Argument "limit" of field "Query.items" is deprecated: Use first.`,
+			Locations: []gqlerrors.Location{{Line: 3, Column: 10}},
+			Rule:      "DeprecatedArgument",
+		},
+		{
+			Message:   `Input field "Filter.owner" is deprecated: No longer supported`,
+			Locations: []gqlerrors.Location{{Line: 3, Column: 29}},
+			Rule:      "DeprecatedInputField",
+		},
+		{
+			Message:   `Enum value "Sort.TITLE" is deprecated: Sort by NAME.`,
+			Locations: []gqlerrors.Location{{Line: 3, Column: 49}},
+			Rule:      "DeprecatedEnumValue",
+		},
+		{
+			// The fragment is reported once, although it is spread twice.
+			Message:   `Field "Item.title" is deprecated: Use name.`,
+			Locations: []gqlerrors.Location{{Line: 13, Column: 4}},
+			Rule:      "DeprecatedField",
+		},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("got warnings %v, want %v", warnings, want)
+	}
+
+	// The warnings are not added to responses by default.
+	res := s.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if _, ok := res.Extensions["warnings"]; ok {
+		t.Errorf("unexpected warnings %v", res.Extensions["warnings"])
+	}
+
+	s = graphql.MustParseSchema(sdl, &deprecationWarningsResolver{}, graphql.DeprecationWarnings())
+	res = s.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if got := res.Extensions["warnings"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got warnings %v in the response, want %v", got, want)
+	}
+	if _, warnings := s.ValidateWithWarnings(query); !reflect.DeepEqual(warnings, want) {
+		t.Errorf("got warnings %v with DeprecationWarnings, want %v", warnings, want)
+	}
+
+	if _, warnings := s.ValidateWithWarnings(`{ items(first: 1, sort: NAME) { id name } }`); len(warnings) != 0 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+}
+
+func TestMaxIntrospectionFields(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("query {\n")
+	for i := 0; i < 11; i++ {
+		fmt.Fprintf(&b, "t%d: __type(name: \"Droid\") { name }\n", i)
+	}
+	b.WriteString("}")
+	query := b.String()
+
+	res := starwarsSchema.Exec(context.Background(), query, "", nil)
+	if len(res.Errors) != 1 || res.Errors[0].Rule != "MaxIntrospectionFieldsExceeded" {
+		t.Fatalf("expected a MaxIntrospectionFieldsExceeded error, got %v", res.Errors)
+	}
+	if want := "The operation selects introspection fields too often. Permitted: 10, was: 11"; res.Errors[0].Message != want {
+		t.Errorf("expected message %q, got %q", want, res.Errors[0].Message)
+	}
+
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxIntrospectionFields(20))
+	if res := s.Exec(context.Background(), query, "", nil); len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+
+	s = graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxIntrospectionFields(0))
+	if res := s.Exec(context.Background(), query, "", nil); len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+}
+
+type mutatingArgsResolver struct{}
+
+func (r *mutatingArgsResolver) Items() []*mutatingArgsItemResolver {
+	return []*mutatingArgsItemResolver{{}, {}, {}}
+}
+
+type mutatingArgsItemResolver struct{}
+
+func (r *mutatingArgsItemResolver) Tags(args struct {
+	List   []string
+	Filter *struct{ Names []string }
+}) []string {
+	tags := append([]string(nil), args.List...)
+	if args.Filter != nil {
+		tags = append(tags, args.Filter.Names...)
+		args.Filter.Names[0] = "mutated"
+	}
+	args.List[0] = "mutated"
+	return tags
+}
+
+func TestMutatingArguments(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items: [Item!]!
+		}
+
+		input Filter {
+			names: [String!]!
+		}
+
+		type Item {
+			tags(list: [String!] = ["a", "b"], filter: Filter): [String!]!
+		}
+	`, &mutatingArgsResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: s,
+			Query: `
+				{
+					items {
+						tags
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"items": [
+						{"tags": ["a", "b"]},
+						{"tags": ["a", "b"]},
+						{"tags": ["a", "b"]}
+					]
+				}
+			`,
+		},
+		{
+			Schema: s,
+			Query: `
+				{
+					items {
+						tags(list: ["x"], filter: {names: ["y"]})
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"items": [
+						{"tags": ["x", "y"]},
+						{"tags": ["x", "y"]},
+						{"tags": ["x", "y"]}
+					]
+				}
+			`,
+		},
+	})
+}
+
+type nonFiniteFloatResolver struct{}
+
+func (r *nonFiniteFloatResolver) NaN() *float64 {
+	v := math.NaN()
+	return &v
+}
+
+func (r *nonFiniteFloatResolver) PosInf() float64 {
+	return math.Inf(1)
+}
+
+func (r *nonFiniteFloatResolver) NegInf() *float64 {
+	v := math.Inf(-1)
+	return &v
+}
+
+func (r *nonFiniteFloatResolver) NonNullNaN() float64 {
+	return math.NaN()
+}
+
+func TestNonFiniteFloats(t *testing.T) {
+	const schemaString = `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			nan: Float
+			posInf: Float!
+			negInf: Float
+			nonNullNaN: Float!
+		}
+	`
+	errorSchema := graphql.MustParseSchema(schemaString, &nonFiniteFloatResolver{})
+	nullSchema := graphql.MustParseSchema(schemaString, &nonFiniteFloatResolver{}, graphql.NonFiniteFloatsAsNull())
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: errorSchema,
+			Query: `
+				{
+					nan
+					negInf
+				}
+			`,
+			ExpectedResult: `
+				{
+					"nan": null,
+					"negInf": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `graphql: NaN is not a valid value for "Float"`, Path: []interface{}{"nan"}},
+				{Message: `graphql: -Inf is not a valid value for "Float"`, Path: []interface{}{"negInf"}},
+			},
+		},
+		{
+			Schema: errorSchema,
+			Query: `
+				{
+					posInf
+					nonNullNaN
+				}
+			`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `graphql: NaN is not a valid value for "Float"`, Path: []interface{}{"nonNullNaN"}},
+				{Message: `graphql: +Inf is not a valid value for "Float"`, Path: []interface{}{"posInf"}},
+			},
+		},
+		{
+			Schema: nullSchema,
+			Query: `
+				{
+					nan
+					negInf
+				}
+			`,
+			ExpectedResult: `
+				{
+					"nan": null,
+					"negInf": null
+				}
+			`,
+		},
+		{
+			Schema: nullSchema,
+			Query: `
+				{
+					posInf
+					nonNullNaN
+				}
+			`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `graphql: got NaN for non-null "Float"`, Path: []interface{}{"nonNullNaN"}},
+				{Message: `graphql: got +Inf for non-null "Float"`, Path: []interface{}{"posInf"}},
+			},
+		},
+	})
+}
+
+func TestIncludeErrorLocations(t *testing.T) {
+	const schemaString = `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			findDroid: String!
+		}
+	`
+	query := "{\n  droid: findDroid\n}"
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         graphql.MustParseSchema(schemaString, &findDroidResolver{}),
+			Query:          query,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:       droidNotFoundError.Error(),
+					Path:          []interface{}{"droid"},
+					ResolverError: droidNotFoundError,
+					Extensions:    map[string]interface{}{"code": droidNotFoundError.Code, "message": droidNotFoundError.Message},
+				},
+			},
+		},
+		{
+			Schema:         graphql.MustParseSchema(schemaString, &findDroidResolver{}, graphql.IncludeErrorLocations()),
+			Query:          query,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:       droidNotFoundError.Error(),
+					Locations:     []gqlerrors.Location{{Line: 2, Column: 3}},
+					Path:          []interface{}{"droid"},
+					ResolverError: droidNotFoundError,
+					Extensions:    map[string]interface{}{"code": droidNotFoundError.Code, "message": droidNotFoundError.Message},
+				},
+			},
+		},
+	})
+}
+
+type selectionDirectiveResolver struct{}
+
+func (r *selectionDirectiveResolver) Hello() string { return "Hello world!" }
+func (r *selectionDirectiveResolver) World() string { return "World!" }
+
+func TestSelectionDirective(t *testing.T) {
+	const schemaString = `
+		directive @onlyIf(flag: String!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hello: String!
+			world: String!
+		}
+	`
+	flags := map[string]bool{"on": true}
+	onlyIf := func(args map[string]interface{}) (bool, error) {
+		flag := args["flag"].(string)
+		if flag == "broken" {
+			return false, fmt.Errorf("unknown flag %q", flag)
+		}
+		return !flags[flag], nil
+	}
+	s := graphql.MustParseSchema(schemaString, &selectionDirectiveResolver{}, graphql.SelectionDirective("onlyIf", onlyIf))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: s,
+			Query: `
+				query($flag: String!) {
+					hello @onlyIf(flag: "on")
+					world @onlyIf(flag: $flag)
+				}
+			`,
+			Variables: map[string]interface{}{"flag": "off"},
+			ExpectedResult: `
+				{
+					"hello": "Hello world!"
+				}
+			`,
+		},
+		{
+			Schema: s,
+			Query: `
+				{
+					... on Query @onlyIf(flag: "off") {
+						hello
+					}
+					...World @onlyIf(flag: "on")
+				}
+
+				fragment World on Query {
+					world
+				}
+			`,
+			ExpectedResult: `
+				{
+					"world": "World!"
+				}
+			`,
+		},
+		{
+			Schema: s,
+			Query: `
+				{
+					hello @onlyIf(flag: "broken")
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hello": "Hello world!"
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{Message: `unknown flag "broken"`}},
+		},
+		{
+			Schema: graphql.MustParseSchema(schemaString, &selectionDirectiveResolver{}),
+			Query: `
+				{
+					hello @onlyIf(flag: "off")
+					world @skip(if: true)
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hello": "Hello world!"
+				}
+			`,
+		},
+	})
+}
+
+func TestSelectionDirectiveListOfEnums(t *testing.T) {
+	const schemaString = `
+		directive @visibleTo(roles: [Role!]!, scope: Scope = {tenant: "default"}) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+		enum Role {
+			ADMIN
+			EDITOR
+			VIEWER
+		}
+
+		input Scope {
+			tenant: String!
+			roles: [Role!]
+		}
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hello: String!
+			world: String!
+		}
+	`
+	var got []map[string]interface{}
+	visibleTo := func(args map[string]interface{}) (bool, error) {
+		got = append(got, args)
+		for _, role := range args["roles"].([]interface{}) {
+			if role.(string) == "ADMIN" {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	s := graphql.MustParseSchema(schemaString, &selectionDirectiveResolver{}, graphql.SelectionDirective("visibleTo", visibleTo))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: s,
+			Query: `
+				query($admin: [Role!]!, $others: [Role!]!, $scope: Scope) {
+					hello @visibleTo(roles: $admin, scope: $scope)
+					world @visibleTo(roles: $others)
+				}
+			`,
+			Variables: map[string]interface{}{
+				"admin":  []interface{}{"VIEWER", "ADMIN"},
+				"others": []interface{}{"EDITOR"},
+				"scope":  map[string]interface{}{"tenant": "acme", "roles": []interface{}{"EDITOR"}},
+			},
+			ExpectedResult: `
+				{
+					"hello": "Hello world!"
+				}
+			`,
+		},
+	})
+	want := []map[string]interface{}{
+		{
+			"roles": []interface{}{"VIEWER", "ADMIN"},
+			"scope": map[string]interface{}{"tenant": "acme", "roles": []interface{}{"EDITOR"}},
+		},
+		{
+			"roles": []interface{}{"EDITOR"},
+			"scope": map[string]interface{}{"tenant": "default"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got arguments %v, want %v", got, want)
+	}
+
+	// A single value is coerced to a list.
+	got = nil
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: s,
+			Query: `
+				{
+					hello @visibleTo(roles: ADMIN)
+					... on Query @visibleTo(roles: VIEWER) {
+						world
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hello": "Hello world!"
+				}
+			`,
+		},
+	})
+	if len(got) != 2 || !reflect.DeepEqual(got[0]["roles"], []interface{}{"ADMIN"}) || !reflect.DeepEqual(got[1]["roles"], []interface{}{"VIEWER"}) {
+		t.Errorf("unexpected arguments %v", got)
+	}
+
+	res := s.Exec(context.Background(), `query($roles: [Role!]!) { hello @visibleTo(roles: $roles) }`, "", map[string]interface{}{"roles": []interface{}{"OWNER"}})
+	if len(res.Errors) != 1 {
+		t.Errorf("expected an error for an invalid enum value, got %v", res.Errors)
+	}
+}
+
+func TestSelectionDirectiveCoercion(t *testing.T) {
+	const schemaString = `
+		directive @since(date: DateTime!, note: String) on FIELD
+
+		scalar DateTime
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hello: String!
+			world: String!
+		}
+	`
+	var got []interface{}
+	since := func(args map[string]interface{}) (bool, error) {
+		got = append(got, args["date"])
+		return false, nil
+	}
+	s := graphql.MustParseSchema(schemaString, &selectionDirectiveResolver{},
+		graphql.SelectionDirective("since", since),
+		graphql.CustomScalar("DateTime", dateTimeCoercion{}),
+		graphql.MaxStringLength(20),
+	)
+
+	res := s.Exec(context.Background(), `query($date: DateTime!) { hello @since(date: $date) }`, "", map[string]interface{}{"date": "2020-01-02T00:00:00Z"})
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if want := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC); len(got) != 1 || !want.Equal(got[0].(time.Time)) {
+		t.Errorf("got arguments %v, want the coerced date %v", got, want)
+	}
+
+	// The arguments of directives are coerced like the arguments of fields.
+	for _, tc := range []struct {
+		query     string
+		variables map[string]interface{}
+		err       string
+	}{
+		{
+			query:     `query($date: DateTime!) { hello @since(date: $date) }`,
+			variables: map[string]interface{}{"date": "yesterday"},
+			err:       `could not coerce "yesterday" (string) into DateTime`,
+		},
+		{
+			query:     `query($note: String) { hello @since(date: 0, note: $note) }`,
+			variables: map[string]interface{}{"note": "a note that is too long"},
+			err:       `exceeding the maximum length of 20 bytes`,
+		},
+	} {
+		res := s.Exec(context.Background(), tc.query, "", tc.variables)
+		if len(res.Errors) == 0 || !strings.Contains(res.Errors[0].Message, tc.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.query, tc.err, res.Errors)
+		}
+	}
+}
+
+func TestEstimateQueryCost(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		directive @cost(
+			complexity: Int!
+			multipliers: [String!]
+			useMultipliers: Boolean = true
+		) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			users(first: Int): [User!]! @cost(complexity: 1, multipliers: ["first"])
+		}
+
+		type User {
+			name: String! @cost(complexity: 2)
+		}
+	`, nil)
+
+	for _, tc := range []struct {
+		name          string
+		query         string
+		operationName string
+		variables     map[string]interface{}
+		cost          int
+		err           bool
+	}{
+		{
+			name:  "literal multiplier",
+			query: `{ users(first: 10) { name } }`,
+			cost:  1 + 2*10,
+		},
+		{
+			name:      "multiplier from variable",
+			query:     `query($first: Int) { users(first: $first) { name } }`,
+			variables: map[string]interface{}{"first": float64(5)},
+			cost:      1 + 2*5,
+		},
+		{
+			name:          "operation name",
+			query:         `query A { users { name } } query B { users(first: 3) { name } }`,
+			operationName: "B",
+			cost:          1 + 2*3,
+		},
+		{
+			name:  "missing operation name",
+			query: `query A { users { name } } query B { users(first: 3) { name } }`,
+			err:   true,
+		},
+		{
+			name:  "parse error",
+			query: `{ users(first: 10) { name }`,
+			err:   true,
+		},
+		{
+			name:  "fragment cycle",
+			query: `{ ...a } fragment a on Query { ...a }`,
+			err:   true,
+		},
+		{
+			name:  "invalid query",
+			query: `{ users(first: 10) { name age } }`,
+			err:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, err := graphql.EstimateQueryCost(s, tc.query, tc.operationName, tc.variables)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error: %t, got %v", tc.err, err)
+			}
+			if cost != tc.cost {
+				t.Errorf("expected cost %d, got %d", tc.cost, cost)
+			}
+		})
+	}
+}
+
+func TestEstimateOperationCosts(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		directive @cost(
+			complexity: Int!
+			multipliers: [String!]
+			useMultipliers: Boolean = true
+		) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			users(first: Int): [User!]! @cost(complexity: 1, multipliers: ["first"])
+		}
+
+		type User {
+			name: String! @cost(complexity: 2)
+		}
+	`, nil)
+
+	costs, err := graphql.EstimateOperationCosts(s, `
+		query A($first: Int) { users(first: $first) { name } }
+		query B { users(first: 3) { name } }
+	`, map[string]interface{}{"first": float64(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"A": 1 + 2*5, "B": 1 + 2*3}; !reflect.DeepEqual(costs, want) {
+		t.Errorf("got costs %v, want %v", costs, want)
+	}
+
+	costs, err = graphql.EstimateOperationCosts(s, `{ users(first: 10) { name } }`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"": 1 + 2*10}; !reflect.DeepEqual(costs, want) {
+		t.Errorf("got costs %v, want %v", costs, want)
+	}
+
+	if _, err := graphql.EstimateOperationCosts(s, `query A { users { name } } query A { users { name } }`, nil); err == nil {
+		t.Error("expected error for duplicate operation names")
+	}
+
+	if _, err := graphql.EstimateOperationCosts(s, `query A { users { name } } query B { ...b } fragment b on Query { ...b }`, nil); err == nil {
+		t.Error("expected error for a fragment cycle")
+	}
+
+	if _, err := graphql.EstimateOperationCosts(s, `query A { users { name } } query B { users { name age } }`, nil); err == nil {
+		t.Error("expected error for an invalid operation")
+	}
+}
+
+func TestMaxMultiplierProduct(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		directive @cost(
+			complexity: Int!
+			multipliers: [String!]
+			useMultipliers: Boolean = true
+		) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			users(first: Int): [User!]! @cost(complexity: 1, multipliers: ["first"])
+		}
+
+		type User {
+			name: String! @cost(complexity: 2)
+			friends(first: Int): [User!]! @cost(complexity: 1, multipliers: ["first"])
+		}
+	`, nil, graphql.MaxMultiplierProduct(100))
+
+	errs := s.Validate(`{ users(first: 10) { friends(first: 20) { name } } }`)
+	if len(errs) != 1 || errs[0].Rule != "MaxMultiplierProductExceeded" {
+		t.Errorf("expected a MaxMultiplierProductExceeded error, got %v", errs)
+	}
+
+	// The product is not estimated for invalid documents, e.g. with a fragment cycle.
+	errs = s.Validate(`{ users(first: 10) { ...friends } } fragment friends on User { friends(first: 20) { ...friends } }`)
+	if len(errs) != 1 || errs[0].Rule != "NoFragmentCycles" {
+		t.Errorf("expected a NoFragmentCycles error, got %v", errs)
+	}
+}
+
+type costUser struct {
+	name string
+}
+
+func (u *costUser) Name() string {
+	return u.name
+}
+
+type costResolver struct{}
+
+func (r *costResolver) Users(args struct{ First *int32 }) []*costUser {
+	return []*costUser{{name: "a"}, {name: "b"}, {name: "c"}}
+}
+
+func TestActualCost(t *testing.T) {
+	const sdl = `
+		directive @cost(
+			complexity: Int!
+			multipliers: [String!]
+			useMultipliers: Boolean = true
+		) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			users(first: Int): [User!]! @cost(complexity: 1, multipliers: ["first"])
+		}
+
+		type User {
+			name: String! @cost(complexity: 2)
+		}
+	`
+
+	t.Run("reported", func(t *testing.T) {
+		s := graphql.MustParseSchema(sdl, &costResolver{}, graphql.ActualCost())
+		// The estimate assumes 10 users, but only 3 are resolved.
+		resp := s.Exec(context.Background(), `{ users(first: 10) { name } }`, "", nil)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if got, want := resp.Extensions["actualCost"], 1+2*3; got != want {
+			t.Errorf("got actual cost %v, want %d", got, want)
+		}
+	})
+
+	t.Run("not reported by default", func(t *testing.T) {
+		s := graphql.MustParseSchema(sdl, &costResolver{})
+		resp := s.Exec(context.Background(), `{ users { name } }`, "", nil)
+		if _, ok := resp.Extensions["actualCost"]; ok {
+			t.Errorf("unexpected actual cost extension")
+		}
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		s := graphql.MustParseSchema(sdl, &costResolver{}, graphql.MaxActualCost(5))
+		resp := s.Exec(context.Background(), `{ users { name } }`, "", nil)
+		if resp.Data != nil {
+			t.Errorf("expected no data, got %s", resp.Data)
+		}
+		if len(resp.Errors) != 1 {
+			t.Fatalf("expected one error, got %v", resp.Errors)
+		}
+		if got, want := resp.Errors[0].Message, "The actual query cost is too high. Permitted: 5, was: 7"; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+		if got := resp.Errors[0].Extensions["actualCost"]; got != 7 {
+			t.Errorf("got actualCost extension %v, want 7", got)
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		s := graphql.MustParseSchema(sdl, &costResolver{}, graphql.MaxActualCost(7))
+		resp := s.Exec(context.Background(), `{ users { name } }`, "", nil)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if got, want := string(resp.Data), `{"users":[{"name":"a"},{"name":"b"},{"name":"c"}]}`; got != want {
+			t.Errorf("got data %s, want %s", got, want)
+		}
+	})
+
+	t.Run("inherited from the object and the schema", func(t *testing.T) {
+		s := graphql.MustParseSchema(`
+			directive @cost(complexity: Int!) on SCHEMA | OBJECT | FIELD_DEFINITION
+
+			schema @cost(complexity: 1) {
+				query: Query
+			}
+
+			type Query {
+				users(first: Int): [User!]!
+			}
+
+			type User @cost(complexity: 2) {
+				name: String!
+			}
+		`, &costResolver{}, graphql.ActualCost())
+		resp := s.Exec(context.Background(), `{ __typename users { __typename name } }`, "", nil)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if got, want := resp.Extensions["actualCost"], 1+2*3; got != want {
+			t.Errorf("got actual cost %v, want %d", got, want)
+		}
+	})
+
+	t.Run("inherited from the field of an interface", func(t *testing.T) {
+		s := graphql.MustParseSchema(`
+			directive @cost(complexity: Int!, multipliers: [String!]) on FIELD_DEFINITION
+
+			schema {
+				query: Query
+			}
+
+			type Query {
+				users(first: Int): [User!]! @cost(complexity: 1, multipliers: ["first"])
+			}
+
+			interface Named {
+				name: String! @cost(complexity: 2)
+			}
+
+			type User implements Named {
+				name: String!
+			}
+		`, &costResolver{}, graphql.ActualCost())
+		const query = `{ users(first: 3) { name } }`
+		estimated, err := graphql.EstimateQueryCost(s, query, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := s.Exec(context.Background(), query, "", nil)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if got := resp.Extensions["actualCost"]; got != estimated {
+			t.Errorf("got actual cost %v, want the estimated cost %d", got, estimated)
+		}
+	})
+}
+
+func TestMaxDepthExemptIntrospection(t *testing.T) {
+	query := `
+		{
+			__schema {
+				types {
+					fields {
+						name
+					}
+				}
+			}
+		}
+	`
+
+	s := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxDepth(3))
+	if res := s.Exec(context.Background(), query, "", nil); len(res.Errors) == 0 || res.Errors[0].Rule != "MaxDepthExceeded" {
+		t.Fatalf("expected a MaxDepthExceeded error, got %v", res.Errors)
+	}
+
+	s = graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxDepth(3), graphql.MaxDepthExemptIntrospection())
+	if res := s.Exec(context.Background(), query, "", nil); len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+	if res := s.Exec(context.Background(), `{ hero { friends { friends { name } } } }`, "", nil); len(res.Errors) == 0 || res.Errors[0].Rule != "MaxDepthExceeded" {
+		t.Fatalf("expected a MaxDepthExceeded error, got %v", res.Errors)
+	}
+}
+
+func TestRootTypeNames(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		schema {
+			query: RootQuery
+			subscription: RootSubscription
+		}
+
+		type RootQuery {
+			hello: String!
+		}
+
+		type RootSubscription {
+			helloSaid: String!
+		}
+	`, nil)
+
+	if name := s.QueryTypeName(); name != "RootQuery" {
+		t.Errorf("expected query type name %q, got %q", "RootQuery", name)
+	}
+	if name := s.MutationTypeName(); name != "" {
+		t.Errorf("expected no mutation type name, got %q", name)
+	}
+	if name := s.SubscriptionTypeName(); name != "RootSubscription" {
+		t.Errorf("expected subscription type name %q, got %q", "RootSubscription", name)
+	}
+}
+
+func TestSkipWithoutIfArgument(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxCost(1000)),
+			Query: `
+				{
+					hero {
+						name @skip
+					}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:   `Directive "@skip" argument "if" of type "Boolean!" is required but not provided.`,
+					Locations: []gqlerrors.Location{{Line: 4, Column: 12}},
+					Rule:      "ProvidedNonNullArguments",
+				},
+			},
+		},
+	})
+}
+
+type price int
+
+func (price) ImplementsGraphQLType(name string) bool {
+	return name == "Price"
+}
+
+func (p *price) UnmarshalGraphQL(input interface{}) error {
+	v, ok := input.(int32)
+	if !ok {
+		return fmt.Errorf("wrong type for Price: %T", input)
+	}
+	*p = price(v)
+	return nil
+}
+
+func (p *price) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("$%d.%02d", *p/100, *p%100))
+}
+
+type brokenPrice int
+
+func (brokenPrice) ImplementsGraphQLType(name string) bool {
+	return name == "Price"
+}
+
+func (p *brokenPrice) UnmarshalGraphQL(input interface{}) error {
+	return nil
+}
+
+func (brokenPrice) MarshalJSON() ([]byte, error) {
+	return []byte(`$1.00`), nil
+}
+
+type priceResolver struct{}
+
+func (r *priceResolver) Price() price {
+	return 1234
+}
+
+func (r *priceResolver) OptionalPrice() *price {
+	p := price(5)
+	return &p
+}
+
+func (r *priceResolver) BrokenPrice() *brokenPrice {
+	p := brokenPrice(100)
+	return &p
+}
+
+func TestJSONMarshalerScalar(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		scalar Price
+
+		type Query {
+			price: Price!
+			optionalPrice: Price
+			brokenPrice: Price
+		}
+	`, &priceResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					price
+					optionalPrice
+				}
+			`,
+			ExpectedResult: `
+				{
+					"price": "$12.34",
+					"optionalPrice": "$0.05"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					brokenPrice
+				}
+			`,
+			ExpectedResult: `
+				{
+					"brokenPrice": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `could not marshal 100: invalid JSON "$1.00"`, Path: []interface{}{"brokenPrice"}},
+			},
+		},
+	})
+}
+
+func TestWithIntrospection(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:  starwarsSchemaNoIntrospection,
+			Context: graphql.WithIntrospection(context.Background(), true),
+			Query: `
+				{
+					__type(name: "Droid") {
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"__type": {
+						"name": "Droid"
+					}
+				}
+			`,
+		},
+		{
+			Schema:  starwarsSchema,
+			Context: graphql.WithIntrospection(context.Background(), false),
+			Query: `
+				{
+					__typename
+					__type(name: "Droid") {
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+				}
+			`,
+		},
+		{
+			Schema: starwarsSchema,
+			Query: `
+				{
+					__type(name: "Droid") {
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"__type": {
+						"name": "Droid"
+					}
+				}
+			`,
+		},
+	})
+}
+
+type strictCoercionResolver struct{}
+
+func (r *strictCoercionResolver) Scale(args struct{ Factor float64 }) float64 {
+	return args.Factor
+}
+
+func (r *strictCoercionResolver) Area(args struct {
+	Rect struct{ Width, Height float64 }
+}) float64 {
+	return args.Rect.Width * args.Rect.Height
+}
+
+func TestStrictCoercion(t *testing.T) {
+	const schemaString = `
+		schema {
+			query: Query
+		}
+
+		input Rect {
+			width: Float!
+			height: Float!
+		}
+
+		type Query {
+			scale(factor: Float!): Float!
+			area(rect: Rect!): Float!
+		}
+	`
+	lenientSchema := graphql.MustParseSchema(schemaString, &strictCoercionResolver{})
+	strictSchema := graphql.MustParseSchema(schemaString, &strictCoercionResolver{}, graphql.StrictCoercion())
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: lenientSchema,
+			Query: `
+				{
+					scale(factor: 2)
+				}
+			`,
+			ExpectedResult: `
+				{
+					"scale": 2
+				}
+			`,
+		},
+		{
+			Schema: strictSchema,
+			Query: `
+				{
+					scale(factor: 2)
+				}
+			`,
+			ExpectedResult: `
+				{
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `could not unmarshal 2 (int32) into float64: lenient coercion is not permitted in strict mode`},
+			},
+		},
+		{
+			Schema: strictSchema,
+			Query: `
+				{
+					scale(factor: 2.5)
+				}
+			`,
+			ExpectedResult: `
+				{
+					"scale": 2.5
+				}
+			`,
+		},
+		{
+			Schema: lenientSchema,
+			Query: `
+				query($rect: Rect!) {
+					area(rect: $rect)
+				}
+			`,
+			Variables: map[string]interface{}{
+				"rect": map[string]interface{}{"width": 2.0, "height": 3.0, "depth": 4.0},
+			},
+			ExpectedResult: `
+				{
+					"area": 6
+				}
+			`,
+		},
+		{
+			Schema: strictSchema,
+			Query: `
+				query($rect: Rect!) {
+					area(rect: $rect)
+				}
+			`,
+			Variables: map[string]interface{}{
+				"rect": map[string]interface{}{"width": 2.0, "height": 3.0, "depth": 4.0},
+			},
+			ExpectedResult: `
+				{
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `unknown field "depth": lenient coercion is not permitted in strict mode`},
+			},
+		},
+	})
+}
+
+func TestFragmentTypes(t *testing.T) {
+	const query = `
+		query($withDroids: Boolean = true) {
+			search(text: "an") {
+				__typename
+				... on Human {
+					height
+				}
+				... on Starship @include(if: $withDroids) {
+					length
+				}
+				...DroidFields @include(if: $withDroids)
+			}
+			hero {
+				name
+				friends {
+					... on Droid {
+						primaryFunction
+					}
+				}
+			}
+		}
+
+		fragment DroidFields on Droid {
+			primaryFunction
+		}
+	`
+
+	for _, tc := range []struct {
+		name string
+		vars map[string]interface{}
+		want map[string][]string
+	}{
+		{
+			name: "all fragments",
+			want: map[string][]string{
+				"search":       {"Droid", "Human", "Starship"},
+				"hero":         {},
+				"hero.friends": {"Droid"},
+			},
+		},
+		{
+			name: "skipped fragments",
+			vars: map[string]interface{}{"withDroids": false},
+			want: map[string][]string{
+				"search":       {"Human"},
+				"hero":         {},
+				"hero.friends": {"Droid"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			types, err := starwarsSchema.FragmentTypes(query, "", tc.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(types, tc.want) {
+				t.Fatalf("wrong fragment types, have=%v want=%v", types, tc.want)
+			}
+		})
+	}
+}
+
+func TestApolloTracer(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.Tracer(trace.ApolloTracer{}))
+	resp := schema.Exec(context.Background(), `
+		{
+			hero {
+				name
+				friends {
+					name
+				}
+			}
+		}
+	`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+
+	b, err := json.Marshal(resp.Extensions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extensions struct {
+		Tracing struct {
+			Version   int
+			StartTime time.Time
+			EndTime   time.Time
+			Duration  int64
+			Execution struct {
+				Resolvers []struct {
+					Path       []interface{}
+					ParentType string
+					FieldName  string
+					ReturnType string
+					Duration   int64
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &extensions); err != nil {
+		t.Fatal(err)
+	}
+
+	tracing := extensions.Tracing
+	if tracing.Version != 1 || tracing.EndTime.Before(tracing.StartTime) || tracing.Duration < 0 {
+		t.Fatalf("invalid tracing extension: %s", b)
+	}
+	paths := make(map[string]string)
+	for _, r := range tracing.Execution.Resolvers {
+		p, err := json.Marshal(r.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths[string(p)] = r.ParentType + "." + r.FieldName + ": " + r.ReturnType
+	}
+	want := map[string]string{
+		`["hero"]`:                    "Query.hero: Character",
+		`["hero","name"]`:             "Character.name: String!",
+		`["hero","friends"]`:          "Character.friends: [Character]",
+		`["hero","friends",0,"name"]`: "Character.name: String!",
+		`["hero","friends",1,"name"]`: "Character.name: String!",
+		`["hero","friends",2,"name"]`: "Character.name: String!",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("wrong resolvers\nhave: %v\nwant: %v", paths, want)
+	}
+}
+
+func TestUnmatchedTypeConditions(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: starwarsSchema,
+			Query: `
+				{
+					hero {
+						... on Human {
+							height
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hero": {}
+				}
+			`,
+		},
+		{
+			Schema: starwarsSchema,
+			Query: `
+				{
+					hero {
+						name
+						... on Human {
+							height
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hero": {
+						"name": "R2-D2"
+					}
+				}
+			`,
+		},
+		{
+			Schema: starwarsSchema,
+			Query: `
+				{
+					search(text: "Luke") {
+						... on Starship {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"search": [{}]
+				}
+			`,
+		},
+	})
+}
+
+type partialError struct {
+	data interface{}
+}
+
+func (e *partialError) Error() string {
+	return "some sources failed"
+}
+
+func (e *partialError) IsPartial() bool {
+	return true
+}
+
+func (e *partialError) Data() interface{} {
+	return e.data
+}
+
+type partialResolver struct{}
+
+func (r *partialResolver) Names() ([]string, *gqlerrors.QueryError) {
+	return []string{"a", "b"}, &gqlerrors.QueryError{Message: "source c is unavailable"}
+}
+
+func (r *partialResolver) Count() (int32, error) {
+	return 2, &partialError{}
+}
+
+func (r *partialResolver) Fallback() (*string, error) {
+	fallback := "cached"
+	return nil, &partialError{data: &fallback}
+}
+
+func (r *partialResolver) Invalid() (*string, error) {
+	return nil, &partialError{data: 1}
+}
+
+func TestPartialErrors(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			names: [String!]!
+			count: Int!
+			fallback: String
+			invalid: String
+		}
+	`, &partialResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					names
+					count
+					fallback
+				}
+			`,
+			ExpectedResult: `
+				{
+					"names": ["a", "b"],
+					"count": 2,
+					"fallback": "cached"
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: "some sources failed", Path: []interface{}{"count"}, ResolverError: &partialError{}},
+				{Message: "some sources failed", Path: []interface{}{"fallback"}, ResolverError: &partialError{data: &[]string{"cached"}[0]}},
+				{Message: "source c is unavailable", Path: []interface{}{"names"}},
+			},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					invalid
+				}
+			`,
+			ExpectedResult: `
+				{
+					"invalid": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: "graphql: partial data of type int can not be used as *string", Path: []interface{}{"invalid"}},
+			},
+		},
+	})
+}
+
+func TestCostDirectiveValidation(t *testing.T) {
+	const directive = `
+		directive @cost(
+			complexity: Int!
+			multipliers: [String!]
+			useMultipliers: Boolean = true
+		) on OBJECT | FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+	`
+	for _, tc := range []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			schema: `
+				type Query {
+					friends(first: Int): [String!]! @cost(complexity: 2, multipliers: ["first"])
+				}
+			`,
+		},
+		{
+			name: "negative complexity",
+			schema: `
+				type Query {
+					friends: [String!]! @cost(complexity: -5)
+				}
+			`,
+			wantErr: "@cost on Query.friends: complexity must not be negative, got -5",
+		},
+		{
+			name: "blank multiplier",
+			schema: `
+				type Query {
+					friends(first: Int): [String!]! @cost(complexity: 1, multipliers: ["first", " "])
+				}
+			`,
+			wantErr: "@cost on Query.friends: multiplier names must not be blank",
+		},
+		{
+			name: "negative argument complexity",
+			schema: `
+				type Query {
+					friends(first: Int @cost(complexity: -1)): [String!]!
+				}
+			`,
+			wantErr: "@cost on Query.friends.first: complexity must not be negative, got -1",
+		},
+		{
+			name: "input object multiplier",
+			schema: `
+				type Query {
+					friends(page: Page!): [String!]! @cost(complexity: 1, multipliers: ["page.first"])
+				}
+
+				input Page {
+					first: Int!
+				}
+			`,
+		},
+		{
+			name: "list multiplier",
+			schema: `
+				type Query {
+					search(tags: [String]): [String!]! @cost(complexity: 1, multipliers: ["tags"])
+				}
+			`,
+			wantErr: `@cost on Query.search: multiplier "tags" refers to an argument of type "[String]", but must be Int or Float`,
+		},
+		{
+			name: "enum multiplier",
+			schema: `
+				type Query {
+					friends(page: Page): [String!]! @cost(complexity: 1, multipliers: ["page.size"])
+				}
+
+				input Page {
+					size: Size!
+				}
+
+				enum Size {
+					SMALL
+					LARGE
+				}
+			`,
+			wantErr: `@cost on Query.friends: multiplier "page.size" refers to an argument of type "Size", but must be Int or Float`,
+		},
+		{
+			name: "first type in name order",
+			schema: `
+				type Query {
+					user: User @cost(complexity: -1)
+					admin: Admin
+				}
+
+				type User {
+					name: String @cost(complexity: -2)
+				}
+
+				type Admin {
+					name: String @cost(complexity: -3)
+				}
+			`,
+			wantErr: "@cost on Admin.name: complexity must not be negative, got -3",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := graphql.ParseSchema(directive+tc.schema, nil)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("wrong error, have=%v want=%s", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+type fieldContextResolver struct {
+	t *testing.T
+}
+
+func (r *fieldContextResolver) Hero(ctx context.Context, args struct{ Episode string }) *fieldContextResolver {
+	f, ok := graphql.FieldFromContext(ctx)
+	if !ok {
+		r.t.Error("missing field context for hero")
+		return nil
+	}
+	want := &graphql.FieldContext{
+		Alias:      "villain",
+		Path:       []interface{}{"villain"},
+		Args:       map[string]interface{}{"episode": "EMPIRE"},
+		ParentType: "Query",
+	}
+	if !reflect.DeepEqual(f, want) {
+		r.t.Errorf("wrong field context\nhave: %+v\nwant: %+v", f, want)
+	}
+	return r
+}
+
+func (r *fieldContextResolver) Friends(ctx context.Context) []*fieldContextResolver {
+	return []*fieldContextResolver{r}
+}
+
+func (r *fieldContextResolver) Name(ctx context.Context) string {
+	f, ok := graphql.FieldFromContext(ctx)
+	if !ok {
+		r.t.Error("missing field context for name")
+		return ""
+	}
+	b, _ := json.Marshal(f.Path)
+	return f.ParentType + " " + string(b)
+}
+
+func TestFieldContext(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: graphql.MustParseSchema(`
+				schema {
+					query: Query
+				}
+
+				type Query {
+					hero(episode: String!): Hero
+				}
+
+				type Hero {
+					name: String!
+					friends: [Hero!]!
+				}
+			`, &fieldContextResolver{t: t}),
+			Query: `
+				query($episode: String!) {
+					villain: hero(episode: $episode) {
+						friends {
+							name
+						}
+					}
+				}
+			`,
+			Variables: map[string]interface{}{"episode": "EMPIRE"},
+			ExpectedResult: `
+				{
+					"villain": {
+						"friends": [
+							{"name": "Hero [\"villain\",\"friends\",0,\"name\"]"}
+						]
+					}
+				}
+			`,
+		},
+	})
+}
+
+type fieldDirectivesResolver struct{}
+
+func (r *fieldDirectivesResolver) Hint(ctx context.Context) string {
+	b, err := json.Marshal(graphql.FieldDirectives(ctx))
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func TestFieldDirectives(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		directive @hint(value: String!, weight: Int = 1) on FIELD
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hint: String!
+		}
+	`, &fieldDirectivesResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($value: String!) {
+					direct: hint @hint(value: $value)
+					plain: hint
+					...Hinted
+				}
+
+				fragment Hinted on Query {
+					fromFragment: hint @hint(value: "fragment", weight: 2) @include(if: true)
+				}
+			`,
+			Variables: map[string]interface{}{"value": "fast"},
+			ExpectedResult: `
+				{
+					"direct": "[{\"Name\":\"hint\",\"Args\":{\"value\":\"fast\",\"weight\":1}}]",
+					"plain": "null",
+					"fromFragment": "[{\"Name\":\"hint\",\"Args\":{\"value\":\"fragment\",\"weight\":2}},{\"Name\":\"include\",\"Args\":{\"if\":true}}]"
+				}
+			`,
+		},
+	})
+}
+
+type deprecatedInputResolver struct{}
+
+func (r *deprecatedInputResolver) Search(args struct {
+	Query  *string
+	Filter *struct {
+		Name *string
+		Tag  *string
+	}
+}) string {
+	return ""
+}
+
+func TestIntrospectionDeprecatedInputValues(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			search(query: String @deprecated(reason: "Use filter instead."), filter: Filter): String!
+		}
+
+		input Filter {
+			name: String
+			tag: String @deprecated
+		}
+	`, &deprecatedInputResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					query: __type(name: "Query") {
+						fields {
+							args {
+								name
+								isDeprecated
+								deprecationReason
+							}
+						}
+					}
+					filter: __type(name: "Filter") {
+						inputFields {
+							name
+							isDeprecated
+							deprecationReason
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"query": {
+						"fields": [
+							{
+								"args": [
+									{"name": "query", "isDeprecated": true, "deprecationReason": "Use filter instead."},
+									{"name": "filter", "isDeprecated": false, "deprecationReason": null}
+								]
+							}
+						]
+					},
+					"filter": {
+						"inputFields": [
+							{"name": "name", "isDeprecated": false, "deprecationReason": null},
+							{"name": "tag", "isDeprecated": true, "deprecationReason": "No longer supported"}
+						]
+					}
+				}
+			`,
+		},
+	})
+}
+
+type maxStringLengthResolver struct{}
+
+func (r *maxStringLengthResolver) Post(args struct {
+	Title   string
+	Content string
+	Tags    *[]string
+}) string {
+	return args.Title
+}
+
+func TestMaxStringLength(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		directive @maxLength(max: Int!) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			post(title: String!, content: String! @maxLength(max: 10), tags: [ID!]): String!
+		}
+	`, &maxStringLengthResolver{}, graphql.MaxStringLength(5))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					post(title: "short", content: "0123456789", tags: ["a", "b"])
+				}
+			`,
+			ExpectedResult: `
+				{
+					"post": "short"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					post(title: "too long", content: "")
+				}
+			`,
+			ExpectedResult: `
+				{}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `value of "title" is 8 bytes long, exceeding the maximum length of 5 bytes`,
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($content: String!) {
+					post(title: "ok", content: $content)
+				}
+			`,
+			Variables: map[string]interface{}{"content": "01234567890"},
+			ExpectedResult: `
+				{}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `value of "content" is 11 bytes long, exceeding the maximum length of 10 bytes`,
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($tags: [ID!]) {
+					post(title: "ok", content: "", tags: $tags)
+				}
+			`,
+			Variables: map[string]interface{}{"tags": []interface{}{"a", "abcdef"}},
+			ExpectedResult: `
+				{}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `value of "tags" is 6 bytes long, exceeding the maximum length of 5 bytes`,
+			}},
+		},
+	})
+}
+
+type dateTimeCoercion struct{}
+
+func (dateTimeCoercion) CoerceInput(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return time.Parse(time.RFC3339, value)
+	case int32:
+		return time.Unix(int64(value), 0).UTC(), nil
+	case float64:
+		return time.Unix(int64(value), 0).UTC(), nil
+	default:
+		return nil, fmt.Errorf("expected RFC3339 string or unix timestamp")
+	}
+}
+
+func (dateTimeCoercion) CoerceOutput(value interface{}) (interface{}, error) {
+	t, ok := value.(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("expected time.Time, got %T", value)
+	}
+	if t.IsZero() {
+		return nil, fmt.Errorf("zero time")
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+type customScalarResolver struct{}
+
+func (r *customScalarResolver) Echo(args struct{ At time.Time }) time.Time {
+	return args.At
+}
+
+func (r *customScalarResolver) Zero() *time.Time {
+	return &time.Time{}
+}
+
+func TestCustomScalar(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		scalar DateTime
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			echo(at: DateTime!): DateTime!
+			zero: DateTime
+		}
+	`, &customScalarResolver{}, graphql.CustomScalar("DateTime", dateTimeCoercion{}))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($at: DateTime!) {
+					rfc3339: echo(at: "2020-01-02T03:04:05Z")
+					literal: echo(at: 1577934245)
+					variable: echo(at: $at)
+				}
+			`,
+			Variables: map[string]interface{}{"at": float64(1577934245)},
+			ExpectedResult: `
+				{
+					"rfc3339": "2020-01-02T03:04:05Z",
+					"literal": "2020-01-02T03:04:05Z",
+					"variable": "2020-01-02T03:04:05Z"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					echo(at: "yesterday")
+				}
+			`,
+			ExpectedResult: `
+				{}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `could not coerce "yesterday" (string) into DateTime: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					zero
+				}
+			`,
+			ExpectedResult: `
+				{
+					"zero": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `could not coerce 0001-01-01 00:00:00 +0000 UTC into DateTime: zero time`,
+				Path:    []interface{}{"zero"},
+			}},
+		},
+	})
+}
+
+func TestCustomScalarUnknownType(t *testing.T) {
+	_, err := graphql.ParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hello: String!
+		}
+	`, nil, graphql.CustomScalar("DateTime", dateTimeCoercion{}))
+	if err == nil || err.Error() != `can not bind coercion to "DateTime": not a scalar type of the schema` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateVariables(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items(n: Int, ids: [ID!]): [String!]!
+		}
+	`, nil)
+	query := `query($n: Int, $ids: [ID!]) { items(n: $n, ids: $ids) }`
+
+	if errs := s.ValidateVariables(query, "", map[string]interface{}{"n": 3.0, "ids": []interface{}{"a"}}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	want := []*gqlerrors.QueryError{
+		{
+			Message:   "Variable \"n\" has invalid value.\nExpected type \"Int\": could not unmarshal \"abc\" (string) into int32: incompatible type.",
+			Locations: []gqlerrors.Location{{Line: 1, Column: 7}},
+			Rule:      "VariablesOfCorrectType",
+		},
+		{
+			Message:   "Variable \"ids\" has invalid value at \"ids[1]\".\nExpected type \"ID\": wrong type for ID: bool.",
+			Locations: []gqlerrors.Location{{Line: 1, Column: 16}},
+			Rule:      "VariablesOfCorrectType",
+		},
+	}
+	errs := s.ValidateVariables(query, "", map[string]interface{}{"n": "abc", "ids": []interface{}{"a", true}})
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("got errors %v, want %v", errs, want)
+	}
+}
+
+func TestValidateVariablesLikeExec(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		scalar DateTime
+
+		input Event {
+			name: String!
+			at: DateTime!
+		}
+
+		type Query {
+			echo(text: String, at: DateTime, event: Event): String!
+		}
+	`, &validateVariablesResolver{}, graphql.CustomScalar("DateTime", dateTimeCoercion{}), graphql.MaxStringLength(5))
+
+	for _, tc := range []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		valid     bool
+	}{
+		{
+			name:      "valid values",
+			query:     `query($text: String, $at: DateTime) { echo(text: $text, at: $at) }`,
+			variables: map[string]interface{}{"text": "short", "at": float64(1577923200)},
+			valid:     true,
+		},
+		{
+			name:      "custom scalar rejected by its coercion",
+			query:     `query($at: DateTime) { echo(at: $at) }`,
+			variables: map[string]interface{}{"at": "yesterday"},
+		},
+		{
+			name:      "string longer than the maximum length",
+			query:     `query($text: String) { echo(text: $text) }`,
+			variables: map[string]interface{}{"text": "too long"},
+		},
+		{
+			name:      "input field longer than the maximum length",
+			query:     `query($event: Event) { echo(event: $event) }`,
+			variables: map[string]interface{}{"event": map[string]interface{}{"name": "too long", "at": float64(1577923200)}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := s.ValidateVariables(tc.query, "", tc.variables)
+			res := s.Exec(context.Background(), tc.query, "", tc.variables)
+			if tc.valid {
+				if len(errs) != 0 || len(res.Errors) != 0 {
+					t.Fatalf("unexpected errors: %v, %v", errs, res.Errors)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Rule != "VariablesOfCorrectType" {
+				t.Errorf("expected a variable error from ValidateVariables, got %v", errs)
+			}
+			if len(res.Errors) == 0 {
+				t.Errorf("expected Exec to fail, got %s", res.Data)
+			}
+		})
+	}
+}
+
+type validateVariablesResolver struct{}
+
+func (*validateVariablesResolver) Echo(args struct {
+	Text  *string
+	At    *time.Time
+	Event *struct {
+		Name string
+		At   time.Time
+	}
+}) string {
+	return "ok"
+}
+
+func TestValidationObserver(t *testing.T) {
+	var failures []graphql.ValidationFailure
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.ValidationObserver(func(f graphql.ValidationFailure) {
+		failures = append(failures, f)
+	}))
+
+	queryString := `
+		query HeroName {
+			hero {
+				name
+				nickname
+			}
+		}
+	`
+	result := schema.Exec(context.Background(), queryString, "", nil)
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Cannot query field "nickname" on type "Character".` {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	if len(failures) != 1 {
+		t.Fatalf("expected one validation failure, got %d", len(failures))
+	}
+	f := failures[0]
+	if f.Query != queryString || f.OperationName != "HeroName" || f.OperationType != "query" {
+		t.Errorf("unexpected operation: %q %q", f.OperationName, f.OperationType)
+	}
+	if !reflect.DeepEqual(f.Errors, result.Errors) {
+		t.Errorf("got errors %v, want %v", f.Errors, result.Errors)
+	}
+
+	schema.Exec(context.Background(), `{ hero { name } }`, "", nil)
+	if len(failures) != 1 {
+		t.Errorf("observer called for a valid query")
+	}
+}
+
+type chanListResolver struct {
+	started  chan struct{}
+	resume   chan struct{}
+	finished chan struct{}
+}
+
+type chanItemResolver struct {
+	id int32
+}
+
+func (r *chanItemResolver) ID() int32 {
+	return r.id
+}
+
+func (r *chanItemResolver) Double(ctx context.Context) int32 {
+	return 2 * r.id
+}
+
+func (r *chanListResolver) Items(args struct{ Count int32 }) <-chan *chanItemResolver {
+	c := make(chan *chanItemResolver)
+	go func() {
+		defer close(c)
+		for i := int32(1); i <= args.Count; i++ {
+			c <- &chanItemResolver{id: i}
+		}
+	}()
+	return c
+}
+
+func (r *chanListResolver) Names() <-chan string {
+	return nil
+}
+
+func (r *chanListResolver) Endless() <-chan *chanItemResolver {
+	c := make(chan *chanItemResolver)
+	go func() {
+		defer close(r.finished)
+		defer close(c)
+		for i := int32(1); i <= 10; i++ {
+			if i == 3 {
+				close(r.started)
+				<-r.resume
+			}
+			c <- &chanItemResolver{id: i}
+		}
+	}()
+	return c
+}
+
+func TestChannelListResolvers(t *testing.T) {
+	schemaString := `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items(count: Int!): [Item!]!
+			names: [String!]
+			endless: [Item!]
+		}
+
+		type Item {
+			id: Int!
+			double: Int!
+		}
+	`
+	schema := graphql.MustParseSchema(schemaString, &chanListResolver{}, graphql.MaxParallelism(2))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					items(count: 4) {
+						id
+						double
+					}
+					none: items(count: 0) {
+						id
+					}
+					names
+				}
+			`,
+			ExpectedResult: `
+				{
+					"items": [
+						{"id": 1, "double": 2},
+						{"id": 2, "double": 4},
+						{"id": 3, "double": 6},
+						{"id": 4, "double": 8}
+					],
+					"none": [],
+					"names": null
+				}
+			`,
+		},
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		resolver := &chanListResolver{
+			started:  make(chan struct{}),
+			resume:   make(chan struct{}),
+			finished: make(chan struct{}),
+		}
+		schema := graphql.MustParseSchema(schemaString, resolver)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-resolver.started
+			cancel()
+		}()
+		result := schema.Exec(ctx, `{ endless { id } }`, "", nil)
+		close(resolver.resume)
+		if result.Data != nil {
+			t.Errorf("unexpected data: %s", result.Data)
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Message != context.Canceled.Error() {
+			t.Errorf("unexpected errors: %v", result.Errors)
+		}
+		select {
+		case <-resolver.finished:
+		case <-time.After(time.Second):
+			t.Error("channel was not drained")
+		}
+	})
+
+	t.Run("channel for a non-list field", func(t *testing.T) {
+		_, err := graphql.ParseSchema(`
+			schema {
+				query: Query
+			}
+
+			type Query {
+				names: String
+			}
+		`, &chanListResolver{})
+		if err == nil || !strings.Contains(err.Error(), "channels can only resolve lists") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+type mapBackedResolver struct{}
+
+func (r *mapBackedResolver) User() map[string]interface{} {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"id": 1,
+		"name": "Alice",
+		"role": "ADMIN",
+		"score": 4.5,
+		"address": {"city": "Berlin"},
+		"friends": [
+			{"id": "2", "name": "Bob", "friends": []},
+			{"id": 3, "name": "Carol", "address": null}
+		]
+	}`), &data); err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func (r *mapBackedResolver) Nobody() map[string]interface{} {
+	return nil
+}
+
+func (r *mapBackedResolver) Broken() map[string]interface{} {
+	return map[string]interface{}{"id": "1", "name": 42}
+}
+
+func (r *mapBackedResolver) Partial() map[string]interface{} {
+	return map[string]interface{}{"id": "4", "address": map[string]interface{}{}}
+}
+
+func TestMapBackedObjects(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			user: User!
+			nobody: User
+			broken: User
+			partial: User
+		}
+
+		enum Role {
+			ADMIN
+			USER
+		}
+
+		type User {
+			id: ID!
+			name: String!
+			role: Role
+			score: Float
+			age: Int
+			address: Address
+			friends: [User!]
+		}
+
+		type Address {
+			city: String!
+		}
+	`, &mapBackedResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					user {
+						__typename
+						id
+						name
+						role
+						score
+						age
+						address {
+							city
+						}
+						friends {
+							id
+							name
+							address {
+								city
+							}
+							friends {
+								id
+							}
+						}
+					}
+					nobody {
+						id
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"user": {
+						"__typename": "User",
+						"id": "1",
+						"name": "Alice",
+						"role": "ADMIN",
+						"score": 4.5,
+						"age": null,
+						"address": {
+							"city": "Berlin"
+						},
+						"friends": [
+							{"id": "2", "name": "Bob", "address": null, "friends": []},
+							{"id": "3", "name": "Carol", "address": null, "friends": null}
+						]
+					},
+					"nobody": null
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					broken {
+						id
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"broken": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `graphql: can not resolve 42 as "String": expected a string, got int`,
+				Path:    []interface{}{"broken", "name"},
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					user {
+						userID: id
+						fullName: name
+						home: address {
+							town: city
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"user": {
+						"userID": "1",
+						"fullName": "Alice",
+						"home": {
+							"town": "Berlin"
+						}
+					}
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					partial {
+						id
+						age
+					}
+					withName: partial {
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"partial": {
+						"id": "4",
+						"age": null
+					},
+					"withName": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `graphql: got nil for non-null "String"`,
+				Path:    []interface{}{"withName", "name"},
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					partial {
+						address {
+							city
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"partial": {
+						"address": null
+					}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `graphql: got nil for non-null "String"`,
+				Path:    []interface{}{"partial", "address", "city"},
+			}},
+		},
+	})
+}
+
+func TestDuplicateOperationNames(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: starwarsSchema,
+			Query: `
+				query Foo {
+					hero {
+						name
+					}
+				}
+
+				query Foo {
+					hero {
+						id
+					}
+				}
+			`,
+			OperationName: "Foo",
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:   `There can be only one operation named "Foo".`,
+					Locations: []gqlerrors.Location{{Line: 2, Column: 11}, {Line: 8, Column: 11}},
+					Rule:      "UniqueOperationNames",
+				},
+			},
+		},
+		{
+			Schema: starwarsSchema,
+			Query: `
+				query Foo {
+					hero {
+						name
+					}
+				}
+
+				mutation Foo {
+					createReview(episode: JEDI, review: {stars: 5}) {
+						stars
+					}
+				}
+			`,
+			OperationName: "Foo",
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:   `There can be only one operation named "Foo".`,
+					Locations: []gqlerrors.Location{{Line: 2, Column: 11}, {Line: 8, Column: 14}},
+					Rule:      "UniqueOperationNames",
+				},
+			},
+		},
+		{
+			Schema: starwarsSchema,
+			Query: `
+				query Foo {
+					hero {
+						name
+					}
+				}
+
+				query Bar {
+					hero {
+						id
+					}
+				}
+			`,
+			OperationName: "Bar",
+			ExpectedResult: `
+				{
+					"hero": {
+						"id": "2001"
+					}
+				}
+			`,
+		},
+	})
+}
+
+type priorityResolver struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *priorityResolver) resolve(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append(r.order, name)
+	return name
+}
+
+func (r *priorityResolver) Fast(ctx context.Context) string {
+	return r.resolve("fast")
+}
+
+func (r *priorityResolver) Slow(ctx context.Context) string {
+	return r.resolve("slow")
+}
+
+func (r *priorityResolver) Slower(ctx context.Context) string {
+	return r.resolve("slower")
+}
+
+func TestPriorityDirective(t *testing.T) {
+	r := &priorityResolver{}
+	schema := graphql.MustParseSchema(`
+		directive @priority(weight: Int!) on FIELD | FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			fast: String!
+			slow: String! @priority(weight: 1)
+			slower: String!
+		}
+	`, r, graphql.MaxParallelism(1))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					fast
+					slow
+					slower @priority(weight: 2)
+					again: fast
+				}
+			`,
+			ExpectedResult: `
+				{
+					"fast": "fast",
+					"slow": "slow",
+					"slower": "slower",
+					"again": "fast"
+				}
+			`,
+		},
+	})
+
+	if want := []string{"slower", "slow", "fast", "fast"}; !reflect.DeepEqual(r.order, want) {
+		t.Errorf("resolvers were called in order %v, want %v", r.order, want)
+	}
+}
+
+type visibleFieldsResolver struct{}
+
+func (r *visibleFieldsResolver) Public() string {
+	return "public"
+}
+
+func (r *visibleFieldsResolver) Secret() *visibleFieldsSecretResolver {
+	return &visibleFieldsSecretResolver{}
+}
+
+type visibleFieldsSecretResolver struct{}
+
+func (r *visibleFieldsSecretResolver) Code() int32 {
+	return 42
+}
+
+func TestWithVisibleFields(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			public: String!
+			secret: Secret
+		}
+
+		type Secret {
+			code: Int!
+		}
+	`, &visibleFieldsResolver{})
+	ctx := graphql.WithVisibleFields(context.Background(), func(typeName, fieldName string) bool {
+		return typeName != "Query" || fieldName != "secret"
+	})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:  schema,
+			Context: ctx,
+			Query: `
+				{
+					__schema {
+						types {
+							name
+						}
+					}
+					__type(name: "Query") {
+						fields {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"__schema": {
+						"types": [
+							{"name": "Boolean"},
+							{"name": "Query"},
+							{"name": "String"},
+							{"name": "__Directive"},
+							{"name": "__DirectiveLocation"},
+							{"name": "__EnumValue"},
+							{"name": "__Field"},
+							{"name": "__InputValue"},
+							{"name": "__Schema"},
+							{"name": "__Type"},
+							{"name": "__TypeKind"}
+						]
+					},
+					"__type": {
+						"fields": [
+							{"name": "public"}
+						]
+					}
+				}
+			`,
+		},
+		{
+			Schema:  schema,
+			Context: ctx,
+			Query: `
+				{
+					secret: __type(name: "Secret") {
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					__type(name: "Secret") {
+						name
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"__type": {
+						"name": "Secret"
+					}
+				}
+			`,
+		},
+	})
+}
+
+type nonNullErrorResolver struct{}
+
+func (r *nonNullErrorResolver) Hero() *nonNullErrorHeroResolver {
+	return &nonNullErrorHeroResolver{}
+}
+
+type nonNullErrorHeroResolver struct{}
+
+func (r *nonNullErrorHeroResolver) Name() string {
+	return "Luke"
+}
+
+func (r *nonNullErrorHeroResolver) Friend() *nonNullErrorHeroResolver {
+	return nil
+}
+
+func (r *nonNullErrorHeroResolver) Friends() *[]*nonNullErrorHeroResolver {
+	return &[]*nonNullErrorHeroResolver{{}, nil}
+}
+
+func TestNonNullError(t *testing.T) {
+	schemaString := `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hero: Hero
+		}
+
+		type Hero {
+			name: String!
+			friend: Hero!
+			friends: [Hero!]
+		}
+	`
+	factory := func(path []interface{}, typeName, fieldName string) *gqlerrors.QueryError {
+		return &gqlerrors.QueryError{
+			Message:    fmt.Sprintf("%s.%s is missing", typeName, fieldName),
+			Extensions: map[string]interface{}{"code": "NULL_VALUE"},
+		}
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: graphql.MustParseSchema(schemaString, &nonNullErrorResolver{}, graphql.NonNullError(factory)),
+			Query: `
+				{
+					hero {
+						name
+						friend {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hero": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:    "Hero.friend is missing",
+				Path:       []interface{}{"hero", "friend"},
+				Extensions: map[string]interface{}{"code": "NULL_VALUE"},
+			}},
+		},
+		{
+			Schema: graphql.MustParseSchema(schemaString, &nonNullErrorResolver{}, graphql.NonNullError(factory)),
+			Query: `
+				{
+					hero {
+						friends {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hero": {
+						"friends": null
+					}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:    "Hero.friends is missing",
+				Path:       []interface{}{"hero", "friends", 1},
+				Extensions: map[string]interface{}{"code": "NULL_VALUE"},
+			}},
+		},
+		{
+			Schema: graphql.MustParseSchema(schemaString, &nonNullErrorResolver{}),
+			Query: `
+				{
+					hero {
+						friend {
+							name
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"hero": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `graphql: got nil for non-null "Hero"`,
+				Path:    []interface{}{"hero", "friend"},
+			}},
+		},
+	})
+}
+
+type listCoercionResolver struct{}
+
+type listCoercionFilter struct {
+	IDs    *[]*[]int32
+	Colors *[]string
+}
+
+func (r *listCoercionResolver) Filter(args struct {
+	IDs     []int32
+	Filters *[]*listCoercionFilter
+}) string {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func TestListInputCoercion(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			filter(ids: [Int!]!, filters: [Filter]): String!
+		}
+
+		input Filter {
+			ids: [[Int!]]
+			colors: [Color!]
+		}
+
+		enum Color {
+			RED
+			BLUE
+		}
+	`, &listCoercionResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					filter(ids: 1, filters: {ids: 2, colors: RED})
+				}
+			`,
+			ExpectedResult: `
+				{
+					"filter": "{\"IDs\":[1],\"Filters\":[{\"IDs\":[[2]],\"Colors\":[\"RED\"]}]}"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($ids: [Int!]!, $filters: [Filter]) {
+					filter(ids: $ids, filters: $filters)
+				}
+			`,
+			Variables: map[string]interface{}{
+				"ids":     3,
+				"filters": map[string]interface{}{"ids": 4, "colors": "BLUE"},
+			},
+			ExpectedResult: `
+				{
+					"filter": "{\"IDs\":[3],\"Filters\":[{\"IDs\":[[4]],\"Colors\":[\"BLUE\"]}]}"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($filter: Filter) {
+					filter(ids: [5, 6], filters: [$filter])
+				}
+			`,
+			Variables: map[string]interface{}{
+				"filter": map[string]interface{}{"ids": []interface{}{7, 8}},
+			},
+			ExpectedResult: `
+				{
+					"filter": "{\"IDs\":[5,6],\"Filters\":[{\"IDs\":[[7],[8]],\"Colors\":null}]}"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($filters: [Filter]) {
+					filter(ids: 1, filters: $filters)
+				}
+			`,
+			Variables: map[string]interface{}{
+				"filters": map[string]interface{}{"colors": "GREEN"},
+			},
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Variable \"filters\" has invalid value GREEN at \"filters.colors\".\nExpected type \"Color\", found GREEN.",
+				Locations: []gqlerrors.Location{{Line: 2, Column: 11}},
+				Rule:      "VariablesOfCorrectType",
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					filter(ids: "1")
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"ids\" has invalid value \"1\".\nExpected type \"Int\", found \"1\".",
+				Locations: []gqlerrors.Location{{Line: 3, Column: 18}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+	})
+}
+
+type selectedFieldsResolver struct {
+	fields graphql.SelectedFields
+}
+
+func (r *selectedFieldsResolver) Search(args struct{ Text string }, fields graphql.SelectedFields) []*selectedFieldsResult {
+	r.fields = fields
+	return []*selectedFieldsResult{{&selectedFieldsHuman{}}}
+}
+
+type selectedFieldsResult struct {
+	result interface{}
+}
+
+func (r *selectedFieldsResult) ToHuman() (*selectedFieldsHuman, bool) {
+	h, ok := r.result.(*selectedFieldsHuman)
+	return h, ok
+}
+
+func (r *selectedFieldsResult) ToDroid() (*selectedFieldsDroid, bool) {
+	d, ok := r.result.(*selectedFieldsDroid)
+	return d, ok
+}
+
+type selectedFieldsHuman struct{}
+
+func (h *selectedFieldsHuman) Name() string {
+	return "Luke"
+}
+
+func (h *selectedFieldsHuman) Height() float64 {
+	return 1.72
+}
+
+type selectedFieldsDroid struct{}
+
+func (d *selectedFieldsDroid) Name() string {
+	return "R2-D2"
+}
+
+func (d *selectedFieldsDroid) PrimaryFunction() string {
+	return "Astromech"
+}
+
+func TestSelectedFields(t *testing.T) {
+	r := &selectedFieldsResolver{}
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			search(text: String!): [SearchResult!]!
+		}
+
+		union SearchResult = Human | Droid
+
+		type Human {
+			name: String!
+			height: Float!
+		}
+
+		type Droid {
+			name: String!
+			primaryFunction: String!
+		}
+	`, r)
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					search(text: "a") {
+						__typename
+						... on Human {
+							name
+							size: height
+						}
+						...DroidFields
+					}
+				}
+
+				fragment DroidFields on Droid {
+					name
+					primaryFunction
+					name
+				}
+			`,
+			ExpectedResult: `
+				{
+					"search": [
+						{"__typename": "Human", "name": "Luke", "size": 1.72}
+					]
+				}
+			`,
+		},
+	})
+
+	want := graphql.SelectedFields{
+		ByType: map[string][]graphql.SelectedField{
+			"Human": {{Name: "name", Alias: "name"}, {Name: "height", Alias: "size"}},
+			"Droid": {{Name: "name", Alias: "name"}, {Name: "primaryFunction", Alias: "primaryFunction"}},
+		},
+	}
+	if !reflect.DeepEqual(r.fields, want) {
+		t.Errorf("got selected fields %+v, want %+v", r.fields, want)
+	}
+}
+
+type touchedEntitiesResolver struct{}
+
+func (r *touchedEntitiesResolver) Users(args struct{ Count int32 }) []*touchedEntityUser {
+	users := make([]*touchedEntityUser, args.Count)
+	for i := range users {
+		users[i] = &touchedEntityUser{id: fmt.Sprint(i % 50)}
+	}
+	return users
+}
+
+type touchedEntityUser struct {
+	id string
+}
+
+func (u *touchedEntityUser) ID(ctx context.Context) graphql.ID {
+	graphql.TouchEntity(ctx, "User", u.id)
+	return graphql.ID(u.id)
+}
+
+func (u *touchedEntityUser) Friend(ctx context.Context) *touchedEntityUser {
+	graphql.TouchEntity(ctx, "Friend", u.id)
+	return &touchedEntityUser{id: "friend-" + u.id}
+}
+
+func TestTouchEntity(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			users(count: Int!): [User!]!
+		}
+
+		type User {
+			id: ID!
+			friend: User!
+		}
+	`, &touchedEntitiesResolver{}, graphql.CacheControl(60), graphql.MaxParallelism(20))
+
+	res := s.Exec(context.Background(), `{ users(count: 200) { id friend { id } } }`, "", nil)
+	if len(res.Errors) != 0 {
+		t.Fatal(res.Errors)
+	}
+
+	var want []graphql.EntityTag
+	for i := 0; i < 50; i++ {
+		want = append(want, graphql.EntityTag{TypeName: "Friend", ID: fmt.Sprint(i)})
+	}
+	for i := 0; i < 50; i++ {
+		want = append(want, graphql.EntityTag{TypeName: "User", ID: fmt.Sprint(i)})
+	}
+	for i := 0; i < 50; i++ {
+		want = append(want, graphql.EntityTag{TypeName: "User", ID: fmt.Sprintf("friend-%d", i)})
+	}
+	sort.Slice(want, func(i, j int) bool {
+		if want[i].TypeName != want[j].TypeName {
+			return want[i].TypeName < want[j].TypeName
+		}
+		return want[i].ID < want[j].ID
+	})
+	if !reflect.DeepEqual(res.CachePolicy.Entities, want) {
+		t.Errorf("wrong entities\nexpected: %v\ngot:      %v", want, res.CachePolicy.Entities)
+	}
+
+	res = s.Exec(context.Background(), `{ users(count: 0) { id } }`, "", nil)
+	if res.CachePolicy.Entities != nil {
+		t.Errorf("expected no entities, got %v", res.CachePolicy.Entities)
+	}
+}
+
+type cancelResolver struct {
+	cancel func()
+	called []string
+}
+
+func (r *cancelResolver) First() string {
+	r.called = append(r.called, "first")
+	r.cancel()
+	return "first"
+}
+
+func (r *cancelResolver) Second() string {
+	r.called = append(r.called, "second")
+	return "second"
+}
+
+func (r *cancelResolver) User() *cancelUserResolver {
+	r.called = append(r.called, "user")
+	return &cancelUserResolver{r}
+}
+
+type cancelUserResolver struct {
+	r *cancelResolver
+}
+
+func (u *cancelUserResolver) Name() string {
+	u.r.called = append(u.r.called, "name")
+	return "name"
+}
+
+func TestContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &cancelResolver{cancel: cancel}
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			first: String!
+			second: String!
+			user: User
+		}
+
+		type User {
+			name: String!
+		}
+	`, r)
+
+	res := schema.Exec(ctx, `{ first second user { name } }`, "", nil)
+	if len(res.Errors) != 1 || res.Errors[0].Message != context.Canceled.Error() {
+		t.Errorf("expected a context canceled error, got %v", res.Errors)
+	}
+	if want := []string{"first"}; !reflect.DeepEqual(r.called, want) {
+		t.Errorf("resolvers %v were called, want %v", r.called, want)
+	}
+}
+
+const federationSchema = `
+	type Query {
+		me: User
+	}
+
+	type User @key(fields: "id") {
+		id: ID!
+		name: String!
+	}
+`
+
+type federationResolver struct{}
+
+func (r *federationResolver) Me() *federationUser {
+	return &federationUser{id: "1", name: "Alice"}
+}
+
+func (r *federationResolver) ResolveUserReference(rep map[string]interface{}) (*federationUser, error) {
+	id, _ := rep["id"].(string)
+	switch id {
+	case "1":
+		return &federationUser{id: "1", name: "Alice"}, nil
+	case "2":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown user %q", id)
+}
+
+type federationUser struct {
+	id   graphql.ID
+	name string
+}
+
+func (u *federationUser) ID() graphql.ID { return u.id }
+func (u *federationUser) Name() string   { return u.name }
+
+func TestFederation(t *testing.T) {
+	schema := graphql.MustParseSchema(federationSchema, &federationResolver{}, graphql.Federation())
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($reps: [_Any!]!) {
+					_entities(representations: $reps) {
+						__typename
+						... on User {
+							id
+							name
+						}
+					}
+				}
+			`,
+			Variables: map[string]interface{}{
+				"reps": []interface{}{
+					map[string]interface{}{"__typename": "User", "id": "1"},
+					map[string]interface{}{"__typename": "User", "id": "2"},
+				},
+			},
+			ExpectedResult: `
+				{
+					"_entities": [
+						{"__typename": "User", "id": "1", "name": "Alice"},
+						null
+					]
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($reps: [_Any!]!) {
+					_entities(representations: $reps) {
+						... on User {
+							name
+						}
+					}
+				}
+			`,
+			Variables: map[string]interface{}{
+				"reps": []interface{}{
+					map[string]interface{}{"__typename": "User", "id": "3"},
+				},
+			},
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:       `unknown user "3"`,
+					ResolverError: fmt.Errorf(`unknown user "3"`),
+					Path:          []interface{}{"_entities"},
+				},
+			},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					_service {
+						sdl
+					}
+				}
+			`,
+			ExpectedResult: fmt.Sprintf(`{"_service": {"sdl": %q}}`, federationSchema),
+		},
+	})
+
+	_, err := graphql.ParseSchema(federationSchema, &helloWorldResolver1{}, graphql.Federation())
+	if err == nil {
+		t.Error("expected an error for a missing reference resolver")
+	}
+}
+
+func TestTimingTree(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.TimingTree())
+
+	resp := schema.Exec(context.Background(), `
+		{
+			hero {
+				name
+				friends {
+					name
+				}
+			}
+		}
+	`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	if resp.Timing == nil {
+		t.Fatal("expected a timing tree")
+	}
+
+	var shape func(n *graphql.TimingNode) string
+	shape = func(n *graphql.TimingNode) string {
+		var b strings.Builder
+		b.WriteString(n.FieldName)
+		if len(n.Children) > 0 {
+			b.WriteString("{")
+			for i, c := range n.Children {
+				if i > 0 {
+					b.WriteString(" ")
+				}
+				b.WriteString(shape(c))
+			}
+			b.WriteString("}")
+		}
+		return b.String()
+	}
+	if got, want := shape(resp.Timing), "{hero{name friends{name name name}}}"; got != want {
+		t.Errorf("got tree %s, want %s", got, want)
+	}
+
+	hero := resp.Timing.Children[0]
+	if !hero.Async || hero.ReturnType != "Character" || !reflect.DeepEqual(hero.Path, []interface{}{"hero"}) {
+		t.Errorf("unexpected hero node %+v", hero)
+	}
+	if name := hero.Children[0]; name.Async {
+		t.Errorf("expected the name node to be sync: %+v", name)
+	}
+	friend := hero.Children[1].Children[2]
+	if want := []interface{}{"hero", "friends", 2, "name"}; !reflect.DeepEqual(friend.Path, want) {
+		t.Errorf("got path %v, want %v", friend.Path, want)
+	}
+	if hero.Duration > resp.Timing.Duration {
+		t.Errorf("field duration %s exceeds execution duration %s", hero.Duration, resp.Timing.Duration)
+	}
+}
+
+type operationErrorResolver struct{}
+
+func (r *operationErrorResolver) Fail() (*string, error) {
+	return nil, errors.New("failed")
+}
+
+func TestOperationInErrors(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			fail: String
+		}
+	`, &operationErrorResolver{}, graphql.OperationInErrors())
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "named resolver error",
+			query: `query GetFail { fail }`,
+			want:  map[string]interface{}{"name": "GetFail", "type": "query"},
+		},
+		{
+			name:  "anonymous resolver error",
+			query: `{ fail }`,
+			want:  map[string]interface{}{"type": "query"},
+		},
+		{
+			name:  "validation error",
+			query: `query GetUnknown { unknown }`,
+			want:  map[string]interface{}{"name": "GetUnknown", "type": "query"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := schema.Exec(context.Background(), tc.query, "", nil)
+			if len(resp.Errors) != 1 {
+				t.Fatalf("expected one error, got %v", resp.Errors)
+			}
+			if got := resp.Errors[0].Extensions["operation"]; !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got operation %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	plain := graphql.MustParseSchema(`
+		type Query {
+			fail: String
+		}
+	`, &operationErrorResolver{})
+	if resp := plain.Exec(context.Background(), `{ fail }`, "", nil); len(resp.Errors) != 1 || resp.Errors[0].Extensions != nil {
+		t.Errorf("expected an error without extensions, got %v", resp.Errors)
+	}
+}
+
+func TestFragmentTypeConditions(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "unknown inline fragment type",
+			query: `{ hero { ... on Wizard { name } } }`,
+			want:  []string{`KnownTypeNames: Unknown type "Wizard".`},
+		},
+		{
+			name:  "unknown fragment type",
+			query: `{ hero { ...wizardFields } } fragment wizardFields on Wizard { name }`,
+			want:  []string{`KnownTypeNames: Unknown type "Wizard".`},
+		},
+		{
+			name:  "scalar inline fragment type",
+			query: `{ hero { ... on String { length } } }`,
+			want: []string{
+				`FragmentsOnCompositeTypes: Fragment cannot condition on non composite type "String".`,
+				`PossibleFragmentSpreads: Fragment cannot be spread here as objects of type "Character" can never be of type "String".`,
+			},
+		},
+		{
+			name:  "enum fragment type",
+			query: `{ hero { ...episodeFields } } fragment episodeFields on Episode { name }`,
+			want: []string{
+				`FragmentsOnCompositeTypes: Fragment "episodeFields" cannot condition on non composite type "Episode".`,
+				`PossibleFragmentSpreads: Fragment "episodeFields" cannot be spread here as objects of type "Character" can never be of type "Episode".`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := starwarsSchema.Exec(context.Background(), tc.query, "", nil)
+			if resp.Data != nil {
+				t.Errorf("expected no data, got %s", resp.Data)
+			}
+			var got []string
+			for _, err := range resp.Errors {
+				got = append(got, err.Rule+": "+err.Message)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got errors %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxFragmentExpansion(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.MaxFragmentExpansion(1000))
+
+	// Every fragment spreads the next one ten times, so the query expands to 10^30 fields.
+	var b strings.Builder
+	b.WriteString("{ hero { ...f0 } }\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&b, "fragment f%d on Character { %s }\n", i, strings.Repeat(fmt.Sprintf("...f%d ", i+1), 10))
+	}
+	b.WriteString("fragment f30 on Character { name }\n")
+
+	resp := schema.Exec(context.Background(), b.String(), "", nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Rule != "MaxFragmentExpansionExceeded" {
+		t.Errorf("expected a MaxFragmentExpansionExceeded error, got %v", resp.Errors)
+	}
+
+	resp = schema.Exec(context.Background(), `
+		{
+			search(text: "a") {
+				...a
+			}
+		}
+
+		fragment a on SearchResult {
+			... on Human {
+				...b
+			}
+		}
+
+		fragment b on Character {
+			... on Droid {
+				...a
+			}
+		}
+	`, "", nil)
+	var rules []string
+	for _, err := range resp.Errors {
+		rules = append(rules, err.Rule)
+	}
+	if len(rules) == 0 || rules[0] != "NoFragmentCycles" {
+		t.Errorf("expected a NoFragmentCycles error, got %v", resp.Errors)
+	}
+
+	resp = schema.Exec(context.Background(), `{ hero { ...a } } fragment a on Character { name friends { name } }`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Errorf("unexpected errors: %v", resp.Errors)
+	}
+}
+
+type sumResolver struct{}
+
+func (r *sumResolver) Sum(args struct{ Values []int32 }) int32 {
+	var sum int32
+	for _, v := range args.Values {
+		sum += v
+	}
+	return sum
+}
+
+func TestListElementCoercionError(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		type Query {
+			sum(values: [Int!]!): Int!
+		}
+	`, &sumResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($values: [Int!]!) {
+					sum(values: $values)
+				}
+			`,
+			Variables:      map[string]interface{}{"values": []interface{}{1, "two", 3}},
+			ExpectedResult: `{}`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{Message: `element #1: could not unmarshal "two" (string) into int32: incompatible type`},
+			},
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					sum(values: [1, "two", 3])
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:   "Argument \"values\" has invalid value [1, \"two\", 3].\nIn element #1: Expected type \"Int\", found \"two\".",
+					Locations: []gqlerrors.Location{{Line: 3, Column: 18}},
+					Rule:      "ArgumentsOfCorrectType",
+				},
+			},
+		},
+	})
+}
+
+type inputDepthFilter struct {
+	Name *string
+	And  *inputDepthFilter
+}
+
+// inputDepthJSON is a custom scalar that takes any value, including nested objects.
+type inputDepthJSON struct {
+	value interface{}
+}
+
+func (*inputDepthJSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *inputDepthJSON) UnmarshalGraphQL(input interface{}) error {
+	j.value = input
+	return nil
+}
+
+type inputDepthResolver struct{}
+
+func (r *inputDepthResolver) JSON(args struct{ Value *inputDepthJSON }) bool {
+	return args.Value != nil
+}
+
+func (r *inputDepthResolver) Depth(args struct{ Filter *inputDepthFilter }) int32 {
+	var depth int32
+	for f := args.Filter; f != nil; f = f.And {
+		depth++
+	}
+	return depth
+}
+
+func TestMaxInputDepth(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		scalar JSON
+
+		type Query {
+			depth(filter: Filter): Int!
+			json(value: JSON): Boolean!
+		}
+
+		input Filter {
+			name: String
+			and: Filter
+		}
+	`, &inputDepthResolver{}, graphql.MaxInputDepth(3))
+
+	nested := func(depth int) map[string]interface{} {
+		filter := map[string]interface{}{"name": "a"}
+		for i := 1; i < depth; i++ {
+			filter = map[string]interface{}{"and": filter}
+		}
+		return filter
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					depth(filter: {and: {and: {name: "a"}}})
+				}
+			`,
+			ExpectedResult: `
+				{
+					"depth": 3
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				{
+					depth(filter: {and: {and: {and: {name: "a"}}}})
+				}
+			`,
+			ExpectedResult: `{}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: "input objects are nested deeper than the maximum depth of 3",
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($filter: Filter) {
+					depth(filter: $filter)
+				}
+			`,
+			Variables:      map[string]interface{}{"filter": nested(1000)},
+			ExpectedResult: `{}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: "input objects are nested deeper than the maximum depth of 3",
+			}},
+		},
+		{
+			// Objects in the values of custom scalars are not input objects.
+			Schema: schema,
+			Query: `
+				query($value: JSON) {
+					json(value: $value)
+				}
+			`,
+			Variables: map[string]interface{}{"value": nested(10)},
+			ExpectedResult: `
+				{
+					"json": true
+				}
+			`,
+		},
+	})
+}
+
+type oneOfFilter struct {
+	ID   *graphql.ID
+	Name *string
+	Tag  *oneOfTagFilter
+}
+
+type oneOfTagFilter struct {
+	Name   *string
+	Prefix *string
+}
+
+type oneOfResolver struct{}
+
+func (r *oneOfResolver) Find(args struct{ Filter oneOfFilter }) string {
+	switch {
+	case args.Filter.ID != nil:
+		return "id " + string(*args.Filter.ID)
+	case args.Filter.Name != nil:
+		return "name " + *args.Filter.Name
+	case args.Filter.Tag.Name != nil:
+		return "tag " + *args.Filter.Tag.Name
+	default:
+		return "tag prefix " + *args.Filter.Tag.Prefix
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			find(filter: Filter!): String!
+		}
+
+		input Filter @oneOf {
+			id: ID
+			name: String
+			tag: TagFilter
+		}
+
+		input TagFilter @oneOf {
+			name: String
+			prefix: String
+		}
+	`, &oneOfResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         schema,
+			Query:          `{ find(filter: {id: "1"}) }`,
+			ExpectedResult: `{"find": "id 1"}`,
+		},
+		{
+			Schema:         schema,
+			Query:          `{ find(filter: {tag: {prefix: "a"}}) }`,
+			ExpectedResult: `{"find": "tag prefix a"}`,
+		},
+		{
+			Schema: schema,
+			Query:  `{ find(filter: {}) }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"filter\" has invalid value {}.\nOneOf input object \"Filter\" must specify exactly one field.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 16}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+		{
+			Schema: schema,
+			Query:  `{ find(filter: {id: "1", name: "a"}) }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"filter\" has invalid value {id: \"1\", name: \"a\"}.\nOneOf input object \"Filter\" must specify exactly one field.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 16}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+		{
+			Schema: schema,
+			Query:  `{ find(filter: {id: null}) }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"filter\" has invalid value {id: null}.\nIn field \"id\": OneOf input object \"Filter\" requires a non-null value.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 16}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+		{
+			Schema: schema,
+			Query:  `{ find(filter: {tag: {name: "a", prefix: "b"}}) }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"filter\" has invalid value {tag: {name: \"a\", prefix: \"b\"}}.\nIn field \"tag\": OneOf input object \"TagFilter\" must specify exactly one field.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 16}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+		{
+			Schema: schema,
+			Query:  `query($name: String) { find(filter: {name: $name}) }`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Argument \"filter\" has invalid value {name: $name}.\nIn field \"name\": Variable \"$name\" must be non-nullable to be used for oneOf input object \"Filter\".",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 37}},
+				Rule:      "ArgumentsOfCorrectType",
+			}},
+		},
+		{
+			Schema:         schema,
+			Query:          `query($name: String!) { find(filter: {name: $name}) }`,
+			Variables:      map[string]interface{}{"name": "a"},
+			ExpectedResult: `{"find": "name a"}`,
+		},
+		{
+			Schema:         schema,
+			Query:          `query($filter: Filter!) { find(filter: $filter) }`,
+			Variables:      map[string]interface{}{"filter": map[string]interface{}{"tag": map[string]interface{}{"name": "a"}}},
+			ExpectedResult: `{"find": "tag a"}`,
+		},
+		{
+			Schema:    schema,
+			Query:     `query($filter: Filter!) { find(filter: $filter) }`,
+			Variables: map[string]interface{}{"filter": map[string]interface{}{}},
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Variable \"filter\" has invalid value map[].\nOneOf input object \"Filter\" must specify exactly one field.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 7}},
+				Rule:      "VariablesOfCorrectType",
+			}},
+		},
+		{
+			Schema:    schema,
+			Query:     `query($filter: Filter!) { find(filter: $filter) }`,
+			Variables: map[string]interface{}{"filter": map[string]interface{}{"id": "1", "name": "a"}},
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Variable \"filter\" has invalid value map[id:1 name:a].\nOneOf input object \"Filter\" must specify exactly one field.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 7}},
+				Rule:      "VariablesOfCorrectType",
+			}},
+		},
+		{
+			Schema:    schema,
+			Query:     `query($filter: Filter!) { find(filter: $filter) }`,
+			Variables: map[string]interface{}{"filter": map[string]interface{}{"tag": map[string]interface{}{"name": nil}}},
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "Variable \"filter\" has invalid value map[name:<nil>] at \"filter.tag\".\nIn field \"name\": OneOf input object \"TagFilter\" requires a non-null value.",
+				Locations: []gqlerrors.Location{{Line: 1, Column: 7}},
+				Rule:      "VariablesOfCorrectType",
+			}},
+		},
+		{
+			Schema: schema,
+			Query: `{
+				filter: __type(name: "Filter") { isOneOf }
+				query: __type(name: "Query") { isOneOf }
+			}`,
+			ExpectedResult: `{"filter": {"isOneOf": true}, "query": {"isOneOf": null}}`,
+		},
+	})
+}
+
+func TestOneOfSchemaErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "non-null field",
+			input:   `input Filter @oneOf { id: ID! name: String }`,
+			wantErr: `graphql: field "id" of oneOf input object "Filter" must be nullable`,
+		},
+		{
+			name:    "default value",
+			input:   `input Filter @oneOf { id: ID name: String = "a" }`,
+			wantErr: `graphql: field "name" of oneOf input object "Filter" must not have a default value`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := graphql.ParseSchema(`
+				schema {
+					query: Query
+				}
+
+				type Query {
+					find(filter: Filter!): String!
+				}
+			`+tc.input, &oneOfResolver{})
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("got error %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestOneOfWithoutValidation(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			find(filter: Filter!): String!
+		}
+
+		input Filter @oneOf {
+			id: ID
+			name: String
+		}
+	`, &oneOfResolver{})
+
+	// The executor rejects invalid values of documents that are not validated, too.
+	doc, err := graphql.ParseQuery(`{ find(filter: {id: "1", name: "a"}) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := schema.ExecTrustedDocument(context.Background(), doc, "", nil)
+	if len(resp.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", resp.Errors)
+	}
+	if got, want := resp.Errors[0].Message, "graphql: exactly one field of a oneOf input object must be given with a non-null value"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+}
+
+type timeoutResolver struct {
+	// release unblocks the slow resolvers once the test is done.
+	release chan struct{}
+}
+
+func (r *timeoutResolver) Slow() *string {
+	<-r.release
+	s := "slow"
+	return &s
+}
+
+func (r *timeoutResolver) SlowNonNull() string {
+	<-r.release
+	return "slow"
+}
+
+func (r *timeoutResolver) Stuck() *string {
+	<-r.release
+	return nil
+}
+
+func (r *timeoutResolver) Fast(ctx context.Context) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return "", errors.New("expected the context to have a deadline")
+	}
+	return "fast", nil
+}
+
+func TestFieldTimeout(t *testing.T) {
+	r := &timeoutResolver{release: make(chan struct{})}
+	defer close(r.release)
+	schema := graphql.MustParseSchema(`
+		directive @timeout(ms: Int!) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			slow: String @timeout(ms: 10)
+			slowNonNull: String! @timeout(ms: 10)
+			fast: String! @timeout(ms: 10000)
+			stuck: String @timeout(ms: 60000)
+		}
+	`, r)
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         schema,
+			Query:          `{ fast slow }`,
+			ExpectedResult: `{"fast": "fast", "slow": null}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `field "slow" timed out after 10ms`,
+				Path:    []interface{}{"slow"},
+			}},
+		},
+		{
+			Schema:         schema,
+			Query:          `{ fast slowNonNull }`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `field "slowNonNull" timed out after 10ms`,
+				Path:    []interface{}{"slowNonNull"},
+			}},
+		},
+	})
+
+	t.Run("clamped to the request deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		resp := schema.Exec(ctx, `{ stuck }`, "", nil)
+		if d := time.Since(start); d > 5*time.Second {
+			t.Errorf("the request took %s", d)
+		}
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != "context deadline exceeded" {
+			t.Errorf("unexpected errors %v", resp.Errors)
+		}
+	})
+}
+
+func TestFieldTimeoutSchemaError(t *testing.T) {
+	_, err := graphql.ParseSchema(`
+		directive @timeout(ms: Int!) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			slow: String @timeout(ms: 0)
+		}
+	`, &timeoutResolver{})
+	if err == nil || !strings.Contains(err.Error(), "@timeout requires a positive number of milliseconds, got 0") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// stuckResolver has a field whose resolver ignores its context until it is released, and records
+// how many resolvers run at the same time.
+type stuckResolver struct {
+	release chan struct{}
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (r *stuckResolver) Stuck(ctx context.Context) *string {
+	r.mu.Lock()
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.mu.Unlock()
+	<-r.release
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *stuckResolver) peak() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.max
+}
+
+func TestFieldTimeoutParallelism(t *testing.T) {
+	r := &stuckResolver{release: make(chan struct{})}
+	schema := graphql.MustParseSchema(`
+		directive @timeout(ms: Int!) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			stuck: String @timeout(ms: 10)
+		}
+	`, r, graphql.MaxParallelism(1), graphql.MaxGlobalParallelism(1))
+
+	var query strings.Builder
+	query.WriteString("{")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&query, " f%d: stuck", i)
+	}
+	query.WriteString(" }")
+
+	done := make(chan *graphql.Response)
+	go func() {
+		done <- schema.Exec(context.Background(), query.String(), "", nil)
+	}()
+
+	// A resolver that timed out keeps its slot until it returns, so the others wait for it.
+	time.Sleep(100 * time.Millisecond)
+	if peak := r.peak(); peak != 1 {
+		t.Errorf("expected 1 resolver to run at a time while the first one is stuck, got %d", peak)
+	}
+	close(r.release)
+	resp := <-done
+	if len(resp.Errors) == 0 {
+		t.Errorf("expected a timeout error")
+	}
+	if peak := r.peak(); peak != 1 {
+		t.Errorf("expected at most 1 resolver to run at a time, got %d", peak)
+	}
+}
+
+func TestFieldTimeoutOtherDirective(t *testing.T) {
+	// A @timeout directive that is not declared with ms: Int belongs to the application.
+	r := &timeoutResolver{release: make(chan struct{})}
+	close(r.release)
+	schema, err := graphql.ParseSchema(`
+		directive @timeout(seconds: Int) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			slow: String @timeout(seconds: 0)
+		}
+	`, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gqltesting.RunTest(t, &gqltesting.Test{
+		Schema:         schema,
+		Query:          `{ slow }`,
+		ExpectedResult: `{"slow": "slow"}`,
+	})
+}
+
+type executionTimeoutResolver struct {
+	// release unblocks the slow resolvers once the test is done.
+	release chan struct{}
+	// cancelled receives the error of the context of a slow resolver.
+	cancelled chan error
+}
+
+func (r *executionTimeoutResolver) Fast() string {
+	return "fast"
+}
+
+func (r *executionTimeoutResolver) Slow(ctx context.Context) *string {
+	select {
+	case <-ctx.Done():
+		r.cancelled <- ctx.Err()
+	case <-r.release:
+	}
+	<-r.release
+	s := "slow"
+	return &s
+}
+
+func (r *executionTimeoutResolver) Nested() *executionTimeoutResolver {
+	return r
+}
+
+func TestExecutionTimeout(t *testing.T) {
+	r := &executionTimeoutResolver{release: make(chan struct{}), cancelled: make(chan error, 10)}
+	defer close(r.release)
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			fast: String!
+			slow: String
+			nested: Query!
+		}
+	`, r, graphql.WithExecutionTimeout(20*time.Millisecond))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         schema,
+			Query:          `{ fast slow nested { fast slow } }`,
+			ExpectedResult: `{"fast": "fast", "slow": null, "nested": {"fast": "fast", "slow": null}}`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message: `field "slow" was not resolved within the execution timeout of 20ms`,
+					Path:    []interface{}{"slow"},
+				},
+				{
+					Message: `field "slow" was not resolved within the execution timeout of 20ms`,
+					Path:    []interface{}{"nested", "slow"},
+				},
+			},
+		},
+		{
+			Schema:         schema,
+			Query:          `{ fast nested { fast } }`,
+			ExpectedResult: `{"fast": "fast", "nested": {"fast": "fast"}}`,
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-r.cancelled:
+			if err != context.DeadlineExceeded {
+				t.Errorf("unexpected context error %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the context of the slow resolver was not cancelled")
+		}
+	}
+}
+
+func TestExecutionTimeoutParallelism(t *testing.T) {
+	r := &stuckResolver{release: make(chan struct{})}
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			stuck: String
+		}
+	`, r, graphql.MaxParallelism(1), graphql.MaxGlobalParallelism(1), graphql.WithExecutionTimeout(10*time.Millisecond))
+
+	done := make(chan *graphql.Response)
+	go func() {
+		done <- schema.Exec(context.Background(), `{ a: stuck b: stuck c: stuck d: stuck }`, "", nil)
+	}()
+
+	// The resolver that is pending when the timeout expires keeps its slot until it returns.
+	time.Sleep(100 * time.Millisecond)
+	if peak := r.peak(); peak != 1 {
+		t.Errorf("expected 1 resolver to run at a time while the first one is stuck, got %d", peak)
+	}
+	close(r.release)
+	resp := <-done
+	if len(resp.Errors) == 0 {
+		t.Errorf("expected an execution timeout error")
+	}
+	if peak := r.peak(); peak != 1 {
+		t.Errorf("expected at most 1 resolver to run at a time, got %d", peak)
+	}
+}
+
+type blobResolver struct {
+	closed bool
+}
+
+func (r *blobResolver) Echo(args struct{ Data graphql.Blob }) graphql.Blob {
+	return args.Data
+}
+
+func (r *blobResolver) Length(args struct{ Data graphql.BlobReader }) (int32, error) {
+	data, err := ioutil.ReadAll(args.Data)
+	return int32(len(data)), err
+}
+
+func (r *blobResolver) Stream() graphql.BlobReader {
+	return graphql.BlobReader{Reader: &closeTracker{Reader: strings.NewReader("hello, world"), closed: &r.closed}}
+}
+
+func (r *blobResolver) Empty() *graphql.BlobReader {
+	return &graphql.BlobReader{}
+}
+
+func (r *blobResolver) Broken() *graphql.BlobReader {
+	return &graphql.BlobReader{Reader: io.MultiReader(strings.NewReader("partial"), &failingReader{})}
+}
+
+type closeTracker struct {
+	io.Reader
+	closed *bool
+}
+
+func (c *closeTracker) Close() error {
+	*c.closed = true
+	return nil
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("disk failure")
+}
+
+func TestBlob(t *testing.T) {
+	r := &blobResolver{}
+	schema := graphql.MustParseSchema(`
+		scalar Blob
+		scalar Base64
+
+		schema {
+			query: Query
+		}
+
+		type Query {
+			echo(data: Blob!): Blob!
+			length(data: Base64!): Int!
+			stream: Blob!
+			empty: Blob
+			broken: Base64
+		}
+	`, r)
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				query($data: Blob!) {
+					literal: echo(data: "AAEC/w==")
+					variable: echo(data: $data)
+					length(data: "aGVsbG8=")
+					stream
+					empty
+				}
+			`,
+			Variables: map[string]interface{}{"data": "aGVsbG8="},
+			ExpectedResult: `
+				{
+					"literal": "AAEC/w==",
+					"variable": "aGVsbG8=",
+					"length": 5,
+					"stream": "aGVsbG8sIHdvcmxk",
+					"empty": ""
+				}
+			`,
+		},
+		{
+			Schema:         schema,
+			Query:          `{ broken }`,
+			ExpectedResult: `{"broken": null}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `could not marshal Base64: disk failure`,
+				Path:    []interface{}{"broken"},
+			}},
+		},
+	})
+	if !r.closed {
+		t.Error("the reader of the blob was not closed")
+	}
+
+	for _, input := range []string{`"not base64!"`, `"aGVsbG8"`} {
+		resp := schema.Exec(context.Background(), `{ echo(data: `+input+`) }`, "", nil)
+		if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "invalid base64 for Blob") {
+			t.Errorf("unexpected errors for %s: %v", input, resp.Errors)
+		}
+	}
+	resp := schema.Exec(context.Background(), `query($data: Base64!) { length(data: $data) }`, "", map[string]interface{}{"data": "%%%"})
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "invalid base64 for Blob") {
+		t.Errorf("unexpected errors for a variable: %v", resp.Errors)
+	}
+}
+
+type notFoundError struct {
+	id string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.id)
+}
+
+type errorMapperResolver struct{}
+
+func (r *errorMapperResolver) Item(args struct{ ID string }) (*string, error) {
+	if args.ID == "broken" {
+		return nil, errors.New("database unavailable")
+	}
+	return nil, &notFoundError{id: args.ID}
+}
+
+func TestResolverErrorMapper(t *testing.T) {
+	mapper := func(err error) *gqlerrors.QueryError {
+		if nf, ok := err.(*notFoundError); ok {
+			return &gqlerrors.QueryError{
+				Message:    nf.Error(),
+				Extensions: map[string]interface{}{"code": "NOT_FOUND", "status": 404},
+			}
+		}
+		return nil
+	}
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			item(id: String!): String
+		}
+	`, &errorMapperResolver{}, graphql.ResolverErrorMapper(mapper), graphql.IncludeErrorLocations())
+
+	resp := schema.Exec(context.Background(), `{
+		a: item(id: "a")
+		b: item(id: "broken")
+	}`, "", nil)
+	if got, want := string(resp.Data), `{"a":null,"b":null}`; got != want {
+		t.Errorf("got data %s, want %s", got, want)
+	}
+	if len(resp.Errors) != 2 {
+		t.Fatalf("expected two errors, got %v", resp.Errors)
+	}
+	sort.Slice(resp.Errors, func(i, j int) bool { return resp.Errors[i].Path[0].(string) < resp.Errors[j].Path[0].(string) })
+
+	mapped := resp.Errors[0]
+	if mapped.Message != "a not found" || mapped.Extensions["code"] != "NOT_FOUND" || mapped.Extensions["status"] != 404 {
+		t.Errorf("unexpected mapped error %#v", mapped)
+	}
+	if !reflect.DeepEqual(mapped.Path, []interface{}{"a"}) || !reflect.DeepEqual(mapped.Locations, []gqlerrors.Location{{Line: 2, Column: 3}}) {
+		t.Errorf("got path %v and locations %v", mapped.Path, mapped.Locations)
+	}
+	if _, ok := mapped.ResolverError.(*notFoundError); !ok {
+		t.Errorf("expected the resolver error to be kept, got %v", mapped.ResolverError)
+	}
+
+	unmapped := resp.Errors[1]
+	if unmapped.Message != "database unavailable" || unmapped.Extensions != nil {
+		t.Errorf("unexpected unmapped error %#v", unmapped)
+	}
+	if !reflect.DeepEqual(unmapped.Path, []interface{}{"b"}) {
+		t.Errorf("got path %v", unmapped.Path)
+	}
+}
+
+func TestErrorPhases(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			item(id: String!): String
+		}
+	`, &errorMapperResolver{})
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		phase string
+	}{
+		{name: "syntax error", query: `{ item(id: "a") `, phase: gqlerrors.PhaseParse},
+		{name: "unknown field", query: `{ unknown }`, phase: gqlerrors.PhaseValidate},
+		{name: "unknown operation", query: `query A { item(id: "a") } query B { item(id: "b") }`, phase: gqlerrors.PhaseValidate},
+		{name: "resolver error", query: `{ item(id: "a") }`, phase: gqlerrors.PhaseExecute},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := schema.Exec(context.Background(), tc.query, "", nil)
+			if len(resp.Errors) == 0 {
+				t.Fatal("expected errors")
+			}
+			for _, err := range resp.Errors {
+				if err.Phase != tc.phase {
+					t.Errorf("got phase %q for error %q, want %q", err.Phase, err.Message, tc.phase)
+				}
+			}
+		})
+	}
+}
+
+type defaultsSort struct {
+	Field     string
+	Direction string
+}
+
+type defaultsPage struct {
+	Size int32
+	Sort defaultsSort
+}
+
+type defaultsFilter struct {
+	Name  *string
+	Page  defaultsPage
+	Pages *[]defaultsPage
+}
+
+// rawPage unmarshals a Page input object itself.
+type rawPage struct {
+	input map[string]interface{}
+}
+
+func (*rawPage) ImplementsGraphQLType(name string) bool {
+	return name == "Page"
+}
+
+func (p *rawPage) UnmarshalGraphQL(input interface{}) error {
+	m, ok := input.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected an object, got %T", input)
+	}
+	p.input = m
+	return nil
+}
+
+type inputDefaultsResolver struct{}
+
+func (r *inputDefaultsResolver) Search(args struct{ Filter defaultsFilter }) string {
+	f := args.Filter
+	s := fmt.Sprintf("page %+v", f.Page)
+	if f.Pages != nil {
+		for _, p := range *f.Pages {
+			s += fmt.Sprintf(", %+v", p)
+		}
+	}
+	return s
+}
+
+func (r *inputDefaultsResolver) Raw(args struct{ Page rawPage }) string {
+	return fmt.Sprintf("%v", args.Page.input)
+}
+
+func TestNestedInputDefaults(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			search(filter: Filter!): String!
+			raw(page: Page!): String!
+		}
+
+		enum Direction {
+			ASC
+			DESC
+		}
+
+		input Filter {
+			name: String
+			page: Page = {size: 5}
+			pages: [Page!]
+		}
+
+		input Page {
+			size: Int = 10
+			sort: Sort = {}
+		}
+
+		input Sort {
+			field: String = "id"
+			direction: Direction = DESC
+		}
+	`, &inputDefaultsResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         schema,
+			Query:          `{ search(filter: {}) }`,
+			ExpectedResult: `{"search": "page {Size:5 Sort:{Field:id Direction:DESC}}"}`,
+		},
+		{
+			Schema:         schema,
+			Query:          `{ search(filter: {page: {sort: {direction: ASC}}, pages: [{size: 1}, {sort: {field: "name"}}]}) }`,
+			ExpectedResult: `{"search": "page {Size:10 Sort:{Field:id Direction:ASC}}, {Size:1 Sort:{Field:id Direction:DESC}}, {Size:10 Sort:{Field:name Direction:DESC}}"}`,
+		},
+		{
+			Schema: schema,
+			Query:  `query($filter: Filter!) { search(filter: $filter) }`,
+			Variables: map[string]interface{}{"filter": map[string]interface{}{
+				"page":  map[string]interface{}{"size": 2},
+				"pages": []interface{}{map[string]interface{}{"sort": map[string]interface{}{"direction": "ASC"}}},
+			}},
+			ExpectedResult: `{"search": "page {Size:2 Sort:{Field:id Direction:DESC}}, {Size:10 Sort:{Field:id Direction:ASC}}"}`,
+		},
+		{
+			Schema:         schema,
+			Query:          `{ raw(page: {size: 3}) }`,
+			ExpectedResult: `{"raw": "map[size:3 sort:map[direction:DESC field:id]]"}`,
+		},
+	})
+}
+
+const authorizationSchema = `
+	directive @requiresRole(role: String!) on FIELD_DEFINITION
+
+	schema {
+		query: Query
+	}
+
+	type Query {
+		me: User!
+		characters: [Character!]!
+		salary: Int @requiresRole(role: "admin")
+	}
+
+	interface Character {
+		name: String!
+		secret: String @requiresRole(role: "admin")
+	}
+
+	type User implements Character {
+		name: String!
+		secret: String
+		email: String! @requiresRole(role: "self")
+	}
+
+	type Robot implements Character {
+		name: String!
+		secret: String
+	}
+`
+
+type authQueryResolver struct{}
+
+func (r *authQueryResolver) Me() *authUserResolver {
+	return &authUserResolver{}
+}
+
+func (r *authQueryResolver) Characters() []*authCharacterResolver {
+	return []*authCharacterResolver{{&authUserResolver{}}, {&authRobotResolver{}}}
+}
+
+func (r *authQueryResolver) Salary() *int32 {
+	salary := int32(100)
+	return &salary
+}
+
+type authCharacter interface {
+	Name() string
+	Secret() *string
+}
+
+type authCharacterResolver struct {
+	authCharacter
+}
+
+func (r *authCharacterResolver) ToUser() (*authUserResolver, bool) {
+	u, ok := r.authCharacter.(*authUserResolver)
+	return u, ok
+}
+
+func (r *authCharacterResolver) ToRobot() (*authRobotResolver, bool) {
+	robot, ok := r.authCharacter.(*authRobotResolver)
+	return robot, ok
+}
+
+type authUserResolver struct{}
+
+func (r *authUserResolver) Name() string {
+	return "Alice"
+}
+
+func (r *authUserResolver) Secret() *string {
+	secret := "user secret"
+	return &secret
+}
+
+func (r *authUserResolver) Email() string {
+	return "alice@example.com"
+}
+
+type authRobotResolver struct{}
+
+func (r *authRobotResolver) Name() string {
+	return "R2"
+}
+
+func (r *authRobotResolver) Secret() *string {
+	secret := "robot secret"
+	return &secret
+}
+
+func TestFieldAuthorization(t *testing.T) {
+	drop := graphql.MustParseSchema(authorizationSchema, &authQueryResolver{}, graphql.FieldAuthorization("requiresRole", graphql.DropUnauthorized))
+	null := graphql.MustParseSchema(authorizationSchema, &authQueryResolver{}, graphql.FieldAuthorization("requiresRole", graphql.NullUnauthorized))
+	forbidden := map[string]interface{}{"code": "FORBIDDEN"}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: graphql.WithRoles(context.Background(), "admin", "self"),
+			Schema:  drop,
+			Query: `
+				{
+					me {
+						email
+					}
+					salary
+					characters {
+						name
+						secret
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"me": {"email": "alice@example.com"},
+					"salary": 100,
+					"characters": [
+						{"name": "Alice", "secret": "user secret"},
+						{"name": "R2", "secret": "robot secret"}
+					]
+				}
+			`,
+		},
+		{
+			Context: graphql.WithRoles(context.Background(), "self"),
+			Schema:  drop,
+			Query: `
+				{
+					me {
+						name
+						email
+					}
+					salary
+				}
+			`,
+			ExpectedResult: `
+				{
+					"me": {"name": "Alice", "email": "alice@example.com"}
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:    `not authorized to query field "salary" on type "Query": role "admin" is required`,
+				Locations:  []gqlerrors.Location{{Line: 7, Column: 6}},
+				Extensions: forbidden,
+			}},
+		},
+		{
+			Schema: drop,
+			Query: `
+				{
+					characters {
+						name
+						... on User {
+							secret
+						}
+						... on Robot {
+							secret
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"characters": [
+						{"name": "Alice"},
+						{"name": "R2"}
+					]
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:    `not authorized to query field "secret" on type "User": role "admin" is required`,
+					Locations:  []gqlerrors.Location{{Line: 6, Column: 8}},
+					Extensions: forbidden,
+				},
+				{
+					Message:    `not authorized to query field "secret" on type "Robot": role "admin" is required`,
+					Locations:  []gqlerrors.Location{{Line: 9, Column: 8}},
+					Extensions: forbidden,
+				},
+			},
+		},
+		{
+			Schema: null,
+			Query: `
+				{
+					characters {
+						name
+						secret
+					}
+					salary
+				}
+			`,
+			ExpectedResult: `
+				{
+					"characters": [
+						{"name": "Alice", "secret": null},
+						{"name": "R2", "secret": null}
+					],
+					"salary": null
+				}
+			`,
+			ExpectedErrors: []*gqlerrors.QueryError{
+				{
+					Message:    `not authorized to query field "secret" on type "Character": role "admin" is required`,
+					Path:       []interface{}{"characters", 0, "secret"},
+					Extensions: forbidden,
+				},
+				{
+					Message:    `not authorized to query field "secret" on type "Character": role "admin" is required`,
+					Path:       []interface{}{"characters", 1, "secret"},
+					Extensions: forbidden,
+				},
+				{
+					Message:    `not authorized to query field "salary" on type "Query": role "admin" is required`,
+					Path:       []interface{}{"salary"},
+					Extensions: forbidden,
+				},
+			},
+		},
+		{
+			Context: graphql.WithRoles(context.Background(), "admin"),
+			Schema:  null,
+			Query: `
+				{
+					me {
+						name
+						email
+					}
+				}
+			`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:    `not authorized to query field "email" on type "User": role "self" is required`,
+				Path:       []interface{}{"me", "email"},
+				Extensions: forbidden,
+			}},
+		},
+	})
+
+	t.Run("prepared query", func(t *testing.T) {
+		q, errs := drop.Prepare(`{ me { name } salary }`, "")
+		if errs != nil {
+			t.Fatal(errs)
+		}
+		resp := q.Exec(graphql.WithRoles(context.Background(), "admin"), nil)
+		if len(resp.Errors) != 0 || string(resp.Data) != `{"me":{"name":"Alice"},"salary":100}` {
+			t.Errorf("unexpected response with role: %s %v", resp.Data, resp.Errors)
+		}
+		resp = q.Exec(context.Background(), nil)
+		if len(resp.Errors) != 1 || string(resp.Data) != `{"me":{"name":"Alice"}}` {
+			t.Errorf("unexpected response without role: %s %v", resp.Data, resp.Errors)
+		}
+	})
+
+	t.Run("undeclared directive", func(t *testing.T) {
+		_, err := graphql.ParseSchema(authorizationSchema, &authQueryResolver{}, graphql.FieldAuthorization("auth", graphql.DropUnauthorized))
+		if err == nil || err.Error() != "graphql: directive @auth of FieldAuthorization is not declared in the schema" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestMaxTokens(t *testing.T) {
+	schemaString := `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hello: String!
+		}
+	`
+	// The query has 8 tokens, the commas and the comment are not counted.
+	query := `
+		# greetings
+		{ a: hello, b: hello }
+	`
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema:         graphql.MustParseSchema(schemaString, &helloWorldResolver1{}, graphql.MaxTokens(8)),
+			Query:          query,
+			ExpectedResult: `{"a": "Hello world!", "b": "Hello world!"}`,
+		},
+		{
+			Schema: graphql.MustParseSchema(schemaString, &helloWorldResolver1{}, graphql.MaxTokens(7)),
+			Query:  query,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:   "syntax error: document contains more than 7 tokens, parsing aborted",
+				Locations: []gqlerrors.Location{{Line: 3, Column: 24}},
+			}},
+		},
+	})
+
+	schema := graphql.MustParseSchema(schemaString, &helloWorldResolver1{}, graphql.MaxTokens(7))
+	if errs := schema.Validate(query); len(errs) != 1 || errs[0].Message != "syntax error: document contains more than 7 tokens, parsing aborted" {
+		t.Errorf("unexpected validation errors: %v", errs)
+	}
+}
+
+type nullPropagationResolver struct{}
+
+func (r *nullPropagationResolver) Items() []*nullPropagationItem {
+	return []*nullPropagationItem{{id: "1"}, {id: "2", fail: true}, {id: "3"}}
+}
+
+func (r *nullPropagationResolver) StrictItems() *[]*nullPropagationItem {
+	items := r.Items()
+	return &items
+}
+
+func (r *nullPropagationResolver) Owner() *nullPropagationOwner {
+	return &nullPropagationOwner{}
+}
+
+func (r *nullPropagationResolver) Holes() *[]*nullPropagationItem {
+	return &[]*nullPropagationItem{{id: "1"}, nil}
+}
+
+func (r *nullPropagationResolver) Group() *nullPropagationGroup {
+	return &nullPropagationGroup{}
+}
+
+func (r *nullPropagationResolver) Ok() string {
+	return "ok"
+}
+
+type nullPropagationGroup struct{}
+
+func (g *nullPropagationGroup) Name() string {
+	return "group"
+}
+
+func (g *nullPropagationGroup) Items() []*nullPropagationItem {
+	return []*nullPropagationItem{{id: "1"}, {id: "2", fail: true}}
+}
+
+func (g *nullPropagationGroup) Owner() *nullPropagationOwner {
+	return &nullPropagationOwner{}
+}
+
+type nullPropagationOwner struct{}
+
+func (o *nullPropagationOwner) Profile() *nullPropagationItem {
+	return &nullPropagationItem{id: "4", fail: true}
+}
+
+type nullPropagationItem struct {
+	id   string
+	fail bool
+}
+
+func (i *nullPropagationItem) ID() graphql.ID {
+	return graphql.ID(i.id)
+}
+
+func (i *nullPropagationItem) Name() (string, error) {
+	if i.fail {
+		return "", fmt.Errorf("no name for %s", i.id)
+	}
+	return "item " + i.id, nil
+}
+
+func TestNonNullPropagation(t *testing.T) {
+	schemaString := `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items: [Item]!
+			strictItems: [Item!]
+			group: Group
+			owner: Owner!
+			holes: [Item!]
+			ok: String!
+		}
+
+		type Group {
+			name: String!
+			items: [Item!]!
+			owner: Owner!
+		}
+
+		type Owner {
+			profile: Item!
+		}
+
+		type Item {
+			id: ID!
+			name: String!
+		}
+	`
+	schema := graphql.MustParseSchema(schemaString, &nullPropagationResolver{})
+	customSchema := graphql.MustParseSchema(schemaString, &nullPropagationResolver{}, graphql.NonNullError(func(path []interface{}, typeName, fieldName string) *gqlerrors.QueryError {
+		return &gqlerrors.QueryError{Message: fmt.Sprintf("%s.%s has a null element", typeName, fieldName)}
+	}))
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			// The failing element of a list of nullable items is null.
+			Schema: schema,
+			Query:  `{ items { id name } ok }`,
+			ExpectedResult: `{
+				"items": [{"id": "1", "name": "item 1"}, null, {"id": "3", "name": "item 3"}],
+				"ok": "ok"
+			}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "no name for 2",
+				Path:          []interface{}{"items", 1, "name"},
+				ResolverError: fmt.Errorf("no name for 2"),
+			}},
+		},
+		{
+			// A list of non-null items is null if one of them fails.
+			Schema:         schema,
+			Query:          `{ strictItems { id name } ok }`,
+			ExpectedResult: `{"strictItems": null, "ok": "ok"}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "no name for 2",
+				Path:          []interface{}{"strictItems", 1, "name"},
+				ResolverError: fmt.Errorf("no name for 2"),
+			}},
+		},
+		{
+			// The null of a non-null list propagates to the nearest nullable ancestor.
+			Schema:         schema,
+			Query:          `{ group { name items { name } } ok }`,
+			ExpectedResult: `{"group": null, "ok": "ok"}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "no name for 2",
+				Path:          []interface{}{"group", "items", 1, "name"},
+				ResolverError: fmt.Errorf("no name for 2"),
+			}},
+		},
+		{
+			// The null of a field of a nested object propagates through the non-null objects.
+			Schema:         schema,
+			Query:          `{ ok group { name owner { profile { id name } } } }`,
+			ExpectedResult: `{"ok": "ok", "group": null}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "no name for 4",
+				Path:          []interface{}{"group", "owner", "profile", "name"},
+				ResolverError: fmt.Errorf("no name for 4"),
+			}},
+		},
+		{
+			// A null element of a list of non-null items nulls the list.
+			Schema:         schema,
+			Query:          `{ holes { id } ok }`,
+			ExpectedResult: `{"holes": null, "ok": "ok"}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: `graphql: got nil for non-null "Item"`,
+				Path:    []interface{}{"holes", 1},
+			}},
+		},
+		{
+			// A custom non-null error is reported once at the path of the null value.
+			Schema:         customSchema,
+			Query:          `{ holes { id } ok }`,
+			ExpectedResult: `{"holes": null, "ok": "ok"}`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message: "Query.holes has a null element",
+				Path:    []interface{}{"holes", 1},
+			}},
+		},
+		{
+			// Without a nullable ancestor, the data is null.
+			Schema:         schema,
+			Query:          `{ ok owner { profile { name } } }`,
+			ExpectedResult: `null`,
+			ExpectedErrors: []*gqlerrors.QueryError{{
+				Message:       "no name for 4",
+				Path:          []interface{}{"owner", "profile", "name"},
+				ResolverError: fmt.Errorf("no name for 4"),
+			}},
+		},
+	})
+}
+
+type nullIDResolver struct{}
+
+type nullIDInput struct {
+	ID graphql.NullID
+}
+
+func describeNullID(id graphql.NullID) string {
+	switch {
+	case !id.Set:
+		return "absent"
+	case id.Value == nil:
+		return "null"
+	default:
+		return "set to " + string(*id.Value)
+	}
+}
+
+func (*nullIDResolver) Update(args struct{ ID graphql.NullID }) string {
+	return describeNullID(args.ID)
+}
+
+func (*nullIDResolver) UpdateInput(args struct{ Input nullIDInput }) string {
+	return describeNullID(args.Input.ID)
+}
+
+func (*nullIDResolver) Echo(args struct{ ID graphql.NullID }) graphql.NullID {
+	return args.ID
+}
+
+func TestNullID(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			update(id: ID): String!
+			updateInput(input: UpdateInput!): String!
+			echo(id: ID): ID
+		}
+
+		input UpdateInput {
+			id: ID
+		}
+	`, &nullIDResolver{})
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: schema,
+			Query: `
+				{
+					absent: update
+					null: update(id: null)
+					set: update(id: "1")
+					int: update(id: 2)
+					inputAbsent: updateInput(input: {})
+					inputNull: updateInput(input: {id: null})
+					inputSet: updateInput(input: {id: "3"})
+				}
+			`,
+			ExpectedResult: `
+				{
+					"absent": "absent",
+					"null": "null",
+					"set": "set to 1",
+					"int": "set to 2",
+					"inputAbsent": "absent",
+					"inputNull": "null",
+					"inputSet": "set to 3"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($absent: ID, $null: ID, $set: ID) {
+					absent: update(id: $absent)
+					null: update(id: $null)
+					set: update(id: $set)
+					inputAbsent: updateInput(input: {id: $absent})
+					inputNull: updateInput(input: {id: $null})
+					inputSet: updateInput(input: {id: $set})
+				}
+			`,
+			Variables: map[string]interface{}{"null": nil, "set": "4"},
+			ExpectedResult: `
+				{
+					"absent": "absent",
+					"null": "null",
+					"set": "set to 4",
+					"inputAbsent": "absent",
+					"inputNull": "null",
+					"inputSet": "set to 4"
+				}
+			`,
+		},
+		{
+			Schema: schema,
+			Query: `
+				query($default: ID = "5") {
+					default: update(id: $default)
+					absent: echo
+					null: echo(id: null)
+					set: echo(id: "6")
+				}
+			`,
+			ExpectedResult: `
+				{
+					"default": "set to 5",
+					"absent": null,
+					"null": null,
+					"set": "6"
+				}
+			`,
+		},
+	})
+
+	resp := schema.Exec(context.Background(), `{ update(id: 1.5) }`, "", nil)
+	if len(resp.Errors) == 0 {
+		t.Error("expected an error for a float ID")
+	}
+}
+
+// friendBase resolves the fields of the Friend interface that are the same for all implementers.
+type friendBase struct {
+	name string
+}
+
+func (f *friendBase) Name() string {
+	return f.name
+}
+
+func (f *friendBase) Greeting() string {
+	return "Hello, " + f.name
+}
+
+type sharedHuman struct {
+	*friendBase
+}
+
+type sharedDroid struct {
+	friendBase
+}
+
+// Greeting overrides the resolver of friendBase.
+func (d *sharedDroid) Greeting() string {
+	return "Beep, " + d.name
+}
+
+func (d *sharedDroid) Model() string {
+	return "R2"
+}
+
+type sharedFriend struct {
+	value interface{}
+}
+
+func (f *sharedFriend) Name() string {
+	return f.value.(interface{ Name() string }).Name()
+}
+
+func (f *sharedFriend) Greeting() string {
+	return f.value.(interface{ Greeting() string }).Greeting()
+}
+
+func (f *sharedFriend) ToHuman() (*sharedHuman, bool) {
+	h, ok := f.value.(*sharedHuman)
+	return h, ok
+}
+
+func (f *sharedFriend) ToDroid() (*sharedDroid, bool) {
+	d, ok := f.value.(*sharedDroid)
+	return d, ok
+}
+
+type sharedResolver struct{}
+
+func (*sharedResolver) Friends() []*sharedFriend {
+	return []*sharedFriend{
+		{value: &sharedHuman{&friendBase{name: "Luke"}}},
+		{value: &sharedDroid{friendBase{name: "R2-D2"}}},
+	}
+}
+
+const sharedFriendSchema = `
+	schema {
+		query: Query
+	}
+
+	type Query {
+		friends: [Friend!]!
+	}
+
+	interface Friend {
+		name: String!
+		greeting: String!
+	}
+
+	type Human implements Friend {
+		name: String!
+		greeting: String!
+	}
+
+	type Droid implements Friend {
+		name: String!
+		greeting: String!
+		model: String!
+	}
+`
+
+func TestSharedInterfaceResolvers(t *testing.T) {
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: graphql.MustParseSchema(sharedFriendSchema, &sharedResolver{}),
+			Query: `
+				{
+					friends {
+						__typename
+						name
+						greeting
+						... on Droid { model }
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"friends": [
+						{"__typename": "Human", "name": "Luke", "greeting": "Hello, Luke"},
+						{"__typename": "Droid", "name": "R2-D2", "greeting": "Beep, R2-D2", "model": "R2"}
+					]
+				}
+			`,
+		},
+	})
+}
+
+type otherFriendBase struct{}
+
+func (otherFriendBase) Name() string {
+	return "other"
+}
+
+// ambiguousHuman embeds two types that both resolve the name of a Friend.
+type ambiguousHuman struct {
+	*friendBase
+	otherFriendBase
+}
+
+type ambiguousFriend struct {
+	sharedFriend
+}
+
+func (f *ambiguousFriend) ToHuman() (*ambiguousHuman, bool) {
+	h, ok := f.value.(*ambiguousHuman)
+	return h, ok
+}
+
+type ambiguousFriendResolver struct{}
+
+func (*ambiguousFriendResolver) Friends() []*ambiguousFriend {
+	return nil
+}
+
+func TestSharedInterfaceResolvers_ambiguous(t *testing.T) {
+	_, err := graphql.ParseSchema(sharedFriendSchema, &ambiguousFriendResolver{})
+	if err == nil {
+		t.Fatal("expected an error for an ambiguous resolver")
+	}
+	want := `*graphql_test.ambiguousHuman does not resolve "Human": ambiguous method for field "name", it is promoted from both *graphql_test.friendBase and graphql_test.otherFriendBase`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %q, want it to contain %q", err, want)
+	}
+}
+
+type orderResolver struct{}
+
+func (*orderResolver) A() string { return "a" }
+func (*orderResolver) B() int32  { return 2 }
+func (*orderResolver) C() *orderResolver {
+	return &orderResolver{}
+}
+func (*orderResolver) D() map[string]interface{} {
+	return map[string]interface{}{"z": "z", "y": "y", "x": "x"}
+}
+
+func TestResponseFieldOrder(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			a: String!
+			b: Int!
+			c: Query!
+			d: Letters!
+		}
+
+		type Letters {
+			x: String!
+			y: String!
+			z: String!
+		}
+	`, &orderResolver{})
+
+	for _, tt := range []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "reverse order",
+			query: `{ d { z y x } c { b a } b a }`,
+			want:  `{"d":{"z":"z","y":"y","x":"x"},"c":{"b":2,"a":"a"},"b":2,"a":"a"}`,
+		},
+		{
+			name:  "aliases",
+			query: `{ second: b first: a again: b c { zz: a } }`,
+			want:  `{"second":2,"first":"a","again":2,"c":{"zz":"a"}}`,
+		},
+		{
+			name: "fragments",
+			query: `
+				{
+					b
+					...letters
+					... on Query { a b c { a } }
+					c { b __typename }
+					__typename
+				}
+
+				fragment letters on Query {
+					__typename
+					d { y }
+				}
+			`,
+			want: `{"b":2,"__typename":"Query","d":{"y":"y"},"a":"a","c":{"a":"a","b":2,"__typename":"Query"}}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := schema.Exec(context.Background(), tt.query, "", nil)
+			if len(resp.Errors) != 0 {
+				t.Fatal(resp.Errors)
+			}
+			if got := string(resp.Data); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func dataloaderResolver(store *dataloader.UserStore) *dataloader.Resolver {
+	var posts []*dataloader.Post
+	for i := 0; i < 25; i++ {
+		id := graphql.ID(strconv.Itoa(i))
+		posts = append(posts, dataloader.NewPost(id, "Post "+string(id), graphql.ID(strconv.Itoa(i%3)), store))
+	}
+	return dataloader.NewResolver(posts...)
+}
+
+func TestBatchScheduler(t *testing.T) {
+	newStore := func() *dataloader.UserStore {
+		return dataloader.NewUserStore(
+			dataloader.NewUser("0", "Alice"),
+			dataloader.NewUser("1", "Bob"),
+			dataloader.NewUser("2", "Carol"),
+		)
+	}
+	query := `{ posts { title author { name } } }`
+
+	store := newStore()
+	schema := graphql.MustParseSchema(dataloader.Schema, dataloaderResolver(store))
+	want := schema.Exec(context.Background(), query, "", nil)
+	if len(want.Errors) != 0 {
+		t.Fatal(want.Errors)
+	}
+	if calls := store.Calls(); calls != 25 {
+		t.Fatalf("got %d calls without a scheduler, want one per post", calls)
+	}
+
+	// The posts outnumber the parallelism of the request, which the batch must not wait for.
+	store = newStore()
+	schema = graphql.MustParseSchema(dataloader.Schema, dataloaderResolver(store), graphql.UseBatchScheduler(&dataloader.Loader{Store: store}), graphql.MaxParallelism(4))
+	got := schema.Exec(context.Background(), query, "", nil)
+	if len(got.Errors) != 0 {
+		t.Fatal(got.Errors)
+	}
+	if calls := store.Calls(); calls != 1 {
+		t.Errorf("got %d calls with a scheduler, want 1", calls)
+	}
+	if !bytes.Equal(got.Data, want.Data) {
+		t.Errorf("got data %s, want %s", got.Data, want.Data)
+	}
+}
+
+// recordingScheduler records the batches it receives, and resolves them like the executor would
+// without a scheduler, or not at all.
+type recordingScheduler struct {
+	mu      sync.Mutex
+	batches []string
+	skip    bool
+}
+
+func (s *recordingScheduler) Schedule(ctx context.Context, key graphql.BatchKey, parents []interface{}, resolve func(ctx context.Context)) {
+	s.mu.Lock()
+	s.batches = append(s.batches, fmt.Sprintf("%s %s.%s%s: %d", key.Path, key.ParentType, key.Field, key.Args, len(parents)))
+	s.mu.Unlock()
+	if !s.skip {
+		resolve(ctx)
+	}
+}
+
+type batchQuery struct{}
+
+func (*batchQuery) Items() []*batchItem {
+	return []*batchItem{{1}, {2}, nil, {3}}
+}
+
+type batchItem struct {
+	n int32
+}
+
+func (i *batchItem) N() int32 {
+	return i.n
+}
+
+func (i *batchItem) Times(ctx context.Context, args struct{ Factor int32 }) int32 {
+	return i.n * args.Factor
+}
+
+func (i *batchItem) Children(ctx context.Context) []*batchItem {
+	return []*batchItem{{i.n * 10}, {i.n*10 + 1}}
+}
+
+func TestBatchScheduler_keys(t *testing.T) {
+	const schemaString = `
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items: [Item]!
+		}
+
+		type Item {
+			n: Int!
+			times(factor: Int!): Int!
+			children: [Item!]!
+		}
+	`
+	query := `
+		{
+			items {
+				n
+				double: times(factor: 2)
+				triple: times(factor: 3)
+				children { times(factor: 2) }
+			}
+		}
+	`
+	want := `{"items":[` +
+		`{"n":1,"double":2,"triple":3,"children":[{"times":20},{"times":22}]},` +
+		`{"n":2,"double":4,"triple":6,"children":[{"times":40},{"times":42}]},` +
+		`null,` +
+		`{"n":3,"double":6,"triple":9,"children":[{"times":60},{"times":62}]}]}`
+
+	for _, skip := range []bool{false, true} {
+		scheduler := &recordingScheduler{skip: skip}
+		schema := graphql.MustParseSchema(schemaString, &batchQuery{}, graphql.UseBatchScheduler(scheduler))
+		resp := schema.Exec(context.Background(), query, "", nil)
+		if len(resp.Errors) != 0 {
+			t.Fatal(resp.Errors)
+		}
+		if got := string(resp.Data); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+		sort.Strings(scheduler.batches)
+		wantBatches := []string{
+			"items.children Item.children{}: 3",
+			"items.children.times Item.times{\"factor\":2}: 2",
+			"items.children.times Item.times{\"factor\":2}: 2",
+			"items.children.times Item.times{\"factor\":2}: 2",
+			"items.double Item.times{\"factor\":2}: 3",
+			"items.triple Item.times{\"factor\":3}: 3",
+		}
+		if !reflect.DeepEqual(scheduler.batches, wantBatches) {
+			t.Errorf("got batches:\n%s\nwant:\n%s", strings.Join(scheduler.batches, "\n"), strings.Join(wantBatches, "\n"))
+		}
+	}
+}
+
+// cancelItem cancels the request when its field n is resolved.
+type cancelItem struct {
+	cancel context.CancelFunc
+	called int32
+}
+
+func (r *cancelItem) Items() []*cancelItem {
+	return []*cancelItem{r}
+}
+
+func (r *cancelItem) N() int32 {
+	r.cancel()
+	return 1
+}
+
+func (r *cancelItem) Times(ctx context.Context, args struct{ Factor int32 }) int32 {
+	atomic.AddInt32(&r.called, 1)
+	return args.Factor
+}
+
+func TestBatchScheduler_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &cancelItem{cancel: cancel}
+	scheduler := &recordingScheduler{}
+	schema := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			items: [Item!]!
+		}
+
+		type Item {
+			n: Int!
+			times(factor: Int!): Int!
+		}
+	`, r, graphql.UseBatchScheduler(scheduler))
+
+	resp := schema.Exec(ctx, `{ items { n times(factor: 2) } }`, "", nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != context.Canceled.Error() {
+		t.Errorf("expected a context canceled error, got %v", resp.Errors)
+	}
+	// The fields after the cancellation are neither scheduled nor resolved.
+	if len(scheduler.batches) != 0 {
+		t.Errorf("expected no batches, got %v", scheduler.batches)
+	}
+	if called := atomic.LoadInt32(&r.called); called != 0 {
+		t.Errorf("expected times not to be resolved, but it was called %d times", called)
+	}
+}
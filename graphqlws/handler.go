@@ -0,0 +1,365 @@
+// Package graphqlws serves GraphQL subscriptions over WebSockets with the graphql-transport-ws
+// subprotocol, which is used by clients like Apollo and urql. See
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+package graphqlws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// Subprotocol is the WebSocket subprotocol spoken by Handler.
+const Subprotocol = "graphql-transport-ws"
+
+const (
+	// DefaultInitTimeout is the default time a client has to send connection_init.
+	DefaultInitTimeout = 10 * time.Second
+	// DefaultKeepAlive is the default interval of the pings sent to the client.
+	DefaultKeepAlive = 15 * time.Second
+	// DefaultWriteTimeout is the default time a client has to receive a message.
+	DefaultWriteTimeout = 10 * time.Second
+	// DefaultMaxMessageSize is the default maximum size of a message from the client in bytes.
+	DefaultMaxMessageSize = 1 << 20
+)
+
+// Close codes of the graphql-transport-ws protocol.
+const (
+	closeInvalidMessage   = 4400
+	closeUnauthorized     = 4401
+	closeForbidden        = 4403
+	closeInitTimeout      = 4408
+	closeSubscriberExists = 4409
+	closeTooManyInits     = 4429
+)
+
+// Handler is an http.Handler that upgrades requests to WebSocket connections, on which clients can
+// run any number of subscriptions, queries and mutations of the schema, identified by unique ids.
+//
+// The context of an operation is derived from the request context, or from the context returned
+// by InitFunc, and is cancelled when the client completes the operation or the connection is
+// closed, which stops the channel of the subscription resolver.
+//
+// Results are written to the client as they are produced, so a slow client slows down the
+// subscriptions of its connection instead of making the handler buffer their results. A client
+// that does not receive a message within WriteTimeout is disconnected.
+type Handler struct {
+	Schema *graphql.Schema
+
+	// InitFunc is called with the payload of the connection_init message of the client, e.g. to
+	// authenticate it. The returned context, which must be derived from ctx, is used for the
+	// operations of the connection. The connection is closed as forbidden if it returns an error.
+	// It may be nil.
+	InitFunc func(ctx context.Context, payload map[string]interface{}) (context.Context, error)
+
+	// InitTimeout is the time a client has to send connection_init. DefaultInitTimeout is used
+	// if it is zero.
+	InitTimeout time.Duration
+
+	// KeepAlive is the interval at which pings are sent to the client. DefaultKeepAlive is used if
+	// it is zero, and no pings are sent if it is negative.
+	KeepAlive time.Duration
+
+	// WriteTimeout is the time a client has to receive a message. DefaultWriteTimeout is used if
+	// it is zero.
+	WriteTimeout time.Duration
+
+	// MaxMessageSize is the maximum size of a message from the client in bytes.
+	// DefaultMaxMessageSize is used if it is zero.
+	MaxMessageSize int64
+
+	// CheckOrigin reports whether a connection may be opened for the request. Browsers send the
+	// cookies of the site of the handler with the handshake from any page, so connections from
+	// other origins must be rejected unless the client is authenticated otherwise. If it is nil,
+	// a request is rejected if it has an Origin header whose host is not the Host of the request.
+	CheckOrigin func(r *http.Request) bool
+}
+
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type subscribePayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	checkOrigin := h.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "websocket: origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	ws, err := upgrade(w, r, Subprotocol)
+	if err != nil {
+		return
+	}
+	ws.writeTimeout = durationOr(h.WriteTimeout, DefaultWriteTimeout)
+	ws.maxMessageSize = h.MaxMessageSize
+	if ws.maxMessageSize == 0 {
+		ws.maxMessageSize = DefaultMaxMessageSize
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	c := &connection{
+		handler: h,
+		ws:      ws,
+		ctx:     ctx,
+		subs:    make(map[string]*subscription),
+	}
+	c.serve(ctx)
+}
+
+// sameOrigin reports whether the request has no Origin header, as for clients that are not
+// browsers, or the host of its Origin header is the Host of the request.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
+
+// connection is the state of a WebSocket connection of a client.
+type connection struct {
+	handler *Handler
+	ws      *wsConn
+	// ctx is the parent of the contexts of the operations. It is replaced by the context returned
+	// by InitFunc, before any operation is started. It is only used by the goroutine that reads
+	// the messages of the client.
+	ctx context.Context
+
+	mu           sync.Mutex
+	initReceived bool
+	acked        bool
+	subs         map[string]*subscription
+}
+
+type subscription struct {
+	cancel context.CancelFunc
+}
+
+// serve handles the messages of the client until the connection is closed. The keep-alive pings
+// stop when ctx is done.
+func (c *connection) serve(ctx context.Context) {
+	defer c.ws.close(closeNormal, "")
+
+	initTimer := time.AfterFunc(durationOr(c.handler.InitTimeout, DefaultInitTimeout), func() {
+		c.mu.Lock()
+		received := c.initReceived
+		c.mu.Unlock()
+		if !received {
+			c.ws.close(closeInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	if keepAlive := durationOr(c.handler.KeepAlive, DefaultKeepAlive); keepAlive > 0 {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := c.send(&message{Type: "ping"}); err != nil {
+						c.ws.close(closeNormal, "")
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for {
+		data, err := c.ws.readMessage()
+		if err != nil {
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.ws.close(closeInvalidMessage, "Invalid message received")
+			return
+		}
+		if !c.handle(&msg) {
+			return
+		}
+	}
+}
+
+// handle handles a message of the client and reports whether the connection is still open.
+func (c *connection) handle(msg *message) bool {
+	switch msg.Type {
+	case "connection_init":
+		c.mu.Lock()
+		received := c.initReceived
+		c.initReceived = true
+		c.mu.Unlock()
+		if received {
+			c.ws.close(closeTooManyInits, "Too many initialisation requests")
+			return false
+		}
+
+		if c.handler.InitFunc != nil {
+			var payload map[string]interface{}
+			if len(msg.Payload) != 0 {
+				if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+					c.ws.close(closeInvalidMessage, "Invalid message received")
+					return false
+				}
+			}
+			ctx, err := c.handler.InitFunc(c.ctx, payload)
+			if err != nil {
+				c.ws.close(closeForbidden, "Forbidden")
+				return false
+			}
+			c.ctx = ctx
+		}
+		c.mu.Lock()
+		c.acked = true
+		c.mu.Unlock()
+		return c.send(&message{Type: "connection_ack"}) == nil
+
+	case "ping":
+		return c.send(&message{Type: "pong", Payload: msg.Payload}) == nil
+
+	case "pong":
+		return true
+
+	case "subscribe":
+		var payload subscribePayload
+		if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+			c.ws.close(closeInvalidMessage, "Invalid message received")
+			return false
+		}
+
+		c.mu.Lock()
+		if !c.acked {
+			c.mu.Unlock()
+			c.ws.close(closeUnauthorized, "Unauthorized")
+			return false
+		}
+		if _, ok := c.subs[msg.ID]; ok {
+			c.mu.Unlock()
+			c.ws.close(closeSubscriberExists, truncate(fmt.Sprintf("Subscriber for %s already exists", msg.ID)))
+			return false
+		}
+		ctx, cancel := context.WithCancel(c.ctx)
+		sub := &subscription{cancel: cancel}
+		c.subs[msg.ID] = sub
+		c.mu.Unlock()
+
+		go c.run(ctx, msg.ID, sub, &payload)
+		return true
+
+	case "complete":
+		c.mu.Lock()
+		if sub, ok := c.subs[msg.ID]; ok {
+			sub.cancel()
+			delete(c.subs, msg.ID)
+		}
+		c.mu.Unlock()
+		return true
+
+	default:
+		c.ws.close(closeInvalidMessage, "Invalid message received")
+		return false
+	}
+}
+
+// run executes the operation with the given id and sends its results to the client. A response
+// without data as the first result, e.g. because the operation is invalid, is sent as an error.
+func (c *connection) run(ctx context.Context, id string, sub *subscription, payload *subscribePayload) {
+	defer func() {
+		c.mu.Lock()
+		if c.subs[id] == sub {
+			delete(c.subs, id)
+		}
+		c.mu.Unlock()
+		sub.cancel()
+	}()
+
+	responses, err := c.handler.Schema.Subscribe(ctx, payload.Query, payload.OperationName, payload.Variables)
+	if err != nil {
+		c.sendPayload(id, "error", []*errors.QueryError{{Message: err.Error()}})
+		return
+	}
+
+	first := true
+	for resp := range responses {
+		// The channel is drained after the operation is done, so that its producer can finish.
+		if ctx.Err() != nil {
+			continue
+		}
+		r := resp.(*graphql.Response)
+		if first && r.Data == nil && len(r.Errors) != 0 {
+			c.sendPayload(id, "error", r.Errors)
+			sub.cancel()
+			continue
+		}
+		first = false
+		if c.sendPayload(id, "next", r) != nil {
+			sub.cancel()
+		}
+	}
+	if ctx.Err() == nil {
+		c.send(&message{ID: id, Type: "complete"})
+	}
+}
+
+func (c *connection) sendPayload(id, typ string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.send(&message{ID: id, Type: typ, Payload: data})
+}
+
+func (c *connection) send(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := c.ws.writeFrame(opText, data); err != nil {
+		// The client is gone or too slow, which ends all of its operations.
+		c.ws.close(closeNormal, "")
+		return err
+	}
+	return nil
+}
+
+// truncate shortens the reason of a close frame to the maximum length of 123 bytes.
+func truncate(reason string) string {
+	if len(reason) > 123 {
+		return reason[:123]
+	}
+	return reason
+}
@@ -0,0 +1,382 @@
+package graphqlws
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const testSchema = `
+	schema {
+		query: Query
+		subscription: Subscription
+	}
+
+	type Query {
+		hello: String!
+	}
+
+	type Subscription {
+		counter: Int!
+		ticks: Int!
+	}
+`
+
+type testResolver struct {
+	// cancelled receives a value when the context of a ticks subscription is done.
+	cancelled chan struct{}
+}
+
+func (r *testResolver) Hello() string {
+	return "Hello world!"
+}
+
+func (r *testResolver) Counter(ctx context.Context) <-chan int32 {
+	c := make(chan int32)
+	go func() {
+		defer close(c)
+		for i := int32(1); i <= 3; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (r *testResolver) Ticks(ctx context.Context) <-chan int32 {
+	c := make(chan int32)
+	go func() {
+		defer close(c)
+		for i := int32(1); ; i++ {
+			select {
+			case c <- i:
+			case <-ctx.Done():
+				r.cancelled <- struct{}{}
+				return
+			}
+		}
+	}()
+	return c
+}
+
+type userKey struct{}
+
+func newTestServer(h *Handler) (*httptest.Server, *testResolver) {
+	r := &testResolver{cancelled: make(chan struct{}, 10)}
+	h.Schema = graphql.MustParseSchema(testSchema, r)
+	if h.InitFunc == nil {
+		h.InitFunc = func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+			if payload["token"] != "secret" {
+				return nil, errors.New("invalid token")
+			}
+			return context.WithValue(ctx, userKey{}, "user"), nil
+		}
+	}
+	return httptest.NewServer(h), r
+}
+
+// dial opens a client connection to the server, which the caller must close.
+func dial(t *testing.T, srv *httptest.Server) *wsConn {
+	conn, br, resp := handshake(t, srv, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Fatalf("got accept key %q, want %q", got, want)
+	}
+	return &wsConn{conn: conn, br: br, client: true}
+}
+
+// handshake sends the opening handshake with the given additional header lines, each ending in
+// "\r\n", and returns the response of the server. The caller must close the connection.
+func handshake(t *testing.T, srv *httptest.Server, header string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Protocol: %s\r\n%s\r\n", srv.Listener.Addr(), Subprotocol, header)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, br, resp
+}
+
+func send(t *testing.T, c *wsConn, msg string) {
+	t.Helper()
+	if err := c.writeFrame(opText, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expect reads the next message, skipping pings of the server, and compares it to want.
+func expect(t *testing.T, c *wsConn, want string) {
+	t.Helper()
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, err := c.readMessage()
+		if err != nil {
+			t.Fatalf("expected message %s, got %s", want, err)
+		}
+		if got := string(data); got != `{"type":"ping"}` {
+			if !jsonEqual(got, want) {
+				t.Fatalf("got message %s, want %s", got, want)
+			}
+			return
+		}
+	}
+}
+
+func jsonEqual(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return fmt.Sprint(va) == fmt.Sprint(vb)
+}
+
+// expectClose reads frames until the close frame of the server and compares its code to want.
+func expectClose(t *testing.T, c *wsConn, want int) {
+	t.Helper()
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, op, payload, err := c.readFrame()
+		if err != nil {
+			t.Fatalf("expected close frame with code %d, got %s", want, err)
+		}
+		if op != opClose {
+			continue
+		}
+		if len(payload) < 2 {
+			t.Fatalf("expected close frame with code %d, got none", want)
+		}
+		if got := int(binary.BigEndian.Uint16(payload)); got != want {
+			t.Fatalf("got close code %d (%s), want %d", got, payload[2:], want)
+		}
+		return
+	}
+}
+
+func initConn(t *testing.T, c *wsConn) {
+	t.Helper()
+	send(t, c, `{"type":"connection_init","payload":{"token":"secret"}}`)
+	expect(t, c, `{"type":"connection_ack"}`)
+}
+
+func TestSubscribe(t *testing.T) {
+	srv, _ := newTestServer(&Handler{KeepAlive: -1})
+	defer srv.Close()
+	c := dial(t, srv)
+	defer c.conn.Close()
+	initConn(t, c)
+
+	send(t, c, `{"id":"1","type":"subscribe","payload":{"query":"subscription { counter }"}}`)
+	expect(t, c, `{"id":"1","type":"next","payload":{"data":{"counter":1}}}`)
+	expect(t, c, `{"id":"1","type":"next","payload":{"data":{"counter":2}}}`)
+	expect(t, c, `{"id":"1","type":"next","payload":{"data":{"counter":3}}}`)
+	expect(t, c, `{"id":"1","type":"complete"}`)
+
+	send(t, c, `{"id":"2","type":"subscribe","payload":{"query":"query Hello { hello }","operationName":"Hello"}}`)
+	expect(t, c, `{"id":"2","type":"next","payload":{"data":{"hello":"Hello world!"}}}`)
+	expect(t, c, `{"id":"2","type":"complete"}`)
+
+	send(t, c, `{"id":"3","type":"subscribe","payload":{"query":"subscription { unknown }"}}`)
+	expect(t, c, `{"id":"3","type":"error","payload":[{"message":"Cannot query field \"unknown\" on type \"Subscription\".","locations":[{"line":1,"column":16}]}]}`)
+
+	send(t, c, `{"type":"ping"}`)
+	expect(t, c, `{"type":"pong"}`)
+}
+
+func TestSubscribeComplete(t *testing.T) {
+	srv, r := newTestServer(&Handler{KeepAlive: -1})
+	defer srv.Close()
+	c := dial(t, srv)
+	defer c.conn.Close()
+	initConn(t, c)
+
+	send(t, c, `{"id":"1","type":"subscribe","payload":{"query":"subscription { ticks }"}}`)
+	expect(t, c, `{"id":"1","type":"next","payload":{"data":{"ticks":1}}}`)
+	send(t, c, `{"id":"1","type":"complete"}`)
+	select {
+	case <-r.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription was not cancelled by complete")
+	}
+
+	// The id can be used again once the operation is completed.
+	send(t, c, `{"id":"1","type":"subscribe","payload":{"query":"subscription { counter }"}}`)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, err := c.readMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jsonEqual(string(data), `{"id":"1","type":"complete"}`) {
+			break
+		}
+	}
+
+	send(t, c, `{"id":"2","type":"subscribe","payload":{"query":"subscription { ticks }"}}`)
+	expect(t, c, `{"id":"2","type":"next","payload":{"data":{"ticks":1}}}`)
+	c.conn.Close()
+	select {
+	case <-r.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription was not cancelled when the connection was closed")
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	srv, _ := newTestServer(&Handler{KeepAlive: 10 * time.Millisecond})
+	defer srv.Close()
+	c := dial(t, srv)
+	defer c.conn.Close()
+	initConn(t, c)
+
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := c.readMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != `{"type":"ping"}` {
+		t.Errorf("got message %s, want a ping", got)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		handler  *Handler
+		messages []string
+		code     int
+	}{
+		{
+			name:     "subscribe before connection_init",
+			messages: []string{`{"id":"1","type":"subscribe","payload":{"query":"subscription { ticks }"}}`},
+			code:     closeUnauthorized,
+		},
+		{
+			name:     "rejected connection_init",
+			messages: []string{`{"type":"connection_init","payload":{"token":"wrong"}}`},
+			code:     closeForbidden,
+		},
+		{
+			name: "repeated connection_init",
+			messages: []string{
+				`{"type":"connection_init","payload":{"token":"secret"}}`,
+				`{"type":"connection_init","payload":{"token":"secret"}}`,
+			},
+			code: closeTooManyInits,
+		},
+		{
+			name: "duplicate subscription id",
+			messages: []string{
+				`{"type":"connection_init","payload":{"token":"secret"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { ticks }"}}`,
+				`{"id":"1","type":"subscribe","payload":{"query":"subscription { ticks }"}}`,
+			},
+			code: closeSubscriberExists,
+		},
+		{
+			name:     "unknown message type",
+			messages: []string{`{"type":"unknown"}`},
+			code:     closeInvalidMessage,
+		},
+		{
+			name:    "missing connection_init",
+			handler: &Handler{InitTimeout: 10 * time.Millisecond},
+			code:    closeInitTimeout,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := tc.handler
+			if h == nil {
+				h = &Handler{}
+			}
+			h.KeepAlive = -1
+			srv, _ := newTestServer(h)
+			defer srv.Close()
+			c := dial(t, srv)
+			defer c.conn.Close()
+			for _, msg := range tc.messages {
+				send(t, c, msg)
+			}
+			expectClose(t, c, tc.code)
+		})
+	}
+}
+
+func TestUpgradeRequired(t *testing.T) {
+	srv, _ := newTestServer(&Handler{})
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	allowAll := func(r *http.Request) bool { return true }
+	for _, tc := range []struct {
+		name        string
+		checkOrigin func(r *http.Request) bool
+		origin      func(srv *httptest.Server) string
+		status      int
+	}{
+		{
+			name:   "no origin",
+			origin: func(srv *httptest.Server) string { return "" },
+			status: http.StatusSwitchingProtocols,
+		},
+		{
+			name:   "same origin",
+			origin: func(srv *httptest.Server) string { return srv.URL },
+			status: http.StatusSwitchingProtocols,
+		},
+		{
+			name:   "cross origin",
+			origin: func(srv *httptest.Server) string { return "https://evil.example" },
+			status: http.StatusForbidden,
+		},
+		{
+			name:        "cross origin allowed",
+			checkOrigin: allowAll,
+			origin:      func(srv *httptest.Server) string { return "https://evil.example" },
+			status:      http.StatusSwitchingProtocols,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, _ := newTestServer(&Handler{CheckOrigin: tc.checkOrigin})
+			defer srv.Close()
+			var header string
+			if origin := tc.origin(srv); origin != "" {
+				header = "Origin: " + origin + "\r\n"
+			}
+			conn, _, resp := handshake(t, srv, header)
+			defer conn.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package graphqlws
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements the parts of the WebSocket protocol (RFC 6455) that the handler needs:
+// the opening handshake, reading fragmented text messages and writing single-frame messages.
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// Close codes of RFC 6455.
+const (
+	closeNormal        = 1000
+	closeProtocolError = 1002
+	closeMessageTooBig = 1009
+	closeNoStatus      = 1005
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var errClosed = errors.New("graphqlws: connection closed")
+
+// wsConn is a WebSocket connection. Writes are safe for concurrent use, reads are not.
+type wsConn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	client         bool // clients mask the frames they write
+	maxMessageSize int64
+	writeTimeout   time.Duration
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// upgrade performs the opening handshake of a WebSocket connection that speaks the given
+// subprotocol. It writes an HTTP error if the request is not a valid handshake for it.
+func upgrade(w http.ResponseWriter, r *http.Request, subprotocol string) (*wsConn, error) {
+	fail := func(status int, format string, args ...interface{}) (*wsConn, error) {
+		err := fmt.Errorf(format, args...)
+		http.Error(w, err.Error(), status)
+		return nil, err
+	}
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "websocket: method must be GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "websocket: not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return fail(http.StatusBadRequest, "websocket: missing Sec-WebSocket-Key")
+	}
+	if !headerContains(r.Header, "Sec-WebSocket-Protocol", subprotocol) {
+		return fail(http.StatusBadRequest, "websocket: subprotocol %q is required", subprotocol)
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fail(http.StatusInternalServerError, "websocket: response does not support hijacking")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "websocket: %s", err)
+	}
+
+	fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\nSec-WebSocket-Protocol: %s\r\n\r\n", acceptKey(key), subprotocol)
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: brw.Reader}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+acceptGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains reports whether the comma separated values of the header contain token, ignoring
+// case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage returns the payload of the next text or binary message. Pings are answered while
+// waiting for it. It returns errClosed once the peer closed the connection.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			code := closeNoStatus
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			c.close(code, "")
+			return nil, errClosed
+		case opText, opBinary, opContinuation:
+			if started == (op != opContinuation) {
+				c.close(closeProtocolError, "unexpected continuation")
+				return nil, errClosed
+			}
+			started = true
+			if c.maxMessageSize > 0 && int64(len(message)+len(payload)) > c.maxMessageSize {
+				c.close(closeMessageTooBig, "message too big")
+				return nil, errClosed
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			c.close(closeProtocolError, "unknown opcode")
+			return nil, errClosed
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	op = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	if header[0]&0x70 != 0 || masked == c.client {
+		c.close(closeProtocolError, "invalid frame header")
+		return false, 0, nil, errClosed
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(b[:])
+	}
+	if op >= opClose && (!fin || length > 125) {
+		c.close(closeProtocolError, "invalid control frame")
+		return false, 0, nil, errClosed
+	}
+	if c.maxMessageSize > 0 && length > uint64(c.maxMessageSize) {
+		c.close(closeMessageTooBig, "message too big")
+		return false, 0, nil, errClosed
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes a single frame with the given opcode and payload.
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errClosed
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *wsConn) writeFrameLocked(op byte, payload []byte) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|op)
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = append(buf, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(buf[len(buf)-8:], uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		buf = append(buf, mask[:]...)
+		for i, b := range payload {
+			buf = append(buf, b^mask[i%4])
+		}
+	} else {
+		buf = append(buf, payload...)
+	}
+
+	if c.writeTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// close sends a close frame with the given code and reason, unless the connection is already
+// closed, and closes the underlying connection.
+func (c *wsConn) close(code int, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	var payload []byte
+	if code != closeNoStatus {
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+	}
+	c.writeFrameLocked(opClose, payload)
+	c.conn.Close()
+}
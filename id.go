@@ -28,3 +28,44 @@ func (id *ID) UnmarshalGraphQL(input interface{}) error {
 func (id ID) MarshalJSON() ([]byte, error) {
 	return strconv.AppendQuote(nil, string(id)), nil
 }
+
+// NullID is a nullable ID that tells an explicit null apart from an omitted value, which a pointer
+// can not. It can be used for arguments and input fields of type ID, and as the result of fields of
+// type ID. Set is true if a value was given, Value is nil if that value was null.
+type NullID struct {
+	Value *ID
+	Set   bool
+}
+
+// NewNullID returns a NullID that is set to id.
+func NewNullID(id ID) NullID {
+	return NullID{Value: &id, Set: true}
+}
+
+func (NullID) ImplementsGraphQLType(name string) bool {
+	return name == "ID"
+}
+
+func (n *NullID) UnmarshalGraphQL(input interface{}) error {
+	n.Set = true
+	if input == nil {
+		n.Value = nil
+		return nil
+	}
+	var id ID
+	if err := id.UnmarshalGraphQL(input); err != nil {
+		return err
+	}
+	n.Value = &id
+	return nil
+}
+
+// Nullable marks NullID as a nullable type, which is unmarshaled from null as well.
+func (n *NullID) Nullable() {}
+
+func (n NullID) MarshalJSON() ([]byte, error) {
+	if n.Value == nil {
+		return []byte("null"), nil
+	}
+	return n.Value.MarshalJSON()
+}
@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// ExecIncremental executes the given query like Exec, but delivers the results of fragments with
+// the @defer directive and the items of list fields with the @stream directive incrementally.
+// The first response holds the result without the deferred fragments and with only the first
+// initialCount items of streamed lists. Each of the following responses holds the result of one
+// deferred fragment together with the path of the object to merge it into, or one streamed item
+// together with its path in the list, along with the label of the directive. HasNext is false on
+// the last response, after which the channel is closed. The directives are only recognized if the
+// schema declares them:
+//
+//	directive @defer(if: Boolean = true, label: String) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+//	directive @stream(if: Boolean = true, label: String, initialCount: Int = 0) on FIELD
+//
+// Exec ignores the directives and includes everything in its response. If the context gets
+// cancelled, the response channel will be closed and no further resolvers will be called.
+func (s *Schema) ExecIncremental(ctx context.Context, queryString string, operationName string, variables map[string]interface{}) <-chan *Response {
+	if s.res.Resolver == (reflect.Value{}) {
+		panic("schema created without resolver, can not exec")
+	}
+
+	c := make(chan *Response)
+	go func() {
+		defer close(c)
+
+		resp, r := s.execute(ctx, queryString, operationName, variables, s.res, true)
+		if r == nil || resp.Data == nil {
+			resp.HasNext = hasNext(false)
+			select {
+			case c <- resp:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		// A response is only sent once the next one is known, so that HasNext can be set.
+		for p := range r.ExecuteDeferred(ctx, s.res) {
+			resp.HasNext = hasNext(true)
+			select {
+			case c <- resp:
+			case <-ctx.Done():
+				return
+			}
+			resp = &Response{
+				Errors: errors.SetPhase(p.Errors, errors.PhaseExecute),
+				Data:   p.Data,
+				Items:  p.Items,
+				Label:  p.Label,
+				Path:   p.Path,
+			}
+		}
+		resp.HasNext = hasNext(false)
+		select {
+		case c <- resp:
+		case <-ctx.Done():
+		}
+	}()
+	return c
+}
+
+func hasNext(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,253 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+)
+
+var starwarsSchemaWithDefer = graphql.MustParseSchema(starwars.Schema+`
+	directive @defer(if: Boolean = true, label: String) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+	directive @stream(if: Boolean = true, label: String, initialCount: Int = 0) on FIELD
+`, &starwars.Resolver{})
+
+func TestExecIncremental(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name: "deferred fragment spread",
+			query: `
+				{
+					hero {
+						name
+						...HeroFriends @defer(label: "friends")
+					}
+				}
+
+				fragment HeroFriends on Character {
+					friends {
+						name
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"hero":{"name":"R2-D2"}},"hasNext":true}`,
+				`{"data":{"friends":[{"name":"Luke Skywalker"},{"name":"Han Solo"},{"name":"Leia Organa"}]},"label":"friends","path":["hero"],"hasNext":false}`,
+			},
+		},
+		{
+			name: "nested deferred fragments in a list",
+			query: `
+				{
+					hero {
+						friends {
+							name
+							... on Human @defer {
+								height
+							}
+						}
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"hero":{"friends":[{"name":"Luke Skywalker"},{"name":"Han Solo"},{"name":"Leia Organa"}]}},"hasNext":true}`,
+				`{"data":{"height":1.72},"path":["hero","friends",0],"hasNext":true}`,
+				`{"data":{"height":1.8},"path":["hero","friends",1],"hasNext":true}`,
+				`{"data":{"height":1.5},"path":["hero","friends",2],"hasNext":false}`,
+			},
+		},
+		{
+			name: "deferred fragment on the root object",
+			query: `
+				{
+					... @defer(label: "root") {
+						hero {
+							name
+						}
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{},"hasNext":true}`,
+				`{"data":{"hero":{"name":"R2-D2"}},"label":"root","path":[],"hasNext":false}`,
+			},
+		},
+		{
+			name: "disabled defer",
+			query: `
+				{
+					hero {
+						name
+						... @defer(if: false) {
+							id
+						}
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"hero":{"name":"R2-D2","id":"2001"}},"hasNext":false}`,
+			},
+		},
+		{
+			name: "streamed list",
+			query: `
+				{
+					hero {
+						friends @stream(initialCount: 1, label: "friends") {
+							name
+						}
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"hero":{"friends":[{"name":"Luke Skywalker"}]}},"hasNext":true}`,
+				`{"items":[{"name":"Han Solo"}],"label":"friends","path":["hero","friends",1],"hasNext":true}`,
+				`{"items":[{"name":"Leia Organa"}],"label":"friends","path":["hero","friends",2],"hasNext":false}`,
+			},
+		},
+		{
+			name: "streamed empty list",
+			query: `
+				{
+					search(text: "nobody") @stream {
+						__typename
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"search":[]},"hasNext":false}`,
+			},
+		},
+		{
+			name: "initial count larger than the list",
+			query: `
+				{
+					hero {
+						appearsIn @stream(initialCount: 5)
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"hero":{"appearsIn":["NEWHOPE","EMPIRE","JEDI"]}},"hasNext":false}`,
+			},
+		},
+		{
+			name: "nested streamed lists",
+			query: `
+				{
+					hero {
+						friends @stream(initialCount: 2) {
+							appearsIn @stream(initialCount: 2)
+						}
+					}
+				}
+			`,
+			want: []string{
+				`{"data":{"hero":{"friends":[{"appearsIn":["NEWHOPE","EMPIRE"]},{"appearsIn":["NEWHOPE","EMPIRE"]}]}},"hasNext":true}`,
+				`{"items":["JEDI"],"path":["hero","friends",0,"appearsIn",2],"hasNext":true}`,
+				`{"items":["JEDI"],"path":["hero","friends",1,"appearsIn",2],"hasNext":true}`,
+				`{"items":[{"appearsIn":["NEWHOPE","EMPIRE"]}],"path":["hero","friends",2],"hasNext":true}`,
+				`{"items":["JEDI"],"path":["hero","friends",2,"appearsIn",2],"hasNext":false}`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for resp := range starwarsSchemaWithDefer.ExecIncremental(context.Background(), tc.query, "", nil) {
+				b, err := json.Marshal(resp)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, string(b))
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("wrong number of responses, have=%q want=%q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("wrong response #%d\nhave: %s\nwant: %s", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExecIgnoresDefer(t *testing.T) {
+	resp := starwarsSchemaWithDefer.Exec(context.Background(), `
+		{
+			hero {
+				name
+				... @defer {
+					id
+				}
+			}
+		}
+	`, "", nil)
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	if want := `{"hero":{"name":"R2-D2","id":"2001"}}`; string(resp.Data) != want {
+		t.Fatalf("wrong data\nhave: %s\nwant: %s", resp.Data, want)
+	}
+}
+
+type nulledOuterResolver struct{}
+
+func (*nulledOuterResolver) Outer() *nulledOuterResolver { return &nulledOuterResolver{} }
+
+func (*nulledOuterResolver) Inner() *nulledOuterResolver { return &nulledOuterResolver{} }
+
+func (*nulledOuterResolver) Name() string { return "inner" }
+
+func (*nulledOuterResolver) Broken() (string, error) { return "", errors.New("broken") }
+
+func TestExecIncrementalNulledAncestor(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		directive @defer(if: Boolean = true, label: String) on FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+		type Query {
+			outer: Outer
+		}
+
+		type Outer {
+			inner: Inner!
+			broken: String!
+		}
+
+		type Inner {
+			name: String!
+		}
+	`, &nulledOuterResolver{})
+
+	// The fragment deferred by inner is dropped when outer resolves to null because of broken.
+	var got []string
+	for resp := range s.ExecIncremental(context.Background(), `
+		{
+			outer {
+				inner {
+					... @defer {
+						name
+					}
+				}
+				broken
+			}
+		}
+	`, "", nil) {
+		b, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(b))
+	}
+	want := `{"errors":[{"message":"broken","path":["outer","broken"]}],"data":{"outer":null},"hasNext":false}`
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("wrong responses\nhave: %s\nwant: %s", got, want)
+	}
+}
@@ -17,6 +17,8 @@ type Lexer struct {
 	next                  rune
 	comment               bytes.Buffer
 	useStringDescriptions bool
+	maxTokens             int
+	tokens                int
 }
 
 type Ident struct {
@@ -33,6 +35,12 @@ func NewLexer(s string, useStringDescriptions bool) *Lexer {
 	return &Lexer{sc: sc, useStringDescriptions: useStringDescriptions}
 }
 
+// SetMaxTokens makes the lexer fail with a syntax error as soon as it scans more than n tokens, so
+// that huge documents are rejected before they are parsed completely. There is no limit if n is 0.
+func (l *Lexer) SetMaxTokens(n int) {
+	l.maxTokens = n
+}
+
 func (l *Lexer) CatchSyntaxError(f func()) (errRes *errors.QueryError) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -84,6 +92,13 @@ func (l *Lexer) ConsumeWhitespace() {
 
 		break
 	}
+
+	if l.next != scanner.EOF {
+		l.tokens++
+		if l.maxTokens > 0 && l.tokens > l.maxTokens {
+			l.SyntaxError(fmt.Sprintf("document contains more than %d tokens, parsing aborted", l.maxTokens))
+		}
+	}
 }
 
 // consumeDescription optionally consumes a description based on the June 2018 graphql spec if any are present.
@@ -104,6 +104,9 @@ type ObjectLitField struct {
 func (lit *ObjectLit) Value(vars map[string]interface{}) interface{} {
 	fields := make(map[string]interface{}, len(lit.Fields))
 	for _, f := range lit.Fields {
+		if IsUnsetVariable(f.Value, vars) {
+			continue
+		}
 		fields[f.Name.Name] = f.Value.Value(vars)
 	}
 	return fields
@@ -146,6 +149,17 @@ func (v Variable) Value(vars map[string]interface{}) interface{} {
 	return vars[v.Name]
 }
 
+// IsUnsetVariable reports whether lit is a variable that was not provided. An argument or input
+// field whose value is such a variable is absent, rather than null.
+func IsUnsetVariable(lit Literal, vars map[string]interface{}) bool {
+	v, ok := lit.(*Variable)
+	if !ok {
+		return false
+	}
+	_, ok = vars[v.Name]
+	return !ok
+}
+
 func (v Variable) String() string {
 	return "$" + v.Name
 }
@@ -0,0 +1,206 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+)
+
+// BatchKey identifies the resolutions of a field that are scheduled as one batch: the same field,
+// with the same arguments, at the same path in the elements of a list.
+type BatchKey struct {
+	// Path lists the response names of the field and its ancestors without the list indexes,
+	// separated by dots, e.g. "posts.author".
+	Path string
+	// ParentType is the name of the object type that declares the field.
+	ParentType string
+	// Field is the name of the field.
+	Field string
+	// Args holds the arguments of the field as JSON, with the keys in sorted order.
+	Args string
+}
+
+// BatchScheduler coalesces the resolutions of a field across the elements of a list, so that the
+// data of all of them can be loaded at once instead of once per element.
+type BatchScheduler interface {
+	// Schedule is called once per batch, after the elements of the list have collected their fields.
+	// The parents are the resolvers of the elements that resolve the field, in the order of the
+	// list. Schedule may load the data of all of them, and then calls resolve with a context from
+	// which the resolvers can read it. resolve runs the resolvers and returns when they are done.
+	// If Schedule does not call resolve, the resolvers are run with ctx after it returns.
+	Schedule(ctx context.Context, key BatchKey, parents []interface{}, resolve func(ctx context.Context))
+}
+
+type batchGroupKey struct{}
+
+// batchElement is the value of batchGroupKey in the context of an element of a list.
+type batchElement struct {
+	group *batchGroup
+	index int
+}
+
+// withBatchGroup returns the context of the element with the given index of a list whose fields
+// are scheduled by group. A nil group removes the group of the parent list from ctx.
+func withBatchGroup(ctx context.Context, group *batchGroup, index int) context.Context {
+	if group == nil {
+		if ctx.Value(batchGroupKey{}) == nil {
+			return ctx
+		}
+		return context.WithValue(ctx, batchGroupKey{}, (*batchElement)(nil))
+	}
+	return context.WithValue(ctx, batchGroupKey{}, &batchElement{group: group, index: index})
+}
+
+// batchElementOf returns the element of a list that ctx belongs to, or nil.
+func batchElementOf(ctx context.Context) *batchElement {
+	e, _ := ctx.Value(batchGroupKey{}).(*batchElement)
+	return e
+}
+
+// batchCall is an asynchronous field of an element of a list.
+type batchCall struct {
+	key    BatchKey
+	parent interface{}
+	run    func(ctx context.Context)
+}
+
+// batchGroup collects the asynchronous fields of the elements of a list and, once all elements
+// have collected their fields, passes them to the scheduler in batches of the same BatchKey.
+type batchGroup struct {
+	ctx       context.Context
+	r         *Request
+	mu        sync.Mutex
+	arrived   []bool
+	remaining int
+	keys      []BatchKey
+	calls     map[BatchKey][]batchCall
+}
+
+func newBatchGroup(ctx context.Context, r *Request, n int) *batchGroup {
+	return &batchGroup{
+		ctx:       ctx,
+		r:         r,
+		arrived:   make([]bool, n),
+		remaining: n,
+		calls:     make(map[BatchKey][]batchCall),
+	}
+}
+
+// arrive adds the calls of the element with the given index to the group. Elements without
+// asynchronous fields arrive with no calls when they are done, and only the first arrival of an
+// element counts. The batches are scheduled when the last element arrives.
+func (g *batchGroup) arrive(index int, calls []batchCall) {
+	g.mu.Lock()
+	if g.arrived[index] {
+		g.mu.Unlock()
+		return
+	}
+	g.arrived[index] = true
+	for _, c := range calls {
+		if _, ok := g.calls[c.key]; !ok {
+			g.keys = append(g.keys, c.key)
+		}
+		g.calls[c.key] = append(g.calls[c.key], c)
+	}
+	g.remaining--
+	done := g.remaining == 0
+	g.mu.Unlock()
+
+	if done {
+		for _, key := range g.keys {
+			go g.schedule(key, g.calls[key])
+		}
+	}
+}
+
+// schedule passes a batch to the scheduler. The calls that the scheduler does not run, also
+// because it panicked, are run afterwards, so that the elements of the list always complete.
+func (g *batchGroup) schedule(key BatchKey, calls []batchCall) {
+	var once sync.Once
+	resolve := func(ctx context.Context) {
+		once.Do(func() {
+			var wg sync.WaitGroup
+			wg.Add(len(calls))
+			for _, c := range calls {
+				go func(c batchCall) {
+					defer wg.Done()
+					c.run(ctx)
+				}(c)
+			}
+			wg.Wait()
+		})
+	}
+	defer resolve(g.ctx)
+	defer g.r.handlePanic(g.ctx)
+
+	parents := make([]interface{}, len(calls))
+	for i, c := range calls {
+		parents[i] = c.parent
+	}
+	g.r.BatchScheduler.Schedule(g.ctx, key, parents, resolve)
+}
+
+// execBatched executes the fields of an element of a list. The asynchronous fields are passed to
+// the batch group of the list, and the others are executed right away. The fields are dispatched in
+// the order of byPriority, but the limiter slots of the asynchronous fields are only taken when
+// they run, since the batches wait for all elements of the list. Once ctx is done, the remaining
+// fields are skipped, and the element arrives at the group with the calls collected so far.
+func (r *Request) execBatched(ctx context.Context, e *batchElement, fields []*fieldToExec, path *pathSegment, s *resolvable.Schema) {
+	var wg sync.WaitGroup
+	var calls []batchCall
+	dispatch, _ := byPriority(fields)
+	for i, f := range dispatch {
+		if ctx.Err() != nil {
+			r.skipFields(ctx, dispatch[i:], path)
+			break
+		}
+		f.out = new(bytes.Buffer)
+		fieldPath := fieldSegment(path, f.field)
+		if !f.field.Async {
+			execFieldSelection(ctx, r, s, f, fieldPath, true)
+			continue
+		}
+		wg.Add(1)
+		f := f
+		calls = append(calls, batchCall{
+			key:    batchKey(fieldPath, f),
+			parent: f.resolver.Interface(),
+			run: func(ctx context.Context) {
+				defer wg.Done()
+				defer r.handlePanic(ctx)
+				execFieldSelection(ctx, r, s, f, fieldPath, true)
+			},
+		})
+	}
+	e.group.arrive(e.index, calls)
+	wg.Wait()
+}
+
+// batchKey returns the BatchKey of the field f at path.
+func batchKey(path *pathSegment, f *fieldToExec) BatchKey {
+	var names []string
+	for _, v := range path.toSlice() {
+		if name, ok := v.(string); ok {
+			names = append(names, name)
+		}
+	}
+	args := "{}"
+	if len(f.field.Args) > 0 {
+		data, err := json.Marshal(f.field.Args)
+		if err != nil {
+			data = []byte(fmt.Sprint(f.field.Args))
+		}
+		args = string(data)
+	}
+	return BatchKey{
+		Path:       strings.Join(names, "."),
+		ParentType: f.field.TypeName,
+		Field:      f.field.Name,
+		Args:       args,
+	}
+}
@@ -0,0 +1,163 @@
+package exec
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// CacheControl aggregates the @cacheControl hints of all fields resolved in a request. The
+// resulting max age is the minimum of all hints, and the scope is private if any hint is private.
+type CacheControl struct {
+	// DefaultMaxAge applies to root fields and fields returning a composite type without a hint.
+	DefaultMaxAge int
+
+	mu       sync.Mutex
+	maxAge   int
+	hasAge   bool
+	private  bool
+	entities map[EntityTag]struct{}
+}
+
+// EntityTag identifies an entity that a resolver touched, so that cached responses can be
+// invalidated when the entity changes.
+type EntityTag struct {
+	TypeName string
+	ID       string
+}
+
+// AddEntity records that the entity with the given type and ID was touched. It is safe to call
+// from concurrent resolvers.
+func (c *CacheControl) AddEntity(typeName, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entities == nil {
+		c.entities = make(map[EntityTag]struct{})
+	}
+	c.entities[EntityTag{TypeName: typeName, ID: id}] = struct{}{}
+}
+
+// Entities returns a copy of the touched entities, sorted by type name and ID, or nil if there are
+// none.
+func (c *CacheControl) Entities() []EntityTag {
+	c.mu.Lock()
+	if len(c.entities) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	l := make([]EntityTag, 0, len(c.entities))
+	for tag := range c.entities {
+		l = append(l, tag)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].TypeName != l[j].TypeName {
+			return l[i].TypeName < l[j].TypeName
+		}
+		return l[i].ID < l[j].ID
+	})
+	return l
+}
+
+type cacheControlKey struct{}
+
+// WithCacheControl returns a copy of ctx that carries c, so that resolvers can add entities to it.
+func WithCacheControl(ctx context.Context, c *CacheControl) context.Context {
+	return context.WithValue(ctx, cacheControlKey{}, c)
+}
+
+// CacheControlFromContext returns the cache control of the request that ctx belongs to, if any.
+func CacheControlFromContext(ctx context.Context) (*CacheControl, bool) {
+	c, ok := ctx.Value(cacheControlKey{}).(*CacheControl)
+	return c, ok
+}
+
+// Result returns the aggregated max age and whether the response is private.
+func (c *CacheControl) Result() (maxAge int, private bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.hasAge {
+		return c.DefaultMaxAge, c.private
+	}
+	return c.maxAge, c.private
+}
+
+func (c *CacheControl) add(maxAge *int, private bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if maxAge != nil && (!c.hasAge || *maxAge < c.maxAge) {
+		c.maxAge = *maxAge
+		c.hasAge = true
+	}
+	c.private = c.private || private
+}
+
+// addFieldHint records the hint of a field. The hint on the field definition takes precedence
+// over the hint on the type the field returns. Leaf fields without a hint inherit the hint of
+// their parent, all other fields without a hint fall back to the default max age.
+func (c *CacheControl) addFieldHint(f *schema.Field, isRoot bool) {
+	d := f.Directives.Get("cacheControl")
+	if d == nil {
+		d = typeDirectives(f.Type).Get("cacheControl")
+	}
+	if d == nil {
+		if isRoot || !isLeaf(f.Type) {
+			c.add(&c.DefaultMaxAge, false)
+		}
+		return
+	}
+
+	var maxAge *int
+	if lit, ok := d.Args.Get("maxAge"); ok && lit != nil {
+		if v, ok := lit.Value(nil).(int32); ok {
+			age := int(v)
+			maxAge = &age
+		}
+	}
+	if maxAge == nil && (isRoot || !isLeaf(f.Type)) {
+		maxAge = &c.DefaultMaxAge
+	}
+	private := false
+	if lit, ok := d.Args.Get("scope"); ok && lit != nil {
+		private = lit.Value(nil) == "PRIVATE"
+	}
+	c.add(maxAge, private)
+}
+
+func typeDirectives(t common.Type) common.DirectiveList {
+	for {
+		switch t2 := t.(type) {
+		case *common.List:
+			t = t2.OfType
+		case *common.NonNull:
+			t = t2.OfType
+		case *schema.Object:
+			return t2.Directives
+		case *schema.Interface:
+			return t2.Directives
+		case *schema.Union:
+			return t2.Directives
+		default:
+			return nil
+		}
+	}
+}
+
+func isLeaf(t common.Type) bool {
+	for {
+		switch t2 := t.(type) {
+		case *common.List:
+			t = t2.OfType
+		case *common.NonNull:
+			t = t2.OfType
+		case *schema.Scalar, *schema.Enum:
+			return true
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+)
+
+// ActualCost sums the @cost complexity of all fields resolved in a request. A field is counted
+// every time it is resolved, so the fields selected on the items of a list are weighted with the
+// resolved length of the list instead of the multipliers that the estimated cost uses.
+type ActualCost struct {
+	mu    sync.Mutex
+	total int
+}
+
+// Total returns the cost of the fields resolved so far.
+func (c *ActualCost) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// addField adds the complexity of a resolution of f, which is taken from the same @cost directive
+// as for the estimated cost.
+func (c *ActualCost) addField(s *resolvable.Schema, f *resolvable.Field) {
+	d := s.CostDirective(s.Types[f.TypeName], &f.Field)
+	complexity := fieldComplexity(d)
+	if complexity == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.total > int(^uint(0)>>1)-complexity {
+		c.total = int(^uint(0) >> 1)
+		return
+	}
+	c.total += complexity
+}
+
+func fieldComplexity(d *common.Directive) int {
+	if d == nil {
+		return 0
+	}
+	if lit, ok := d.Args.Get("complexity"); ok && lit != nil {
+		if v, ok := lit.Value(nil).(int32); ok && v > 0 {
+			return int(v)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+)
+
+// Patch is the result of a deferred fragment or of a streamed list item. Data holds the fields of
+// a fragment, which are merged into the object at Path, which is empty for the root object. Items
+// holds a list item, which is inserted into the list at the index that Path ends with.
+type Patch struct {
+	Label  string
+	Path   []interface{}
+	Data   json.RawMessage
+	Items  json.RawMessage
+	Errors []*errors.QueryError
+}
+
+// deferredFragment is a deferred fragment, or a streamed list item if itemType is set.
+type deferredFragment struct {
+	label    string
+	sels     []selected.Selection
+	itemType common.Type
+	resolver reflect.Value
+	path     *pathSegment
+}
+
+func (r *Request) addDeferred(deferred []*deferredFragment, path *pathSegment) {
+	if len(deferred) == 0 {
+		return
+	}
+	r.Mu.Lock()
+	for _, d := range deferred {
+		if d.path == nil {
+			d.path = path
+		}
+		r.deferred = append(r.deferred, d)
+	}
+	r.Mu.Unlock()
+}
+
+// dropDeferred removes the deferred fragments and streamed items within path from the queue,
+// because the value at path resolved to null when a non-null field below it did, after they were
+// queued by its descendants.
+func (r *Request) dropDeferred(path *pathSegment) {
+	r.Mu.Lock()
+	kept := r.deferred[:0]
+	for _, d := range r.deferred {
+		if !d.path.within(path) {
+			kept = append(kept, d)
+		}
+	}
+	r.deferred = kept
+	r.Mu.Unlock()
+}
+
+func (r *Request) nextDeferred() *deferredFragment {
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+	if len(r.deferred) == 0 {
+		return nil
+	}
+	d := r.deferred[0]
+	r.deferred = r.deferred[1:]
+	return d
+}
+
+// execStreamedList resolves the first items of a list field with the @stream directive and
+// defers the other ones.
+func (r *Request) execStreamedList(ctx context.Context, f *fieldToExec, path *pathSegment, s *resolvable.Schema, resolver reflect.Value) {
+	if resolver.Kind() == reflect.Chan || resolver.Kind() == reflect.Interface {
+		// The items of channels and of lists read from maps are not known in advance, so all of
+		// them are sent at once.
+		r.execSelectionSet(ctx, f.sels, f.field.Type, path, s, resolver, f.out)
+		return
+	}
+
+	t, nonNull := unwrapNonNull(f.field.Type)
+	if !nonNull {
+		if resolver.IsNil() {
+			f.out.WriteString("null")
+			return
+		}
+		resolver = resolver.Elem()
+	}
+	typ := t.(*common.List)
+
+	initialCount := f.field.Stream.InitialCount
+	if l := resolver.Len(); initialCount > l {
+		initialCount = l
+	}
+	r.execList(ctx, f.sels, typ, path, s, resolver.Slice(0, initialCount), f.out)
+	if resolvedToNull(f.out) {
+		return
+	}
+
+	for i := initialCount; i < resolver.Len(); i++ {
+		f.streamed = append(f.streamed, &deferredFragment{
+			label:    f.field.Stream.Label,
+			sels:     f.sels,
+			itemType: typ.OfType,
+			resolver: resolver.Index(i),
+			path:     &pathSegment{parent: path, value: i},
+		})
+	}
+}
+
+// ExecuteDeferred executes the fragments and list items deferred by Execute one after the other,
+// including the ones deferred while doing so, and sends their results to the returned channel.
+// The channel is closed when everything is executed or the context is done.
+func (r *Request) ExecuteDeferred(ctx context.Context, s *resolvable.Schema) <-chan *Patch {
+	c := make(chan *Patch)
+	go func() {
+		defer close(c)
+		for {
+			d := r.nextDeferred()
+			if d == nil {
+				return
+			}
+
+			r.Mu.Lock()
+			numErrs := len(r.Errs)
+			r.Mu.Unlock()
+
+			var out bytes.Buffer
+			func() {
+				defer r.handlePanic(ctx)
+				if d.itemType != nil {
+					out.WriteByte('[')
+					r.execSelectionSet(ctx, d.sels, d.itemType, d.path, s, d.resolver, &out)
+					out.WriteByte(']')
+					return
+				}
+				r.execSelections(ctx, d.sels, d.path, s, d.resolver, &out, false)
+			}()
+
+			if err := ctx.Err(); err != nil {
+				return
+			}
+
+			r.Mu.Lock()
+			errs := r.Errs[numErrs:len(r.Errs):len(r.Errs)]
+			r.Mu.Unlock()
+
+			p := &Patch{
+				Label:  d.label,
+				Path:   d.path.toSlice(),
+				Errors: errs,
+			}
+			if p.Path == nil {
+				// The fragments deferred on the root object are merged into it at the empty path.
+				p.Path = []interface{}{}
+			}
+			if out.Len() != 0 {
+				if d.itemType != nil {
+					p.Items = out.Bytes()
+				} else {
+					p.Data = out.Bytes()
+				}
+			}
+			select {
+			case c <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
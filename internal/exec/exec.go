@@ -5,11 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/packer"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
@@ -21,8 +27,63 @@ import (
 type Request struct {
 	selected.Request
 	Limiter chan struct{}
-	Tracer  trace.Tracer
-	Logger  log.Logger
+	// GlobalLimiter is shared by all requests of a schema. It is nil when there is no global limit.
+	GlobalLimiter chan struct{}
+	Tracer        trace.Tracer
+	Logger        log.Logger
+	// CacheControl collects the cache control hints of the resolved fields. It is nil when cache
+	// control is disabled.
+	CacheControl *CacheControl
+	// NonFiniteFloatsAsNull serializes NaN and infinite floats as null instead of failing the field.
+	NonFiniteFloatsAsNull bool
+	// IncludeErrorLocations adds the location of the field in the query to resolver errors.
+	IncludeErrorLocations bool
+	// NonNullError creates the error for a field of a non-null type that resolved to null without
+	// an error of its resolver. The default error is used if it is nil or returns nil.
+	NonNullError func(path []interface{}, typeName, fieldName string) *errors.QueryError
+	// ResolverErrorMapper converts the errors returned by resolvers. The default conversion is used
+	// if it is nil or returns nil.
+	ResolverErrorMapper func(err error) *errors.QueryError
+	// Timing builds the timing tree of the request. It is nil when the tree is not collected.
+	Timing *Timing
+	// ActualCost sums the complexity of the resolved fields. It is nil when the cost is not
+	// tracked.
+	ActualCost *ActualCost
+
+	// ExecutionTimeout limits the time that Execute waits for resolvers. Fields that are not
+	// resolved when it expires resolve to null with an error, while the fields that were resolved
+	// in time are returned. It is 0 when there is no timeout.
+	ExecutionTimeout time.Duration
+
+	// BatchScheduler coalesces the asynchronous fields of the elements of lists. It is nil when the
+	// fields are executed independently.
+	BatchScheduler BatchScheduler
+
+	// Plan is bound to the request instead of applying the operation, if it is set.
+	Plan *selected.Plan
+
+	// deadline is the time at which the execution timeout expires, if it is set.
+	deadline time.Time
+
+	// deferred holds the deferred fragments that are waiting to be executed by ExecuteDeferred.
+	deferred []*deferredFragment
+}
+
+// acquire blocks until a slot of the request limiter and, if set, of the global limiter is free.
+// The request slot is always taken first, so a request never occupies a global slot while waiting
+// for one of its own.
+func (r *Request) acquire() {
+	r.Limiter <- struct{}{}
+	if r.GlobalLimiter != nil {
+		r.GlobalLimiter <- struct{}{}
+	}
+}
+
+func (r *Request) release() {
+	if r.GlobalLimiter != nil {
+		<-r.GlobalLimiter
+	}
+	<-r.Limiter
 }
 
 func (r *Request) handlePanic(ctx context.Context) {
@@ -42,9 +103,21 @@ func makePanicError(value interface{}) *errors.QueryError {
 
 func (r *Request) Execute(ctx context.Context, s *resolvable.Schema, op *query.Operation) ([]byte, []*errors.QueryError) {
 	var out bytes.Buffer
+	if r.Timing != nil {
+		r.Timing.begin()
+		defer r.Timing.end()
+	}
+	if r.ExecutionTimeout > 0 {
+		r.deadline = time.Now().Add(r.ExecutionTimeout)
+	}
 	func() {
 		defer r.handlePanic(ctx)
-		sels := selected.ApplyOperation(&r.Request, s, op)
+		var sels []selected.Selection
+		if r.Plan != nil {
+			sels = selected.Bind(&r.Request, s, r.Plan)
+		} else {
+			sels = selected.ApplyOperation(&r.Request, s, op)
+		}
 		r.execSelections(ctx, sels, nil, s, s.Resolver, &out, op.Type == query.Mutation)
 	}()
 
@@ -60,6 +133,12 @@ type fieldToExec struct {
 	sels     []selected.Selection
 	resolver reflect.Value
 	out      *bytes.Buffer
+	// streamed holds the list items that are deferred by the @stream directive of the field.
+	streamed []*deferredFragment
+	// acquired is set if the limiter slot for the resolver was taken when the field was dispatched.
+	acquired bool
+	// timing is the node of the field in the timing tree, if it is collected.
+	timing *TimingNode
 }
 
 func resolvedToNull(b *bytes.Buffer) bool {
@@ -68,26 +147,55 @@ func resolvedToNull(b *bytes.Buffer) bool {
 
 func (r *Request) execSelections(ctx context.Context, sels []selected.Selection, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer, serially bool) {
 	async := !serially && selected.HasAsyncSel(sels)
+	element := batchElementOf(ctx)
+	if element != nil {
+		// The group of the list only applies to the fields of its elements, not to nested ones.
+		ctx = withBatchGroup(ctx, nil, 0)
+	}
 
 	var fields []*fieldToExec
-	collectFieldsToResolve(sels, s, resolver, &fields, make(map[string]*fieldToExec))
+	var deferred []*deferredFragment
+	collectFieldsToResolve(sels, s, resolver, &fields, make(map[string]*fieldToExec), &deferred)
+	if r.Timing != nil {
+		r.Timing.addFields(path, fields)
+	}
 
-	if async {
+	if async && element != nil {
+		r.execBatched(ctx, element, fields, path, s)
+	} else if async {
 		var wg sync.WaitGroup
-		wg.Add(len(fields))
-		for _, f := range fields {
+		dispatch, prioritized := byPriority(fields)
+		for i, f := range dispatch {
+			if prioritized {
+				// Take the slots in the order of dispatch, so the fields with the highest priority
+				// are resolved first when the limiter is saturated.
+				r.acquire()
+				f.acquired = true
+			}
+			if ctx.Err() != nil {
+				if f.acquired {
+					r.release()
+				}
+				r.skipFields(ctx, dispatch[i:], path)
+				break
+			}
+			wg.Add(1)
 			go func(f *fieldToExec) {
 				defer wg.Done()
 				defer r.handlePanic(ctx)
 				f.out = new(bytes.Buffer)
-				execFieldSelection(ctx, r, s, f, &pathSegment{path, f.field.Alias}, true)
+				execFieldSelection(ctx, r, s, f, fieldSegment(path, f.field), true)
 			}(f)
 		}
 		wg.Wait()
 	} else {
-		for _, f := range fields {
+		for i, f := range fields {
+			if ctx.Err() != nil {
+				r.skipFields(ctx, fields[i:], path)
+				break
+			}
 			f.out = new(bytes.Buffer)
-			execFieldSelection(ctx, r, s, f, &pathSegment{path, f.field.Alias}, true)
+			execFieldSelection(ctx, r, s, f, fieldSegment(path, f.field), true)
 		}
 	}
 
@@ -99,6 +207,7 @@ func (r *Request) execSelections(ctx context.Context, sels []selected.Selection,
 		if _, ok := f.field.Type.(*common.NonNull); ok && resolvedToNull(f.out) {
 			out.Reset()
 			out.Write([]byte("null"))
+			r.dropDeferred(path)
 			return
 		}
 
@@ -110,11 +219,50 @@ func (r *Request) execSelections(ctx context.Context, sels []selected.Selection,
 		out.WriteByte('"')
 		out.WriteByte(':')
 		out.Write(f.out.Bytes())
+		deferred = append(deferred, f.streamed...)
 	}
 	out.WriteByte('}')
+
+	// Deferred fragments and streamed items of objects that resolved to null are never executed.
+	r.addDeferred(deferred, path)
+}
+
+// skipFields resolves the fields to null without calling their resolvers, because ctx is done and
+// the response is discarded anyway. Resolvers that are already running observe the cancellation
+// through their own context.
+func (r *Request) skipFields(ctx context.Context, fields []*fieldToExec, path *pathSegment) {
+	err := errors.Errorf("%s", ctx.Err())
+	err.Path = path.toSlice()
+	r.AddError(err)
+	for _, f := range fields {
+		f.out = bytes.NewBufferString("null")
+	}
 }
 
-func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, resolver reflect.Value, fields *[]*fieldToExec, fieldByAlias map[string]*fieldToExec) {
+// byPriority returns the fields in the order in which they are dispatched: by descending priority,
+// and in the order of the query for equal priorities. It reports whether any field has a priority.
+func byPriority(fields []*fieldToExec) ([]*fieldToExec, bool) {
+	prioritized := false
+	for _, f := range fields {
+		if f.field.Priority != 0 {
+			prioritized = true
+			break
+		}
+	}
+	if !prioritized {
+		return fields, false
+	}
+	sorted := make([]*fieldToExec, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].field.Priority > sorted[j].field.Priority
+	})
+	return sorted, true
+}
+
+// collectFieldsToResolve collects the fields to resolve on the given resolver. Deferred fragments
+// are added to deferred, or their fields are collected as well if deferred is nil.
+func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, resolver reflect.Value, fields *[]*fieldToExec, fieldByAlias map[string]*fieldToExec, deferred *[]*deferredFragment) {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
 		case *selected.SchemaField:
@@ -127,19 +275,42 @@ func collectFieldsToResolve(sels []selected.Selection, s *resolvable.Schema, res
 			field.sels = append(field.sels, sel.Sels...)
 
 		case *selected.TypenameField:
+			// Like other fields, __typename is written once, at the position where its alias is
+			// first selected, so the keys of the response follow the order of the query.
+			if _, ok := fieldByAlias[sel.Alias]; ok {
+				continue
+			}
 			sf := &selected.SchemaField{
 				Field:       s.Meta.FieldTypename,
 				Alias:       sel.Alias,
 				FixedResult: reflect.ValueOf(typeOf(sel, resolver)),
 			}
-			*fields = append(*fields, &fieldToExec{field: sf, resolver: resolver})
+			field := &fieldToExec{field: sf, resolver: resolver}
+			fieldByAlias[sel.Alias] = field
+			*fields = append(*fields, field)
 
 		case *selected.TypeAssertion:
+			if sel.MethodIndex == -1 {
+				// members of the _Entity union of a federated schema carry their type name
+				e := resolver.Interface().(*resolvable.Entity)
+				if e.TypeName != sel.TypeExec.(*resolvable.Object).Name {
+					continue
+				}
+				collectFieldsToResolve(sel.Sels, s, e.Value, fields, fieldByAlias, deferred)
+				continue
+			}
 			out := resolver.Method(sel.MethodIndex).Call(nil)
 			if !out[1].Bool() {
 				continue
 			}
-			collectFieldsToResolve(sel.Sels, s, out[0], fields, fieldByAlias)
+			collectFieldsToResolve(sel.Sels, s, out[0], fields, fieldByAlias, deferred)
+
+		case *selected.DeferredFragment:
+			if deferred == nil {
+				collectFieldsToResolve(sel.Sels, s, resolver, fields, fieldByAlias, deferred)
+				continue
+			}
+			*deferred = append(*deferred, &deferredFragment{label: sel.Label, sels: sel.Sels, resolver: resolver})
 
 		default:
 			panic("unreachable")
@@ -152,6 +323,9 @@ func typeOf(tf *selected.TypenameField, resolver reflect.Value) string {
 		return tf.Name
 	}
 	for name, a := range tf.TypeAssertions {
+		if a.MethodIndex == -1 {
+			return resolver.Interface().(*resolvable.Entity).TypeName
+		}
 		out := resolver.Method(a.MethodIndex).Call(nil)
 		if out[1].Bool() {
 			return name
@@ -160,18 +334,82 @@ func typeOf(tf *selected.TypenameField, resolver reflect.Value) string {
 	return ""
 }
 
+// callWithTimeout calls the resolver method in its own goroutine and returns early with timedOut
+// set if ctx is done before the method returns. The method keeps running in the background then, so
+// it should return once its context is done. If returned is not nil, it is called by the goroutine
+// once the method returns, also after a timeout. A panic of the method is raised again by the
+// caller.
+func callWithTimeout(ctx context.Context, method reflect.Value, in []reflect.Value, returned func()) (out []reflect.Value, timedOut bool) {
+	type callResult struct {
+		out        []reflect.Value
+		panicValue interface{}
+	}
+	done := make(chan *callResult, 1)
+	go func() {
+		res := &callResult{}
+		defer func() {
+			res.panicValue = recover()
+			if returned != nil {
+				returned()
+			}
+			done <- res
+		}()
+		res.out = method.Call(in)
+	}()
+
+	select {
+	case res := <-done:
+		if res.panicValue != nil {
+			panic(res.panicValue)
+		}
+		return res.out, false
+	case <-ctx.Done():
+		return nil, true
+	}
+}
+
+// expired reports whether the execution timeout of the request has expired.
+func (r *Request) expired() bool {
+	return !r.deadline.IsZero() && !time.Now().Before(r.deadline)
+}
+
+func (r *Request) executionTimeoutError(path *pathSegment, f *selected.SchemaField) *errors.QueryError {
+	err := errors.Errorf("field %q was not resolved within the execution timeout of %s", f.Name, r.ExecutionTimeout)
+	err.Path = path.toSlice()
+	r.addLocation(err, f)
+	return err
+}
+
 func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f *fieldToExec, path *pathSegment, applyLimiter bool) {
-	if applyLimiter {
-		r.Limiter <- struct{}{}
+	if applyLimiter && !f.acquired {
+		r.acquire()
 	}
 
 	var result reflect.Value
 	var err *errors.QueryError
+	// releasedOnReturn is set if the limiter slot is released by the resolver call.
+	var releasedOnReturn bool
 
-	traceCtx, finish := r.Tracer.TraceField(ctx, f.field.TraceLabel, f.field.TypeName, f.field.Name, !f.field.Async, f.field.Args)
+	if r.CacheControl != nil {
+		r.CacheControl.addFieldHint(&f.field.Field.Field, path.parent == nil)
+	}
+	if r.ActualCost != nil {
+		r.ActualCost.addField(s, &f.field.Field)
+	}
+
+	var traceCtx context.Context
+	var finish trace.TraceFieldFinishFunc
+	if rt, ok := r.Tracer.(trace.ResolverTracer); ok {
+		traceCtx, finish = rt.TraceResolver(ctx, path.toSlice(), f.field.TypeName, f.field.Name, f.field.Type.String(), !f.field.Async, f.field.Args)
+	} else {
+		traceCtx, finish = r.Tracer.TraceField(ctx, f.field.TraceLabel, f.field.TypeName, f.field.Name, !f.field.Async, f.field.Args)
+	}
 	defer func() {
 		finish(err)
 	}()
+	if f.timing != nil {
+		defer r.Timing.startField(f.timing, path)()
+	}
 
 	err = func() (err *errors.QueryError) {
 		defer func() {
@@ -179,9 +417,17 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 				r.Logger.LogPanic(ctx, panicValue)
 				err = makePanicError(panicValue)
 				err.Path = path.toSlice()
+				r.addLocation(err, f.field)
 			}
 		}()
 
+		if f.field.Unauthorized != nil {
+			err := *f.field.Unauthorized
+			err.Path = path.toSlice()
+			r.addLocation(&err, f.field)
+			return &err
+		}
+
 		if f.field.FixedResult.IsValid() {
 			result = f.field.FixedResult
 			return nil
@@ -190,26 +436,103 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 		if err := traceCtx.Err(); err != nil {
 			return errors.Errorf("%s", err) // don't execute any more resolvers if context got cancelled
 		}
+		if r.expired() {
+			return r.executionTimeoutError(path, f.field)
+		}
 
 		res := f.resolver
-		if f.field.UseMethodResolver() {
+		if f.field.FromMap {
+			result = res.MapIndex(reflect.ValueOf(f.field.Name))
+			if !result.IsValid() {
+				result = reflect.Zero(res.Type().Elem())
+			}
+		} else if f.field.Resolve != nil {
+			var resolverErr error
+			result, resolverErr = f.field.Resolve(traceCtx, res, f.field.Args)
+			if resolverErr != nil {
+				return r.resolverError(resolverErr, path, f.field)
+			}
+		} else if f.field.UseMethodResolver() {
+			ctx := traceCtx
+			if f.field.Timeout > 0 {
+				// The timeout of the field is clamped to the deadline of the request.
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, f.field.Timeout)
+				defer cancel()
+			}
+			if !r.deadline.IsZero() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, r.deadline)
+				defer cancel()
+			}
 			var in []reflect.Value
 			if f.field.HasContext {
-				in = append(in, reflect.ValueOf(traceCtx))
+				in = append(in, reflect.ValueOf(withFieldInfo(ctx, f.field, path)))
 			}
 			if f.field.ArgsPacker != nil {
-				in = append(in, f.field.PackedArgs)
+				in = append(in, packer.DeepCopy(f.field.PackedArgs))
+			}
+			if f.field.HasSelectedFields {
+				in = append(in, reflect.ValueOf(selected.Fields(f.sels)))
+			}
+			var callOut []reflect.Value
+			if f.field.Timeout > 0 || !r.deadline.IsZero() {
+				var returned func()
+				if applyLimiter {
+					// The slot is held until the method returns, even if it is abandoned, so that
+					// the resolvers that keep running after a timeout count against the limits.
+					returned = r.release
+					releasedOnReturn = true
+				}
+				var timedOut bool
+				if callOut, timedOut = callWithTimeout(ctx, res.Method(f.field.MethodIndex), in, returned); timedOut {
+					if r.expired() && traceCtx.Err() == nil {
+						return r.executionTimeoutError(path, f.field)
+					}
+					err := errors.Errorf("field %q timed out after %s", f.field.Name, f.field.Timeout)
+					if ctxErr := traceCtx.Err(); ctxErr != nil {
+						err = errors.Errorf("%s", ctxErr)
+					}
+					err.Path = path.toSlice()
+					r.addLocation(err, f.field)
+					return err
+				}
+			} else {
+				callOut = res.Method(f.field.MethodIndex).Call(in)
 			}
-			callOut := res.Method(f.field.MethodIndex).Call(in)
 			result = callOut[0]
 			if f.field.HasError && !callOut[1].IsNil() {
+				if f.field.HasPartialError {
+					// A *errors.QueryError does not fail the field.
+					err := *callOut[1].Interface().(*errors.QueryError)
+					if err.Path == nil {
+						err.Path = path.toSlice()
+					}
+					if err.Locations == nil {
+						r.addLocation(&err, f.field)
+					}
+					r.AddError(&err)
+					return nil
+				}
 				resolverErr := callOut[1].Interface().(error)
-				err := errors.Errorf("%s", resolverErr)
-				err.Path = path.toSlice()
-				err.ResolverError = resolverErr
-				if ex, ok := callOut[1].Interface().(extensionser); ok {
+				err := r.resolverError(resolverErr, path, f.field)
+				if ex, ok := resolverErr.(extensionser); ok && err.Extensions == nil {
 					err.Extensions = ex.Extensions()
 				}
+				if pe, ok := resolverErr.(errors.PartialError); ok && pe.IsPartial() {
+					if data := pe.Data(); data != nil {
+						v := reflect.ValueOf(data)
+						if !v.Type().AssignableTo(result.Type()) {
+							err := errors.Errorf("graphql: partial data of type %s can not be used as %s", v.Type(), result.Type())
+							err.Path = path.toSlice()
+							return err
+						}
+						result = reflect.New(result.Type()).Elem()
+						result.Set(v)
+					}
+					r.AddError(err)
+					return nil
+				}
 				return err
 			}
 		} else {
@@ -222,8 +545,8 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 		return nil
 	}()
 
-	if applyLimiter {
-		<-r.Limiter
+	if applyLimiter && !releasedOnReturn {
+		r.release()
 	}
 
 	if err != nil {
@@ -234,22 +557,68 @@ func execFieldSelection(ctx context.Context, r *Request, s *resolvable.Schema, f
 		return
 	}
 
+	if f.field.Stream != nil {
+		r.execStreamedList(traceCtx, f, path, s, result)
+		return
+	}
 	r.execSelectionSet(traceCtx, f.sels, f.field.Type, path, s, result, f.out)
 }
 
+// resolverError converts an error returned by the resolver of f with ResolverErrorMapper, if set,
+// or into an error with the message of err otherwise. The path and location of the field are added
+// unless the mapper set them.
+func (r *Request) resolverError(resolverErr error, path *pathSegment, f *selected.SchemaField) *errors.QueryError {
+	var err *errors.QueryError
+	if r.ResolverErrorMapper != nil {
+		if mapped := r.ResolverErrorMapper(resolverErr); mapped != nil {
+			// The mapper may return the same error for several fields.
+			e := *mapped
+			err = &e
+		}
+	}
+	if err == nil {
+		err = errors.Errorf("%s", resolverErr)
+	}
+	if err.ResolverError == nil {
+		err.ResolverError = resolverErr
+	}
+	if path != nil && err.Path == nil {
+		err.Path = path.toSlice()
+	}
+	if err.Locations == nil {
+		r.addLocation(err, f)
+	}
+	return err
+}
+
+// addLocation sets the location of the field in the query on err, if enabled.
+func (r *Request) addLocation(err *errors.QueryError, f *selected.SchemaField) {
+	if r.IncludeErrorLocations {
+		err.Locations = []errors.Location{f.Loc}
+	}
+}
+
 func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selection, typ common.Type, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer) {
 	t, nonNull := unwrapNonNull(typ)
+	if resolver.Kind() == reflect.Interface && resolver.Type().NumMethod() == 0 {
+		r.execDynamicValue(ctx, sels, t, nonNull, path, s, resolver, out)
+		return
+	}
+
 	switch t := t.(type) {
 	case *schema.Object, *schema.Interface, *schema.Union:
+		// a resolver returning a pointer to an interface is resolved through the interface
+		if resolver.Kind() == reflect.Ptr && resolver.Type().Elem().Kind() == reflect.Interface && !resolver.IsNil() {
+			resolver = resolver.Elem()
+		}
+
 		// a reflect.Value of a nil interface will show up as an Invalid value
-		if resolver.Kind() == reflect.Invalid || ((resolver.Kind() == reflect.Ptr || resolver.Kind() == reflect.Interface) && resolver.IsNil()) {
+		if resolver.Kind() == reflect.Invalid || ((resolver.Kind() == reflect.Ptr || resolver.Kind() == reflect.Interface || resolver.Kind() == reflect.Map) && resolver.IsNil()) {
 			// If a field of a non-null type resolves to null (either because the
 			// function to resolve the field returned null or because an error occurred),
 			// add an error to the "errors" list in the response.
 			if nonNull {
-				err := errors.Errorf("graphql: got nil for non-null %q", t)
-				err.Path = path.toSlice()
-				r.AddError(err)
+				r.AddError(r.nonNullError(t, path))
 			}
 			out.WriteString("null")
 			return
@@ -259,7 +628,18 @@ func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selectio
 		return
 	}
 
-	if !nonNull {
+	if resolver.Kind() == reflect.Chan {
+		// Lists can be resolved by channels, which are not wrapped in a pointer when nullable.
+		if resolver.IsNil() {
+			if nonNull {
+				r.AddError(r.nonNullError(t, path))
+			}
+			out.WriteString("null")
+			return
+		}
+	} else if !nonNull && resolver.Kind() == reflect.Ptr {
+		// Nullable types such as graphql.NullID are not wrapped in a pointer and marshal null
+		// themselves.
 		if resolver.IsNil() {
 			out.WriteString("null")
 			return
@@ -273,6 +653,64 @@ func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selectio
 
 	case *schema.Scalar:
 		v := resolver.Interface()
+		if t.Coercion != nil {
+			coerced, err := t.Coercion.CoerceOutput(v)
+			if err != nil {
+				qErr := errors.Errorf("could not coerce %v into %s: %s", v, t, err)
+				qErr.Path = path.toSlice()
+				r.AddError(qErr)
+				out.WriteString("null")
+				return
+			}
+			v = coerced
+			resolver = reflect.ValueOf(coerced)
+			if !resolver.IsValid() {
+				out.WriteString("null")
+				return
+			}
+		}
+		if f, ok := nonFiniteFloat(v); ok {
+			// JSON can not represent NaN and infinite floats, and neither can GraphQL.
+			var err *errors.QueryError
+			switch {
+			case !r.NonFiniteFloatsAsNull:
+				err = errors.Errorf("graphql: %v is not a valid value for %q", f, t)
+			case nonNull:
+				err = errors.Errorf("graphql: got %v for non-null %q", f, t)
+			}
+			if err != nil {
+				err.Path = path.toSlice()
+				r.AddError(err)
+			}
+			out.WriteString("null")
+			return
+		}
+		if w, ok := v.(jsonWriter); ok {
+			start := out.Len()
+			if err := w.WriteJSON(out); err != nil {
+				out.Truncate(start)
+				qErr := errors.Errorf("could not marshal %s: %s", t, err)
+				qErr.Path = path.toSlice()
+				r.AddError(qErr)
+				out.WriteString("null")
+			}
+			return
+		}
+		if m, ok := jsonMarshaler(resolver); ok {
+			data, err := m.MarshalJSON()
+			if err == nil && !json.Valid(data) {
+				err = fmt.Errorf("invalid JSON %q", data)
+			}
+			if err != nil {
+				qErr := errors.Errorf("could not marshal %v: %s", v, err)
+				qErr.Path = path.toSlice()
+				r.AddError(qErr)
+				out.WriteString("null")
+				return
+			}
+			out.Write(data)
+			return
+		}
 		data, err := json.Marshal(v)
 		if err != nil {
 			panic(errors.Errorf("could not marshal %v: %s", v, err))
@@ -308,34 +746,288 @@ func (r *Request) execSelectionSet(ctx context.Context, sels []selected.Selectio
 	}
 }
 
+// jsonWriter is implemented by scalar values that write their JSON representation themselves, e.g.
+// to encode data while it is read instead of buffering it. It takes precedence over json.Marshaler.
+type jsonWriter interface {
+	WriteJSON(w io.Writer) error
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// jsonMarshaler returns the json.Marshaler implemented by v, also considering methods with a
+// pointer receiver when v itself is not a pointer.
+func jsonMarshaler(v reflect.Value) (json.Marshaler, bool) {
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		return m, true
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		return nil, false
+	}
+	// Only copy v to make it addressable if the pointer has the method.
+	if !reflect.PtrTo(v.Type()).Implements(marshalerType) {
+		return nil, false
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	m, ok := p.Interface().(json.Marshaler)
+	return m, ok
+}
+
+func nonFiniteFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	if k := rv.Kind(); k != reflect.Float32 && k != reflect.Float64 {
+		return 0, false
+	}
+	f := rv.Float()
+	return f, math.IsNaN(f) || math.IsInf(f, 0)
+}
+
+// execDynamicValue resolves a value of type interface{}, e.g. read from a map-backed object, by its
+// dynamic type: objects must be map[string]interface{}, lists must be slices and leaf values are
+// coerced to the type.
+func (r *Request) execDynamicValue(ctx context.Context, sels []selected.Selection, t common.Type, nonNull bool, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer) {
+	v := resolver.Elem()
+	if !v.IsValid() || (v.Kind() == reflect.Map && v.IsNil()) {
+		if nonNull {
+			r.AddError(r.nonNullError(t, path))
+		}
+		out.WriteString("null")
+		return
+	}
+
+	var err error
+	switch t := t.(type) {
+	case *schema.Object:
+		if v.Type() != reflect.TypeOf(map[string]interface{}(nil)) {
+			err = fmt.Errorf("expected map[string]interface{}, got %s", v.Type())
+			break
+		}
+		r.execSelections(ctx, sels, path, s, v, out, false)
+		return
+
+	case *common.List:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			err = fmt.Errorf("expected a slice, got %s", v.Type())
+			break
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).Interface()
+		}
+		r.execList(ctx, sels, t, path, s, reflect.ValueOf(items), out)
+		return
+
+	case *schema.Scalar:
+		var coerced interface{}
+		coerced, err = coerceDynamicScalar(t, v.Interface())
+		if err != nil {
+			break
+		}
+		r.execSelectionSet(ctx, sels, &common.NonNull{OfType: t}, path, s, reflect.ValueOf(coerced), out)
+		return
+
+	case *schema.Enum:
+		if v.Kind() != reflect.String {
+			err = fmt.Errorf("expected a string, got %s", v.Type())
+			break
+		}
+		r.execSelectionSet(ctx, sels, &common.NonNull{OfType: t}, path, s, v, out)
+		return
+	}
+
+	qErr := errors.Errorf("graphql: can not resolve %v as %q: %s", v.Interface(), t, err)
+	qErr.Path = path.toSlice()
+	r.AddError(qErr)
+	out.WriteString("null")
+}
+
+// coerceDynamicScalar converts a value of a map-backed object to the Go type of a built-in scalar.
+// Other scalars are passed through.
+func coerceDynamicScalar(t *schema.Scalar, v interface{}) (interface{}, error) {
+	if t.Coercion != nil {
+		return v, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch t.Name {
+	case "Int":
+		var i int64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt32 {
+				return nil, fmt.Errorf("not a 32-bit integer")
+			}
+			i = int64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if f != math.Trunc(f) || f < math.MinInt32 || f > math.MaxInt32 {
+				return nil, fmt.Errorf("not a 32-bit integer")
+			}
+			i = int64(f)
+		default:
+			return nil, fmt.Errorf("expected a number, got %T", v)
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("not a 32-bit integer")
+		}
+		return int32(i), nil
+	case "Float":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(rv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(rv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return rv.Float(), nil
+		}
+		return nil, fmt.Errorf("expected a number, got %T", v)
+	case "String":
+		if rv.Kind() != reflect.String {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return rv.String(), nil
+	case "Boolean":
+		if rv.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("expected a boolean, got %T", v)
+		}
+		return rv.Bool(), nil
+	case "ID":
+		switch rv.Kind() {
+		case reflect.String:
+			return rv.String(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(rv.Int(), 10), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(rv.Uint(), 10), nil
+		case reflect.Float32, reflect.Float64:
+			if f := rv.Float(); f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+				return strconv.FormatInt(int64(f), 10), nil
+			}
+		}
+		return nil, fmt.Errorf("expected a string or an integer, got %T", v)
+	}
+	return v, nil
+}
+
 func (r *Request) execList(ctx context.Context, sels []selected.Selection, typ *common.List, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer) {
+	ctx = withBatchGroup(ctx, nil, 0)
+	if resolver.Kind() == reflect.Chan {
+		r.execChanList(ctx, sels, typ, path, s, resolver, out)
+		return
+	}
+
 	l := resolver.Len()
-	entryouts := make([]bytes.Buffer, l)
+	entryouts := make([]*bytes.Buffer, l)
+	for i := range entryouts {
+		entryouts[i] = new(bytes.Buffer)
+	}
 
 	if selected.HasAsyncSel(sels) {
+		var group *batchGroup
+		if r.BatchScheduler != nil {
+			group = newBatchGroup(ctx, r, l)
+		}
 		var wg sync.WaitGroup
 		wg.Add(l)
 		for i := 0; i < l; i++ {
 			go func(i int) {
 				defer wg.Done()
 				defer r.handlePanic(ctx)
-				r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
+				elemCtx := ctx
+				if group != nil {
+					// Elements that have no fields to batch, e.g. because they are null, complete
+					// the group when they are done.
+					elemCtx = withBatchGroup(ctx, group, i)
+					defer group.arrive(i, nil)
+				}
+				r.execSelectionSet(elemCtx, sels, typ.OfType, &pathSegment{parent: path, value: i}, s, resolver.Index(i), entryouts[i])
 			}(i)
 		}
 		wg.Wait()
 	} else {
 		for i := 0; i < l; i++ {
-			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{path, i}, s, resolver.Index(i), &entryouts[i])
+			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{parent: path, value: i}, s, resolver.Index(i), entryouts[i])
 		}
 	}
 
+	writeList(typ, entryouts, out)
+	if resolvedToNull(out) {
+		r.dropDeferred(path)
+	}
+}
+
+// execChanList resolves a list from the items received from a channel until it is closed. Items
+// with asynchronous selections are executed concurrently, but no more of them than the request
+// may run in parallel, so that the channel is only read as fast as its items are executed. If the
+// context is done, the list resolves to null and the rest of the channel is drained.
+func (r *Request) execChanList(ctx context.Context, sels []selected.Selection, typ *common.List, path *pathSegment, s *resolvable.Schema, resolver reflect.Value, out *bytes.Buffer) {
+	var entryouts []*bytes.Buffer
+	var wg sync.WaitGroup
+	async := selected.HasAsyncSel(sels)
+	parallelism := cap(r.Limiter)
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	slots := make(chan struct{}, parallelism)
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: resolver},
+	}
+	for i := 0; ; i++ {
+		chosen, item, ok := reflect.Select(cases)
+		if chosen == 0 {
+			wg.Wait()
+			go func() {
+				for {
+					if _, ok := resolver.Recv(); !ok {
+						return
+					}
+				}
+			}()
+			err := errors.Errorf("%s", ctx.Err())
+			err.Path = path.toSlice()
+			r.AddError(err)
+			out.WriteString("null")
+			return
+		}
+		if !ok {
+			break
+		}
+
+		entryout := new(bytes.Buffer)
+		entryouts = append(entryouts, entryout)
+		if !async {
+			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{parent: path, value: i}, s, item, entryout)
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-slots }()
+			defer wg.Done()
+			defer r.handlePanic(ctx)
+			r.execSelectionSet(ctx, sels, typ.OfType, &pathSegment{parent: path, value: i}, s, item, entryout)
+		}(i)
+	}
+	wg.Wait()
+
+	writeList(typ, entryouts, out)
+	if resolvedToNull(out) {
+		r.dropDeferred(path)
+	}
+}
+
+// writeList writes the resolved items of a list.
+func writeList(typ *common.List, entryouts []*bytes.Buffer, out *bytes.Buffer) {
 	_, listOfNonNull := typ.OfType.(*common.NonNull)
 
 	out.WriteByte('[')
 	for i, entryout := range entryouts {
 		// If the list wraps a non-null type and one of the list elements
 		// resolves to null, then the entire list resolves to null.
-		if listOfNonNull && resolvedToNull(&entryout) {
+		if listOfNonNull && resolvedToNull(entryout) {
 			out.Reset()
 			out.WriteString("null")
 			return
@@ -359,6 +1051,50 @@ func unwrapNonNull(t common.Type) (common.Type, bool) {
 type pathSegment struct {
 	parent *pathSegment
 	value  interface{}
+	// field is the field that the segment is the response name of. It is nil for list indexes.
+	field *selected.SchemaField
+}
+
+// fieldSegment returns the path segment of the field f below parent.
+func fieldSegment(parent *pathSegment, f *selected.SchemaField) *pathSegment {
+	return &pathSegment{parent: parent, value: f.Alias, field: f}
+}
+
+// nonNullError returns the error for a value of the non-null type t at path that resolved to null,
+// as created by NonNullError if it is set.
+func (r *Request) nonNullError(t common.Type, path *pathSegment) *errors.QueryError {
+	if r.NonNullError != nil {
+		var typeName, fieldName string
+		for p := path; p != nil; p = p.parent {
+			if p.field != nil {
+				typeName, fieldName = p.field.TypeName, p.field.Name
+				break
+			}
+		}
+		if err := r.NonNullError(path.toSlice(), typeName, fieldName); err != nil {
+			if err.Path == nil {
+				err.Path = path.toSlice()
+			}
+			return err
+		}
+	}
+	err := errors.Errorf("graphql: got nil for non-null %q", t)
+	err.Path = path.toSlice()
+	return err
+}
+
+// within reports whether p is path or one of its descendants. Every path is within the root path,
+// which is nil.
+func (p *pathSegment) within(path *pathSegment) bool {
+	if path == nil {
+		return true
+	}
+	for ; p != nil; p = p.parent {
+		if p == path {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *pathSegment) toSlice() []interface{} {
@@ -0,0 +1,39 @@
+package exec
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+)
+
+// FieldInfo describes the field that a resolver is called for.
+type FieldInfo struct {
+	// Alias is the response name of the field, which is its name unless the query aliases it.
+	Alias string
+	// Path is the response path of the field, like the path of errors.
+	Path []interface{}
+	// Args holds the arguments of the field, with the variables of the query resolved.
+	Args map[string]interface{}
+	// ParentType is the name of the type that declares the field.
+	ParentType string
+	// Directives holds the directives applied to the field in the query.
+	Directives []selected.Directive
+}
+
+type fieldInfoKey struct{}
+
+func withFieldInfo(ctx context.Context, f *selected.SchemaField, path *pathSegment) context.Context {
+	return context.WithValue(ctx, fieldInfoKey{}, &FieldInfo{
+		Alias:      f.Alias,
+		Path:       path.toSlice(),
+		Args:       f.Args,
+		ParentType: f.TypeName,
+		Directives: f.Directives,
+	})
+}
+
+// FieldInfoFromContext returns the field that the resolver with the given context is called for.
+func FieldInfoFromContext(ctx context.Context) (*FieldInfo, bool) {
+	f, ok := ctx.Value(fieldInfoKey{}).(*FieldInfo)
+	return f, ok
+}
@@ -0,0 +1,251 @@
+package packer
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// plainType is the Go type that the values of a PlainPacker are packed into.
+var plainType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// PlainPacker packs input values that are not passed to a resolver, like the arguments of
+// directives, into plain values instead of the Go types of a resolver: int32, float64, string and
+// bool for the built-in scalars, string for IDs and enums, []interface{} for lists and
+// map[string]interface{} for input objects. Custom scalars are coerced by their ScalarCoercion, if
+// they have one, and passed as is otherwise. Like the arguments of fields, strings are limited to
+// the maximum length and input objects to the maximum depth of the Builder.
+type PlainPacker struct {
+	packer packer
+}
+
+// Pack packs the value, which is returned by common.Literal.Value or decoded from JSON variables.
+func (p *PlainPacker) Pack(value interface{}) (interface{}, error) {
+	v, err := p.packer.Pack(value)
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	return v.Interface(), nil
+}
+
+// MakePlainPacker returns the plain packer of the values of v, e.g. a variable. It can be used
+// after Finish.
+func (b *Builder) MakePlainPacker(v *common.InputValue) (*PlainPacker, error) {
+	p := &PlainPacker{}
+	if err := b.assignInputValuePacker(&p.packer, v, v.Type, plainType); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MakeArgsPacker returns the plain packer of the arguments of a directive. It packs a map of the
+// given arguments into a map in which the omitted arguments are set to their default value. It can
+// be used after Finish.
+func (b *Builder) MakeArgsPacker(args common.InputValueList) (*PlainPacker, error) {
+	p, err := b.makeMapPacker(args)
+	if err != nil {
+		return nil, err
+	}
+	p.maxDepth = b.MaxInputDepth
+	return &PlainPacker{packer: p}, nil
+}
+
+// assignInputValuePacker assigns the packer of the values of type t of the argument, input field
+// or variable v to target. Strings are limited to the maximum length of v.
+func (b *Builder) assignInputValuePacker(target *packer, v *common.InputValue, t common.Type, reflectType reflect.Type) error {
+	if max := b.maxLength(v); max > 0 && isScalar(t) {
+		p := &maxLengthPacker{name: v.Name.Name, max: max}
+		*target = p
+		target = &p.elem
+	}
+	return b.assignPacker(target, t, reflectType)
+}
+
+// makePlainPacker makes the packer of plain values of the given type, see PlainPacker.
+func (b *Builder) makePlainPacker(schemaType common.Type) (packer, error) {
+	t, nonNull := unwrapNonNull(schemaType)
+	elem, err := b.makeNonNullPlainPacker(t)
+	if err != nil {
+		return nil, err
+	}
+	if nonNull {
+		return elem, nil
+	}
+	return &nullPacker{elemPacker: elem, valueType: plainType}, nil
+}
+
+func (b *Builder) makeNonNullPlainPacker(schemaType common.Type) (packer, error) {
+	switch t := schemaType.(type) {
+	case *schema.Scalar:
+		if t.Coercion != nil {
+			return &coercionPacker{scalar: t, ValueType: plainType}, nil
+		}
+		var valueType reflect.Type
+		switch t.Name {
+		case "Int":
+			valueType = reflect.TypeOf(int32(0))
+		case "Float":
+			valueType = reflect.TypeOf(float64(0))
+		case "String":
+			valueType = reflect.TypeOf("")
+		case "Boolean":
+			valueType = reflect.TypeOf(false)
+		case "ID":
+			return &idPacker{}, nil
+		default:
+			return &anyPacker{}, nil
+		}
+		return &ValuePacker{ValueType: valueType, Strict: b.StrictCoercion}, nil
+
+	case *schema.Enum:
+		return &enumPacker{
+			enum: t,
+			values: ValuePacker{
+				ValueType: reflect.TypeOf(""),
+				Strict:    b.StrictCoercion,
+			},
+		}, nil
+
+	case *schema.InputObject:
+		p, err := b.makeMapPacker(t.Values)
+		if err != nil {
+			return nil, err
+		}
+		p.oneOf = t.IsOneOf()
+		return p, nil
+
+	case *common.List:
+		p := &listPacker{
+			sliceType: reflect.TypeOf([]interface{}{}),
+		}
+		if err := b.assignPacker(&p.elem, t.OfType, plainType); err != nil {
+			return nil, err
+		}
+		return p, nil
+
+	case *schema.Object, *schema.Interface, *schema.Union:
+		return nil, fmt.Errorf("type of kind %s can not be used as input", t.Kind())
+
+	default:
+		panic("unreachable")
+	}
+}
+
+// idPacker packs IDs into strings, like the ID type of the graphql package unmarshals them.
+type idPacker struct{}
+
+func (p *idPacker) Pack(value interface{}) (reflect.Value, error) {
+	switch value := value.(type) {
+	case nil:
+		return reflect.Value{}, errors.Errorf("got null for non-null")
+	case string:
+		return reflect.ValueOf(value), nil
+	case int32:
+		return reflect.ValueOf(strconv.Itoa(int(value))), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("wrong type for ID: %T", value)
+	}
+}
+
+// anyPacker passes the values of custom scalars without a coercion as they are, since only the
+// resolvers know the Go types to unmarshal them into.
+type anyPacker struct{}
+
+func (p *anyPacker) Pack(value interface{}) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Value{}, errors.Errorf("got null for non-null")
+	}
+	return reflect.ValueOf(value), nil
+}
+
+// mapPacker packs input objects, and the arguments of directives, into map[string]interface{}.
+type mapPacker struct {
+	values common.InputValueList
+	fields []packer
+	strict bool
+	// maxDepth limits the nesting of input objects in the packed value, if it is not zero.
+	maxDepth int
+	// oneOf is set for input objects with the @oneOf directive, whose values must have exactly one
+	// non-null field.
+	oneOf bool
+}
+
+func (b *Builder) makeMapPacker(values common.InputValueList) (*mapPacker, error) {
+	p := &mapPacker{
+		values: values,
+		fields: make([]packer, len(values)),
+		strict: b.StrictCoercion,
+	}
+	for i, v := range values {
+		if err := b.assignInputValuePacker(&p.fields[i], v, v.Type, plainType); err != nil {
+			return nil, fmt.Errorf("field %q: %s", v.Name.Name, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *mapPacker) Pack(value interface{}) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Value{}, errors.Errorf("got null for non-null")
+	}
+
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("expected an input object, got %T", value)
+	}
+	if p.maxDepth > 0 && nestedDeeper(values, p.values, 0, p.maxDepth) {
+		return reflect.Value{}, fmt.Errorf("input objects are nested deeper than the maximum depth of %d", p.maxDepth)
+	}
+	if p.strict {
+		for name := range values {
+			if p.values.Get(name) == nil {
+				return reflect.Value{}, fmt.Errorf("unknown field %q: %s", name, ErrStrictCoercion)
+			}
+		}
+	}
+	if p.oneOf {
+		given := 0
+		for _, value := range values {
+			if value != nil {
+				given++
+			}
+		}
+		if given != 1 || len(values) != 1 {
+			return reflect.Value{}, errors.Errorf("exactly one field of a oneOf input object must be given with a non-null value")
+		}
+	}
+
+	packed := make(map[string]interface{}, len(p.values))
+	for i, v := range p.values {
+		value, ok := values[v.Name.Name]
+		if !ok {
+			if v.Default == nil {
+				if _, nonNull := v.Type.(*common.NonNull); nonNull {
+					return reflect.Value{}, fmt.Errorf("got null for non-null %q", v.Name.Name)
+				}
+				continue
+			}
+			value = v.Default.Value(nil)
+		}
+		if value == nil {
+			if _, nonNull := v.Type.(*common.NonNull); nonNull {
+				return reflect.Value{}, fmt.Errorf("got null for non-null %q", v.Name.Name)
+			}
+			packed[v.Name.Name] = nil
+			continue
+		}
+		fieldValue, err := p.fields[i].Pack(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		packed[v.Name.Name] = fieldValue.Interface()
+	}
+	return reflect.ValueOf(packed), nil
+}
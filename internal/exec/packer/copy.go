@@ -0,0 +1,101 @@
+package packer
+
+import (
+	"reflect"
+	"sync"
+)
+
+// DeepCopy returns a copy of v that shares no slices, maps or pointers with v, so that resolvers
+// may mutate their arguments without affecting other calls that were packed from the same values.
+// Values stored in interfaces and unexported struct fields are copied shallowly.
+func DeepCopy(v reflect.Value) reflect.Value {
+	if !v.IsValid() || !hasReferences(v.Type()) {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(DeepCopy(v.Elem()))
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(DeepCopy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(DeepCopy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), DeepCopy(iter.Value()))
+		}
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := c.Field(i); f.CanSet() {
+				f.Set(DeepCopy(v.Field(i)))
+			}
+		}
+		return c
+
+	default:
+		return v
+	}
+}
+
+var referenceTypes sync.Map // map[reflect.Type]bool
+
+// hasReferences reports whether values of type t may share memory through exported fields.
+func hasReferences(t reflect.Type) bool {
+	if has, ok := referenceTypes.Load(t); ok {
+		return has.(bool)
+	}
+	has := hasReferencesImpl(t, make(map[reflect.Type]bool))
+	referenceTypes.Store(t, has)
+	return has
+}
+
+func hasReferencesImpl(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Array:
+		return hasReferencesImpl(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" && hasReferencesImpl(f.Type, visited) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
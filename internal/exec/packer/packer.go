@@ -16,10 +16,25 @@ type packer interface {
 }
 
 type Builder struct {
+	// StrictCoercion rejects lossy coercions of input values, see ErrStrictCoercion.
+	StrictCoercion bool
+	// MaxStringLength is the default maximum length in bytes of string values passed to scalar
+	// arguments and input fields. It can be overridden per argument with @maxLength(max: Int!).
+	// Zero means no limit.
+	MaxStringLength int
+	// MaxInputDepth is the maximum nesting depth of input objects in the arguments of a field,
+	// where an input object passed as an argument has a depth of 1. Zero means no limit.
+	MaxInputDepth int
+
 	packerMap     map[typePair]*packerMapEntry
 	structPackers []*StructPacker
 }
 
+// ErrStrictCoercion is returned when an input value could only be coerced leniently, e.g. an Int
+// into a Float, a non-string value into a string type or an input object with unknown fields, but
+// strict coercion is enabled.
+var ErrStrictCoercion = fmt.Errorf("lenient coercion is not permitted in strict mode")
+
 type typePair struct {
 	graphQLType  common.Type
 	resolverType reflect.Type
@@ -76,10 +91,17 @@ func (b *Builder) assignPacker(target *packer, schemaType common.Type, reflectTy
 }
 
 func (b *Builder) makePacker(schemaType common.Type, reflectType reflect.Type) (packer, error) {
+	if reflectType == plainType {
+		return b.makePlainPacker(schemaType)
+	}
+
 	t, nonNull := unwrapNonNull(schemaType)
 	if !nonNull {
+		if IsNullable(reflectType) {
+			return b.makeNullablePacker(t, reflectType)
+		}
 		if reflectType.Kind() != reflect.Ptr {
-			return nil, fmt.Errorf("%s is not a pointer", reflectType)
+			return nil, fmt.Errorf("%s is not a pointer or a nullable type", reflectType)
 		}
 		elemType := reflectType.Elem()
 		addPtr := true
@@ -101,35 +123,71 @@ func (b *Builder) makePacker(schemaType common.Type, reflectType reflect.Type) (
 	return b.makeNonNullPacker(t, reflectType)
 }
 
+// makeNullablePacker makes the packer of a Nullable type, which is unmarshaled from null as well.
+func (b *Builder) makeNullablePacker(schemaType common.Type, reflectType reflect.Type) (packer, error) {
+	u, ok := reflect.New(reflectType).Interface().(Unmarshaler)
+	if !ok {
+		return nil, fmt.Errorf("nullable type %s does not implement UnmarshalGraphQL", reflectType)
+	}
+	if !u.ImplementsGraphQLType(schemaType.String()) {
+		return nil, fmt.Errorf("can not unmarshal %s into %s", schemaType, reflectType)
+	}
+	p := &unmarshalerPacker{
+		ValueType: reflectType,
+		nullable:  true,
+	}
+	if t, ok := schemaType.(*schema.InputObject); ok {
+		p.inputObject = t
+	}
+	return p, nil
+}
+
 func (b *Builder) makeNonNullPacker(schemaType common.Type, reflectType reflect.Type) (packer, error) {
+	if t, ok := schemaType.(*schema.Scalar); ok && t.Coercion != nil {
+		return &coercionPacker{
+			scalar:    t,
+			ValueType: reflectType,
+		}, nil
+	}
+
 	if u, ok := reflect.New(reflectType).Interface().(Unmarshaler); ok {
 		if !u.ImplementsGraphQLType(schemaType.String()) {
 			return nil, fmt.Errorf("can not unmarshal %s into %s", schemaType, reflectType)
 		}
-		return &unmarshalerPacker{
+		p := &unmarshalerPacker{
 			ValueType: reflectType,
-		}, nil
+		}
+		if t, ok := schemaType.(*schema.InputObject); ok {
+			p.inputObject = t
+		}
+		return p, nil
 	}
 
 	switch t := schemaType.(type) {
 	case *schema.Scalar:
 		return &ValuePacker{
 			ValueType: reflectType,
+			Strict:    b.StrictCoercion,
 		}, nil
 
 	case *schema.Enum:
 		if reflectType.Kind() != reflect.String {
 			return nil, fmt.Errorf("wrong type, expected %s", reflect.String)
 		}
-		return &ValuePacker{
-			ValueType: reflectType,
+		return &enumPacker{
+			enum: t,
+			values: ValuePacker{
+				ValueType: reflectType,
+				Strict:    b.StrictCoercion,
+			},
 		}, nil
 
 	case *schema.InputObject:
-		e, err := b.MakeStructPacker(t.Values, reflectType)
+		e, err := b.makeStructPacker(t.Values, reflectType)
 		if err != nil {
 			return nil, err
 		}
+		e.oneOf = t.IsOneOf()
 		return e, nil
 
 	case *common.List:
@@ -152,7 +210,18 @@ func (b *Builder) makeNonNullPacker(schemaType common.Type, reflectType reflect.
 	}
 }
 
+// MakeStructPacker returns the packer for the arguments of a field, which rejects arguments that
+// nest input objects deeper than MaxInputDepth.
 func (b *Builder) MakeStructPacker(values common.InputValueList, typ reflect.Type) (*StructPacker, error) {
+	p, err := b.makeStructPacker(values, typ)
+	if err != nil {
+		return nil, err
+	}
+	p.maxDepth = b.MaxInputDepth
+	return p, nil
+}
+
+func (b *Builder) makeStructPacker(values common.InputValueList, typ reflect.Type) (*StructPacker, error) {
 	structType := typ
 	usePtr := false
 	if typ.Kind() == reflect.Ptr {
@@ -185,7 +254,7 @@ func (b *Builder) MakeStructPacker(values common.InputValueList, typ reflect.Typ
 			ft = &common.NonNull{OfType: ft}
 		}
 
-		if err := b.assignPacker(&fe.fieldPacker, ft, sf.Type); err != nil {
+		if err := b.assignInputValuePacker(&fe.fieldPacker, v, ft, sf.Type); err != nil {
 			return nil, fmt.Errorf("field %q: %s", sf.Name, err)
 		}
 
@@ -195,6 +264,7 @@ func (b *Builder) MakeStructPacker(values common.InputValueList, typ reflect.Typ
 	p := &StructPacker{
 		structType: structType,
 		usePtr:     usePtr,
+		strict:     b.StrictCoercion,
 		fields:     fields,
 	}
 	b.structPackers = append(b.structPackers, p)
@@ -204,8 +274,14 @@ func (b *Builder) MakeStructPacker(values common.InputValueList, typ reflect.Typ
 type StructPacker struct {
 	structType    reflect.Type
 	usePtr        bool
+	strict        bool
 	defaultStruct reflect.Value
 	fields        []*structPackerField
+	// maxDepth limits the nesting of input objects in the packed value, if it is not zero.
+	maxDepth int
+	// oneOf is set for input objects with the @oneOf directive, whose values must have exactly one
+	// non-null field.
+	oneOf bool
 }
 
 type structPackerField struct {
@@ -220,8 +296,29 @@ func (p *StructPacker) Pack(value interface{}) (reflect.Value, error) {
 	}
 
 	values := value.(map[string]interface{})
+	if p.maxDepth > 0 && p.nestedDeeper(values) {
+		return reflect.Value{}, fmt.Errorf("input objects are nested deeper than the maximum depth of %d", p.maxDepth)
+	}
+	if p.strict {
+		for name := range values {
+			if !p.hasField(name) {
+				return reflect.Value{}, fmt.Errorf("unknown field %q: %s", name, ErrStrictCoercion)
+			}
+		}
+	}
+	if p.oneOf {
+		given := 0
+		for _, value := range values {
+			if value != nil {
+				given++
+			}
+		}
+		if given != 1 || len(values) != 1 {
+			return reflect.Value{}, errors.Errorf("exactly one field of a oneOf input object must be given with a non-null value")
+		}
+	}
 	v := reflect.New(p.structType)
-	v.Elem().Set(p.defaultStruct)
+	v.Elem().Set(DeepCopy(p.defaultStruct))
 	for _, f := range p.fields {
 		if value, ok := values[f.field.Name.Name]; ok {
 			packed, err := f.fieldPacker.Pack(value)
@@ -237,6 +334,123 @@ func (p *StructPacker) Pack(value interface{}) (reflect.Value, error) {
 	return v, nil
 }
 
+// nestedDeeper reports whether the values of the given input fields, at the given depth, contain
+// input objects nested deeper than max. Only the values of input object types are followed, so
+// objects in the values of custom scalars, e.g. JSON, do not count. It stops at the first object
+// that is too deep, so its recursion is bounded by max.
+func nestedDeeper(values map[string]interface{}, fields common.InputValueList, depth, max int) bool {
+	for _, f := range fields {
+		if valueNestedDeeper(values[f.Name.Name], f.Type, depth, max) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueNestedDeeper(value interface{}, t common.Type, depth, max int) bool {
+	switch t := t.(type) {
+	case *common.NonNull:
+		return valueNestedDeeper(value, t.OfType, depth, max)
+	case *common.List:
+		elems, ok := value.([]interface{})
+		if !ok {
+			// A single value is coerced to a list of one element.
+			return valueNestedDeeper(value, t.OfType, depth, max)
+		}
+		for _, elem := range elems {
+			if valueNestedDeeper(elem, t.OfType, depth, max) {
+				return true
+			}
+		}
+	case *schema.InputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if depth+1 > max {
+			return true
+		}
+		return nestedDeeper(fields, t.Values, depth+1, max)
+	}
+	return false
+}
+
+// nestedDeeper reports whether the values of the arguments nest input objects deeper than maxDepth.
+func (p *StructPacker) nestedDeeper(values map[string]interface{}) bool {
+	for _, f := range p.fields {
+		if valueNestedDeeper(values[f.field.Name.Name], f.field.Type, 0, p.maxDepth) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *StructPacker) hasField(name string) bool {
+	for _, f := range p.fields {
+		if f.field.Name.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maxLength returns the maximum length of string values for the given argument or input field.
+func (b *Builder) maxLength(v *common.InputValue) int {
+	if d := v.Directives.Get("maxLength"); d != nil {
+		if lit, ok := d.Args.Get("max"); ok && lit != nil {
+			if max, ok := lit.Value(nil).(int32); ok {
+				return int(max)
+			}
+		}
+	}
+	return b.MaxStringLength
+}
+
+func isScalar(t common.Type) bool {
+	for {
+		switch u := t.(type) {
+		case *common.NonNull:
+			t = u.OfType
+		case *common.List:
+			t = u.OfType
+		case *schema.Scalar:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// maxLengthPacker rejects string values, or lists of them, that are longer than max bytes.
+type maxLengthPacker struct {
+	name string
+	max  int
+	elem packer
+}
+
+func (p *maxLengthPacker) Pack(value interface{}) (reflect.Value, error) {
+	if err := p.check(value); err != nil {
+		return reflect.Value{}, err
+	}
+	return p.elem.Pack(value)
+}
+
+func (p *maxLengthPacker) check(value interface{}) error {
+	switch value := value.(type) {
+	case string:
+		if len(value) > p.max {
+			return fmt.Errorf("value of %q is %d bytes long, exceeding the maximum length of %d bytes", p.name, len(value), p.max)
+		}
+	case []interface{}:
+		for _, v := range value {
+			if err := p.check(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type listPacker struct {
 	sliceType reflect.Type
 	elem      packer
@@ -252,7 +466,7 @@ func (e *listPacker) Pack(value interface{}) (reflect.Value, error) {
 	for i := range list {
 		packed, err := e.elem.Pack(list[i])
 		if err != nil {
-			return reflect.Value{}, err
+			return reflect.Value{}, fmt.Errorf("element #%d: %s", i, err)
 		}
 		v.Index(i).Set(packed)
 	}
@@ -286,6 +500,7 @@ func (p *nullPacker) Pack(value interface{}) (reflect.Value, error) {
 
 type ValuePacker struct {
 	ValueType reflect.Type
+	Strict    bool
 }
 
 func (p *ValuePacker) Pack(value interface{}) (reflect.Value, error) {
@@ -293,21 +508,52 @@ func (p *ValuePacker) Pack(value interface{}) (reflect.Value, error) {
 		return reflect.Value{}, errors.Errorf("got null for non-null")
 	}
 
-	coerced, err := unmarshalInput(p.ValueType, value)
+	coerced, err := unmarshalInput(p.ValueType, value, p.Strict)
 	if err != nil {
 		return reflect.Value{}, fmt.Errorf("could not unmarshal %#v (%T) into %s: %s", value, value, p.ValueType, err)
 	}
 	return reflect.ValueOf(coerced), nil
 }
 
+// enumPacker packs the values of an enum, which are rejected if they are not values of the enum,
+// e.g. when they come from a variable of an operation that was not validated.
+type enumPacker struct {
+	enum   *schema.Enum
+	values ValuePacker
+}
+
+func (p *enumPacker) Pack(value interface{}) (reflect.Value, error) {
+	if s, ok := value.(string); ok {
+		found := false
+		for _, v := range p.enum.Values {
+			if v.Name == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("invalid value %v for enum %q", value, p.enum)
+		}
+	}
+	return p.values.Pack(value)
+}
+
 type unmarshalerPacker struct {
 	ValueType reflect.Type
+	// inputObject is set if the value is an input object, whose omitted fields are set to their
+	// default values before the value is unmarshaled.
+	inputObject *schema.InputObject
+	// nullable is set if the value is a Nullable type, which is unmarshaled from null as well.
+	nullable bool
 }
 
 func (p *unmarshalerPacker) Pack(value interface{}) (reflect.Value, error) {
-	if value == nil {
+	if value == nil && !p.nullable {
 		return reflect.Value{}, errors.Errorf("got null for non-null")
 	}
+	if p.inputObject != nil {
+		value = withDefaults(p.inputObject, value)
+	}
 
 	v := reflect.New(p.ValueType)
 	if err := v.Interface().(Unmarshaler).UnmarshalGraphQL(value); err != nil {
@@ -316,12 +562,84 @@ func (p *unmarshalerPacker) Pack(value interface{}) (reflect.Value, error) {
 	return v.Elem(), nil
 }
 
+// withDefaults returns a copy of value in which the omitted fields of the input objects of type t,
+// including those nested in other input objects and lists, are set to their default values.
+func withDefaults(t common.Type, value interface{}) interface{} {
+	switch t := t.(type) {
+	case *common.NonNull:
+		return withDefaults(t.OfType, value)
+	case *common.List:
+		list, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		l := make([]interface{}, len(list))
+		for i, entry := range list {
+			l[i] = withDefaults(t.OfType, entry)
+		}
+		return l
+	case *schema.InputObject:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		m := make(map[string]interface{}, len(t.Values))
+		for name, v := range obj {
+			m[name] = v
+		}
+		for _, f := range t.Values {
+			if v, ok := m[f.Name.Name]; ok {
+				m[f.Name.Name] = withDefaults(f.Type, v)
+			} else if f.Default != nil {
+				m[f.Name.Name] = withDefaults(f.Type, f.Default.Value(nil))
+			}
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+type coercionPacker struct {
+	scalar    *schema.Scalar
+	ValueType reflect.Type
+}
+
+func (p *coercionPacker) Pack(value interface{}) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Value{}, errors.Errorf("got null for non-null")
+	}
+
+	coerced, err := p.scalar.Coercion.CoerceInput(value)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("could not coerce %#v (%T) into %s: %s", value, value, p.scalar.Name, err)
+	}
+	v := reflect.ValueOf(coerced)
+	if !v.IsValid() || !v.Type().AssignableTo(p.ValueType) {
+		return reflect.Value{}, fmt.Errorf("coercion of %s returned %T, which can not be used as %s", p.scalar.Name, coerced, p.ValueType)
+	}
+	return v, nil
+}
+
 type Unmarshaler interface {
 	ImplementsGraphQLType(name string) bool
 	UnmarshalGraphQL(input interface{}) error
 }
 
-func unmarshalInput(typ reflect.Type, input interface{}) (interface{}, error) {
+// Nullable is implemented by the pointer of an Unmarshaler that represents a nullable value without
+// being a pointer itself, such as graphql.NullID. Its UnmarshalGraphQL is called with nil for an
+// explicit null, and is not called at all for an omitted value, so that both can be told apart.
+type Nullable interface {
+	Nullable()
+}
+
+// IsNullable reports whether t is a Nullable type.
+func IsNullable(t reflect.Type) bool {
+	_, ok := reflect.New(t).Interface().(Nullable)
+	return ok
+}
+
+func unmarshalInput(typ reflect.Type, input interface{}, strict bool) (interface{}, error) {
 	if reflect.TypeOf(input) == typ {
 		return input, nil
 	}
@@ -343,6 +661,9 @@ func unmarshalInput(typ reflect.Type, input interface{}) (interface{}, error) {
 		}
 
 	case reflect.Float64:
+		if strict {
+			return nil, ErrStrictCoercion
+		}
 		switch input := input.(type) {
 		case int32:
 			return float64(input), nil
@@ -351,6 +672,9 @@ func unmarshalInput(typ reflect.Type, input interface{}) (interface{}, error) {
 		}
 
 	case reflect.String:
+		if strict && reflect.TypeOf(input).Kind() != reflect.String {
+			return nil, ErrStrictCoercion
+		}
 		if reflect.TypeOf(input).ConvertibleTo(typ) {
 			return reflect.ValueOf(input).Convert(typ).Interface(), nil
 		}
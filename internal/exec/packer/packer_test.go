@@ -0,0 +1,229 @@
+package packer_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/packer"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+const directiveSchema = `
+	directive @tags(names: [String!]!, limit: Int = 10) on FIELD
+
+	type Query {
+		hello: String!
+	}
+`
+
+func TestArgsPacker(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(directiveSchema, false); err != nil {
+		t.Fatal(err)
+	}
+
+	b := packer.NewBuilder()
+	p, err := b.MakeArgsPacker(s.Directives["tags"].Args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		args    map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "list",
+			args: map[string]interface{}{"names": []interface{}{"a", "b"}},
+			want: map[string]interface{}{"names": []interface{}{"a", "b"}, "limit": int32(10)},
+		},
+		{
+			name: "single value coerced into list",
+			args: map[string]interface{}{"names": "a"},
+			want: map[string]interface{}{"names": []interface{}{"a"}, "limit": int32(10)},
+		},
+		{
+			name:    "null element",
+			args:    map[string]interface{}{"names": []interface{}{"a", nil}},
+			wantErr: true,
+		},
+		{
+			name:    "missing argument",
+			args:    map[string]interface{}{},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := p.Pack(tc.args)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, tc.want) {
+				t.Fatalf("wrong arguments, have=%v want=%v", args, tc.want)
+			}
+		})
+	}
+}
+
+func TestArgsPackerLikeFieldArgs(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		scalar Upper
+
+		input Filter {
+			name: String
+			inner: Filter
+		}
+
+		directive @check(value: Upper, text: String, filter: Filter) on FIELD
+
+		type Query {
+			hello: String!
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+	s.Types["Upper"].(*schema.Scalar).Coercion = upperCoercion{}
+
+	b := packer.NewBuilder()
+	b.MaxStringLength = 3
+	b.MaxInputDepth = 1
+	p, err := b.MakeArgsPacker(s.Directives["check"].Args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := p.Pack(map[string]interface{}{"value": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.(map[string]interface{})["value"] != "ABC" {
+		t.Errorf("the custom scalar was not coerced: %v", args)
+	}
+	for _, args := range []map[string]interface{}{
+		{"value": 1},
+		{"text": "abcd"},
+		{"filter": map[string]interface{}{"name": "abcd"}},
+		{"filter": map[string]interface{}{"inner": map[string]interface{}{}}},
+	} {
+		if _, err := p.Pack(args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+type upperCoercion struct{}
+
+func (upperCoercion) CoerceInput(value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string, got %T", value)
+	}
+	return strings.ToUpper(s), nil
+}
+
+func (upperCoercion) CoerceOutput(value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+func TestPlainPackerStrict(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(directiveSchema, false); err != nil {
+		t.Fatal(err)
+	}
+	float := &common.InputValue{Type: s.Types["Float"]}
+
+	for _, strict := range []bool{false, true} {
+		b := packer.NewBuilder()
+		b.StrictCoercion = strict
+		p, err := b.MakePlainPacker(float)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := p.Pack(int32(2))
+		if strict {
+			if err == nil || !strings.Contains(err.Error(), packer.ErrStrictCoercion.Error()) {
+				t.Fatalf("wrong error, have=%v want=%v", err, packer.ErrStrictCoercion)
+			}
+		} else if err != nil || v != float64(2) {
+			t.Fatalf("wrong value, have=%v, %v want=%v", v, err, float64(2))
+		}
+
+		if _, err := p.Pack(2.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPackEnum(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		enum Color {
+			RED
+			GREEN
+		}
+
+		input Palette {
+			primary: Color!
+			colors: [Color!]
+		}
+
+		type Query {
+			paint(palette: Palette!): String!
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+	args := s.Types["Query"].(*schema.Object).Fields.Get("paint").Args
+
+	type palette struct {
+		Primary string
+		Colors  *[]string
+	}
+	b := packer.NewBuilder()
+	p, err := b.MakeStructPacker(args, reflect.TypeOf(struct{ Palette palette }{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Pack(map[string]interface{}{"palette": map[string]interface{}{"primary": "RED", "colors": []interface{}{"GREEN"}}}); err != nil {
+		t.Fatal(err)
+	}
+	// Values that were not validated, e.g. of a trusted document, are rejected, too.
+	for _, tc := range []struct {
+		value map[string]interface{}
+		err   string
+	}{
+		{map[string]interface{}{"primary": "BLUE"}, `invalid value BLUE for enum "Color"`},
+		{map[string]interface{}{"primary": "RED", "colors": []interface{}{"GREEN", "BLUE"}}, `element #1: invalid value BLUE for enum "Color"`},
+	} {
+		_, err := p.Pack(map[string]interface{}{"palette": tc.value})
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("unexpected error for %v: %v", tc.value, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package resolvable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// Federation holds what is needed to resolve the _service and _entities fields that the query
+// type of a federated schema is extended with.
+type Federation struct {
+	// SDL is the schema as written by the user, returned by _service { sdl }.
+	SDL string
+}
+
+// Entity is a value of the _Entity union of a federated schema, as returned by the reference
+// resolver of the type with the name TypeName.
+type Entity struct {
+	TypeName string
+	Value    reflect.Value
+}
+
+var entityType = reflect.TypeOf(&Entity{})
+
+// service resolves the _Service type of a federated schema.
+type service struct {
+	sdl string
+}
+
+func (s *service) SDL() *string {
+	return &s.sdl
+}
+
+// referenceResolver is a method of the root resolver that resolves entities of a type from their
+// representations, e.g. ResolveUserReference for the entity type User.
+type referenceResolver struct {
+	methodIndex int
+	hasContext  bool
+	hasError    bool
+}
+
+// addFederation adds the _service and _entities fields of the query type to the builtin fields.
+// The entities of each type are resolved by the method "Resolve<Type>Reference" of the root
+// resolver, which takes an optional context.Context and the representation of the entity as a
+// map[string]interface{}, and returns the resolver of the entity and an optional error.
+func (b *execBuilder) addFederation(f *Federation, resolverType reflect.Type) error {
+	query, ok := b.schema.EntryPoints["query"].(*schema.Object)
+	if !ok {
+		return nil
+	}
+	b.builtins = make(map[*schema.Field]*Field)
+
+	if sf := query.Fields.Get("_service"); sf != nil {
+		fe := &Field{
+			Field:       *sf,
+			TypeName:    query.Name,
+			MethodIndex: -1,
+			TraceLabel:  fmt.Sprintf("GraphQL field: %s.%s", query.Name, sf.Name),
+			Resolve: func(ctx context.Context, parent reflect.Value, args map[string]interface{}) (reflect.Value, error) {
+				return reflect.ValueOf(&service{sdl: f.SDL}), nil
+			},
+		}
+		if err := b.assignExec(&fe.ValueExec, sf.Type, reflect.TypeOf(&service{})); err != nil {
+			return err
+		}
+		b.builtins[sf] = fe
+	}
+
+	union, ok := b.schema.Types["_Entity"].(*schema.Union)
+	sf := query.Fields.Get("_entities")
+	if !ok || sf == nil {
+		return nil
+	}
+
+	references := make(map[string]*referenceResolver)
+	b.entityExec = &Object{
+		Name:           union.Name,
+		Fields:         make(map[string]*Field),
+		TypeAssertions: make(map[string]*TypeAssertion),
+	}
+	for _, t := range union.PossibleTypes {
+		name := "Resolve" + t.Name + "Reference"
+		methodIndex := findMethod(resolverType, name)
+		if methodIndex == -1 {
+			return fmt.Errorf("%s does not resolve entity %q: missing method %q", resolverType, t.Name, name)
+		}
+		m := resolverType.Method(methodIndex)
+		ref, err := makeReferenceResolver(m, methodIndex)
+		if err != nil {
+			return fmt.Errorf("%s\n\tused by (%s).%s", err, resolverType, m.Name)
+		}
+		references[t.Name] = ref
+
+		a := &TypeAssertion{MethodIndex: -1}
+		if err := b.assignExec(&a.TypeExec, t, m.Type.Out(0)); err != nil {
+			return err
+		}
+		b.entityExec.TypeAssertions[t.Name] = a
+	}
+
+	fe := &Field{
+		Field:       *sf,
+		TypeName:    query.Name,
+		MethodIndex: -1,
+		TraceLabel:  fmt.Sprintf("GraphQL field: %s.%s", query.Name, sf.Name),
+		Resolve: func(ctx context.Context, parent reflect.Value, args map[string]interface{}) (reflect.Value, error) {
+			return resolveEntities(ctx, parent, args, references)
+		},
+	}
+	if err := b.assignExec(&fe.ValueExec, sf.Type, reflect.TypeOf([]*Entity(nil))); err != nil {
+		return err
+	}
+	b.builtins[sf] = fe
+	return nil
+}
+
+func makeReferenceResolver(m reflect.Method, methodIndex int) (*referenceResolver, error) {
+	in := make([]reflect.Type, m.Type.NumIn()-1)
+	for i := range in {
+		in[i] = m.Type.In(i + 1) // first parameter is receiver
+	}
+
+	ref := &referenceResolver{methodIndex: methodIndex}
+	ref.hasContext = len(in) > 0 && in[0] == contextType
+	if ref.hasContext {
+		in = in[1:]
+	}
+	if len(in) != 1 || in[0] != mapType {
+		return nil, fmt.Errorf("must have a map[string]interface{} parameter for the representation")
+	}
+
+	switch m.Type.NumOut() {
+	case 1:
+	case 2:
+		if m.Type.Out(1) != errorType {
+			return nil, fmt.Errorf(`must have "error" as its last return value`)
+		}
+		ref.hasError = true
+	default:
+		return nil, fmt.Errorf("must return the entity and an optional error")
+	}
+	return ref, nil
+}
+
+// resolveEntities calls the reference resolvers for the representations passed to _entities.
+func resolveEntities(ctx context.Context, resolver reflect.Value, args map[string]interface{}, references map[string]*referenceResolver) (reflect.Value, error) {
+	representations, _ := args["representations"].([]interface{})
+	entities := make([]*Entity, len(representations))
+	for i, rep := range representations {
+		repMap, ok := rep.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, errors.Errorf("representation #%d is not an object", i)
+		}
+		typeName, _ := repMap["__typename"].(string)
+		ref, ok := references[typeName]
+		if !ok {
+			return reflect.Value{}, errors.Errorf("representation #%d has unknown entity type %q", i, typeName)
+		}
+
+		var in []reflect.Value
+		if ref.hasContext {
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		in = append(in, reflect.ValueOf(repMap))
+		out := resolver.Method(ref.methodIndex).Call(in)
+		if ref.hasError && !out[1].IsNil() {
+			return reflect.Value{}, out[1].Interface().(error)
+		}
+		if isNil(out[0]) {
+			continue
+		}
+		entities[i] = &Entity{TypeName: typeName, Value: out[0]}
+	}
+	return reflect.ValueOf(entities), nil
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
+	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/common"
 	"github.com/graph-gophers/graphql-go/internal/exec/packer"
 	"github.com/graph-gophers/graphql-go/internal/schema"
@@ -18,6 +20,33 @@ type Schema struct {
 	Mutation     Resolvable
 	Subscription Resolvable
 	Resolver     reflect.Value
+
+	// directiveArgs pack the arguments of the directives declared by the schema, by name.
+	directiveArgs map[string]*packer.PlainPacker
+}
+
+// DirectiveArgs returns the arguments of the directive d, applied in a query or the schema, with
+// the variables of the query resolved. They are packed into plain values, see packer.PlainPacker,
+// and the omitted arguments are set to their default value.
+func (s *Schema) DirectiveArgs(d *common.Directive, vars map[string]interface{}) (map[string]interface{}, error) {
+	p, ok := s.directiveArgs[d.Name.Name]
+	if !ok {
+		return nil, fmt.Errorf("directive %q is not declared by the schema", "@"+d.Name.Name)
+	}
+	given := make(map[string]interface{}, len(d.Args))
+	for _, arg := range d.Args {
+		// The schema adds the omitted arguments of its directives with their default value, which
+		// is nil if the argument has none.
+		if arg.Value == nil || common.IsUnsetVariable(arg.Value, vars) {
+			continue
+		}
+		given[arg.Name.Name] = arg.Value.Value(vars)
+	}
+	args, err := p.Pack(given)
+	if err != nil {
+		return nil, fmt.Errorf("directive %q: %s", "@"+d.Name.Name, err)
+	}
+	return args.(map[string]interface{}), nil
 }
 
 type Resolvable interface {
@@ -36,16 +65,50 @@ type Field struct {
 	MethodIndex int
 	FieldIndex  []int
 	HasContext  bool
-	HasError    bool
-	ArgsPacker  *packer.StructPacker
-	ValueExec   Resolvable
-	TraceLabel  string
+	// HasSelectedFields is set if the resolver takes a SelectedFields parameter after its arguments.
+	HasSelectedFields bool
+	HasError          bool
+	// HasPartialError is set if the resolver returns a *errors.QueryError instead of an error,
+	// which does not fail the field.
+	HasPartialError bool
+	ArgsPacker      *packer.StructPacker
+	ValueExec       Resolvable
+	TraceLabel      string
+	// FromMap is set if the field is read by name from a map[string]interface{} parent.
+	FromMap bool
+	// Resolve resolves a field that is provided by the library instead of the resolver of its
+	// parent, like the _service and _entities fields of a federated schema, see Federation.
+	Resolve func(ctx context.Context, parent reflect.Value, args map[string]interface{}) (reflect.Value, error)
+	// Timeout is the time the method resolver of the field may take, as declared by the @timeout
+	// directive of the field. It is zero if the field has no timeout.
+	Timeout time.Duration
+}
+
+// SelectedFields describes the subfields that a query selects on the value of a field, so that
+// resolvers can fetch only the data that is needed. The fields of fragments on the type of the
+// field itself are merged into Fields, while the fields of fragments on the members of a union or
+// the implementations of an interface are listed in ByType. __typename is never listed.
+type SelectedFields struct {
+	// Fields holds the fields that are selected for all values, in the order of the query.
+	Fields []SelectedField
+	// ByType holds the fields that are only selected for values of the object type with the given
+	// name, e.g. by the inline fragment "... on Human { height }" on a union field.
+	ByType map[string][]SelectedField
+}
+
+// SelectedField is a field selected in a query.
+type SelectedField struct {
+	Name  string
+	Alias string
 }
 
 func (f *Field) UseMethodResolver() bool {
-	return len(f.FieldIndex) == 0
+	return len(f.FieldIndex) == 0 && !f.FromMap && f.Resolve == nil
 }
 
+// TypeAssertion converts the resolver of an abstract type to the resolver of one of its possible
+// types with the method at MethodIndex. The MethodIndex is -1 for the members of the _Entity union
+// of a federated schema, whose resolvers are *Entity values.
 type TypeAssertion struct {
 	MethodIndex int
 	TypeExec    Resolvable
@@ -62,11 +125,29 @@ func (*List) isResolvable()   {}
 func (*Scalar) isResolvable() {}
 
 func ApplyResolver(s *schema.Schema, resolver interface{}) (*Schema, error) {
+	return ApplyFederatedResolver(s, resolver, nil)
+}
+
+// ApplyFederatedResolver is like ApplyResolver, but also provides the _service and _entities
+// fields of a federated schema if federation is not nil.
+func ApplyFederatedResolver(s *schema.Schema, resolver interface{}, federation *Federation) (*Schema, error) {
+	b := newBuilder(s)
+	directiveArgs, err := b.makeDirectivePackers()
+	if err != nil {
+		return nil, err
+	}
 	if resolver == nil {
-		return &Schema{Meta: newMeta(s), Schema: *s}, nil
+		if err := b.finish(); err != nil {
+			return nil, err
+		}
+		return &Schema{Meta: newMeta(s), Schema: *s, directiveArgs: directiveArgs}, nil
 	}
 
-	b := newBuilder(s)
+	if federation != nil {
+		if err := b.addFederation(federation, reflect.TypeOf(resolver)); err != nil {
+			return nil, err
+		}
+	}
 
 	var query, mutation, subscription Resolvable
 
@@ -93,19 +174,37 @@ func ApplyResolver(s *schema.Schema, resolver interface{}) (*Schema, error) {
 	}
 
 	return &Schema{
-		Meta:         newMeta(s),
-		Schema:       *s,
-		Resolver:     reflect.ValueOf(resolver),
-		Query:        query,
-		Mutation:     mutation,
-		Subscription: subscription,
+		Meta:          newMeta(s),
+		Schema:        *s,
+		Resolver:      reflect.ValueOf(resolver),
+		Query:         query,
+		Mutation:      mutation,
+		Subscription:  subscription,
+		directiveArgs: directiveArgs,
 	}, nil
 }
 
+// makeDirectivePackers makes the packers of the arguments of the directives declared by the schema.
+func (b *execBuilder) makeDirectivePackers() (map[string]*packer.PlainPacker, error) {
+	packers := make(map[string]*packer.PlainPacker, len(b.schema.Directives))
+	for name, d := range b.schema.Directives {
+		p, err := b.packerBuilder.MakeArgsPacker(d.Args)
+		if err != nil {
+			return nil, fmt.Errorf("directive %q: %s", "@"+name, err)
+		}
+		packers[name] = p
+	}
+	return packers, nil
+}
+
 type execBuilder struct {
 	schema        *schema.Schema
 	resMap        map[typePair]*resMapEntry
 	packerBuilder *packer.Builder
+	// builtins holds the fields that are resolved by the library instead of the resolvers.
+	builtins map[*schema.Field]*Field
+	// entityExec resolves the _Entity union of a federated schema from *Entity values.
+	entityExec *Object
 }
 
 type typePair struct {
@@ -119,11 +218,15 @@ type resMapEntry struct {
 }
 
 func newBuilder(s *schema.Schema) *execBuilder {
-	return &execBuilder{
+	b := &execBuilder{
 		schema:        s,
 		resMap:        make(map[typePair]*resMapEntry),
 		packerBuilder: packer.NewBuilder(),
 	}
+	b.packerBuilder.StrictCoercion = s.StrictCoercion
+	b.packerBuilder.MaxStringLength = s.MaxStringLength
+	b.packerBuilder.MaxInputDepth = s.MaxInputDepth
+	return b
 }
 
 func (b *execBuilder) finish() error {
@@ -156,6 +259,25 @@ func (b *execBuilder) makeExec(t common.Type, resolverType reflect.Type) (Resolv
 	var nonNull bool
 	t, nonNull = unwrapNonNull(t)
 
+	switch t.(type) {
+	case *schema.Object, *schema.Interface, *schema.Union:
+		// A pointer to an interface is resolved like the interface itself, the executor
+		// dereferences the pointer before asserting the concrete type.
+		if resolverType.Kind() == reflect.Ptr && resolverType.Elem().Kind() == reflect.Interface {
+			resolverType = resolverType.Elem()
+		}
+	}
+
+	if resolverType == entityType && b.entityExec != nil {
+		return b.entityExec, nil
+	}
+	if resolverType.Kind() == reflect.Chan {
+		return b.makeChanExec(t, resolverType)
+	}
+	if resolverType == mapType || resolverType == emptyInterfaceType {
+		return b.makeDynamicExec(t, resolverType)
+	}
+
 	switch t := t.(type) {
 	case *schema.Object:
 		return b.makeObjectExec(t.Name, t.Fields, nil, nonNull, resolverType)
@@ -167,7 +289,7 @@ func (b *execBuilder) makeExec(t common.Type, resolverType reflect.Type) (Resolv
 		return b.makeObjectExec(t.Name, nil, t.PossibleTypes, nonNull, resolverType)
 	}
 
-	if !nonNull {
+	if !nonNull && !packer.IsNullable(resolverType) {
 		if resolverType.Kind() != reflect.Ptr {
 			return nil, fmt.Errorf("%s is not a pointer", resolverType)
 		}
@@ -196,7 +318,28 @@ func (b *execBuilder) makeExec(t common.Type, resolverType reflect.Type) (Resolv
 	}
 }
 
+// makeChanExec makes the resolvable of a list field whose resolver returns a channel. The items
+// are received until the channel is closed. A nil channel resolves to null.
+func (b *execBuilder) makeChanExec(t common.Type, resolverType reflect.Type) (Resolvable, error) {
+	l, ok := t.(*common.List)
+	if !ok {
+		return nil, fmt.Errorf("%s can not be used as %s, channels can only resolve lists", resolverType, t)
+	}
+	if resolverType.ChanDir()&reflect.RecvDir == 0 {
+		return nil, fmt.Errorf("%s is a send-only channel", resolverType)
+	}
+	e := &List{}
+	if err := b.assignExec(&e.Elem, l.OfType, resolverType.Elem()); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 func makeScalarExec(t *schema.Scalar, resolverType reflect.Type) (Resolvable, error) {
+	if t.Coercion != nil {
+		// Any value is accepted, it is converted by CoerceOutput.
+		return &Scalar{}, nil
+	}
 	implementsType := false
 	switch r := reflect.New(resolverType).Interface().(type) {
 	case *int32:
@@ -230,6 +373,10 @@ func (b *execBuilder) makeObjectExec(typeName string, fields schema.FieldList, p
 	rt := unwrapPtr(resolverType)
 	fieldsCount := fieldCount(rt, map[string]int{})
 	for _, f := range fields {
+		if fe, ok := b.builtins[f]; ok {
+			Fields[f.Name] = fe
+			continue
+		}
 		var fieldIndex []int
 		methodIndex := findMethod(resolverType, f.Name)
 		if b.schema.UseFieldResolvers && methodIndex == -1 {
@@ -239,6 +386,9 @@ func (b *execBuilder) makeObjectExec(typeName string, fields schema.FieldList, p
 			fieldIndex = findField(rt, f.Name, []int{})
 		}
 		if methodIndex == -1 && len(fieldIndex) == 0 {
+			if embedded := ambiguousMethod(rt, f.Name); len(embedded) > 1 {
+				return nil, fmt.Errorf("%s does not resolve %q: ambiguous method for field %q, it is promoted from both %s and %s", resolverType, typeName, f.Name, embedded[0], embedded[1])
+			}
 			hint := ""
 			if findMethod(reflect.PtrTo(resolverType), f.Name) != -1 {
 				hint = " (hint: the method exists on the pointer type)"
@@ -270,8 +420,8 @@ func (b *execBuilder) makeObjectExec(typeName string, fields schema.FieldList, p
 			if methodIndex == -1 {
 				return nil, fmt.Errorf("%s does not resolve %q: missing method %q to convert to %q", resolverType, typeName, "To"+impl.Name, impl.Name)
 			}
-			if resolverType.Method(methodIndex).Type.NumOut() != 2 {
-				return nil, fmt.Errorf("%s does not resolve %q: method %q should return a value and a bool indicating success", resolverType, typeName, "To"+impl.Name)
+			if !isTypeAssertionMethod(resolverType, resolverType.Method(methodIndex)) {
+				return nil, fmt.Errorf("%s does not resolve %q: method %q should take no arguments and return a value and a bool indicating success", resolverType, typeName, "To"+impl.Name)
 			}
 			a := &TypeAssertion{
 				MethodIndex: methodIndex,
@@ -290,15 +440,108 @@ func (b *execBuilder) makeObjectExec(typeName string, fields schema.FieldList, p
 	}, nil
 }
 
+// isTimeoutDirective reports whether d declares the @timeout directive with an argument ms of type
+// Int, as documented. A @timeout directive of the application with a different declaration has
+// another meaning, so it is left alone.
+func isTimeoutDirective(d *schema.DirectiveDecl) bool {
+	if d == nil {
+		return false
+	}
+	arg := d.Args.Get("ms")
+	if arg == nil {
+		return false
+	}
+	t := arg.Type
+	if nn, ok := t.(*common.NonNull); ok {
+		t = nn.OfType
+	}
+	scalar, ok := t.(*schema.Scalar)
+	return ok && scalar.Name == "Int"
+}
+
+// readTimeout returns the timeout of a @timeout(ms: Int) directive, which must be positive.
+func readTimeout(d *common.Directive) (time.Duration, error) {
+	lit, ok := d.Args.Get("ms")
+	if !ok || lit == nil {
+		return 0, fmt.Errorf("@timeout requires the argument \"ms\"")
+	}
+	ms, ok := lit.Value(nil).(int32)
+	if !ok || ms <= 0 {
+		return 0, fmt.Errorf("@timeout requires a positive number of milliseconds, got %s", lit)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// isTypeAssertionMethod reports whether m has the signature of a type assertion method, which is
+// To<Type>() (T, bool). Methods of interface types do not take the receiver as first argument.
+func isTypeAssertionMethod(resolverType reflect.Type, m reflect.Method) bool {
+	in := m.Type.NumIn()
+	if resolverType.Kind() != reflect.Interface {
+		in--
+	}
+	return in == 0 && m.Type.NumOut() == 2 && m.Type.Out(1).Kind() == reflect.Bool
+}
+
+// makeDynamicExec makes the resolvable of a value that is resolved by its dynamic type: objects
+// from a map[string]interface{} with an entry for each field, lists from slices and leaf values
+// from any value that can be coerced to the type. Arguments of fields read from maps are ignored.
+func (b *execBuilder) makeDynamicExec(t common.Type, resolverType reflect.Type) (Resolvable, error) {
+	switch t := t.(type) {
+	case *schema.Object:
+		fields := make(map[string]*Field)
+		for _, f := range t.Fields {
+			fe := &Field{
+				Field:       *f,
+				TypeName:    t.Name,
+				MethodIndex: -1,
+				FromMap:     true,
+				TraceLabel:  fmt.Sprintf("GraphQL field: %s.%s", t.Name, f.Name),
+			}
+			if err := b.assignExec(&fe.ValueExec, f.Type, emptyInterfaceType); err != nil {
+				return nil, err
+			}
+			fields[f.Name] = fe
+		}
+		return &Object{
+			Name:           t.Name,
+			Fields:         fields,
+			TypeAssertions: make(map[string]*TypeAssertion),
+		}, nil
+
+	case *common.List:
+		if resolverType == mapType {
+			break
+		}
+		e := &List{}
+		if err := b.assignExec(&e.Elem, t.OfType, emptyInterfaceType); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case *schema.Scalar, *schema.Enum:
+		if resolverType == mapType {
+			break
+		}
+		return &Scalar{}, nil
+	}
+	return nil, fmt.Errorf("can not use %s as %s, only object types can be resolved from maps", resolverType, t)
+}
+
+var mapType = reflect.TypeOf(map[string]interface{}(nil))
+var emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
 var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
+var queryErrorType = reflect.TypeOf((*errors.QueryError)(nil))
+var selectedFieldsType = reflect.TypeOf(SelectedFields{})
 
 func (b *execBuilder) makeFieldExec(typeName string, f *schema.Field, m reflect.Method, sf reflect.StructField,
 	methodIndex int, fieldIndex []int, methodHasReceiver bool) (*Field, error) {
 
 	var argsPacker *packer.StructPacker
 	var hasError bool
+	var hasPartialError bool
 	var hasContext bool
+	var hasSelectedFields bool
 
 	// Validate resolver method only when there is one
 	if methodIndex != -1 {
@@ -309,15 +552,18 @@ func (b *execBuilder) makeFieldExec(typeName string, f *schema.Field, m reflect.
 		if methodHasReceiver {
 			in = in[1:] // first parameter is receiver
 		}
+		params := in
 
+		// The parameters are matched in a fixed order: the context, the arguments of the field
+		// and the selected fields, each of which is optional except for the arguments.
 		hasContext = len(in) > 0 && in[0] == contextType
 		if hasContext {
 			in = in[1:]
 		}
 
 		if len(f.Args) > 0 {
-			if len(in) == 0 {
-				return nil, fmt.Errorf("must have parameter for field arguments")
+			if len(in) == 0 || in[0] == selectedFieldsType {
+				return nil, signatureError(typeName, f, params, m.Type, "missing the parameter for the field arguments")
 			}
 			var err error
 			argsPacker, err = b.packerBuilder.MakeStructPacker(f.Args, in[0])
@@ -327,36 +573,59 @@ func (b *execBuilder) makeFieldExec(typeName string, f *schema.Field, m reflect.
 			in = in[1:]
 		}
 
-		if len(in) > 0 {
-			return nil, fmt.Errorf("too many parameters")
+		hasSelectedFields = len(in) > 0 && in[0] == selectedFieldsType
+		if hasSelectedFields {
+			in = in[1:]
 		}
 
-		maxNumOfReturns := 2
-		if m.Type.NumOut() < maxNumOfReturns-1 {
-			return nil, fmt.Errorf("too few return values")
+		if len(in) > 0 {
+			reason := fmt.Sprintf("unexpected parameter of type %s", in[0])
+			switch {
+			case in[0] == contextType:
+				reason = "context.Context must be the first parameter"
+			case len(f.Args) == 0 && unwrapPtr(in[0]).Kind() == reflect.Struct:
+				reason = fmt.Sprintf("unexpected parameter of type %s, the field has no arguments", in[0])
+			}
+			return nil, signatureError(typeName, f, params, m.Type, reason)
 		}
 
-		if m.Type.NumOut() > maxNumOfReturns {
-			return nil, fmt.Errorf("too many return values")
+		if m.Type.NumOut() == 0 {
+			return nil, signatureError(typeName, f, params, m.Type, "too few return values")
+		}
+		if m.Type.NumOut() > 2 {
+			return nil, signatureError(typeName, f, params, m.Type, "too many return values")
 		}
 
-		hasError = m.Type.NumOut() == maxNumOfReturns
+		hasError = m.Type.NumOut() == 2
 		if hasError {
-			if m.Type.Out(maxNumOfReturns-1) != errorType {
-				return nil, fmt.Errorf(`must have "error" as its last return value`)
+			switch m.Type.Out(1) {
+			case errorType:
+			case queryErrorType:
+				hasPartialError = true
+			default:
+				return nil, signatureError(typeName, f, params, m.Type, `must have "error" or "*errors.QueryError" as its last return value`)
 			}
 		}
 	}
 
 	fe := &Field{
-		Field:       *f,
-		TypeName:    typeName,
-		MethodIndex: methodIndex,
-		FieldIndex:  fieldIndex,
-		HasContext:  hasContext,
-		ArgsPacker:  argsPacker,
-		HasError:    hasError,
-		TraceLabel:  fmt.Sprintf("GraphQL field: %s.%s", typeName, f.Name),
+		Field:             *f,
+		TypeName:          typeName,
+		MethodIndex:       methodIndex,
+		FieldIndex:        fieldIndex,
+		HasContext:        hasContext,
+		HasSelectedFields: hasSelectedFields,
+		ArgsPacker:        argsPacker,
+		HasError:          hasError,
+		HasPartialError:   hasPartialError,
+		TraceLabel:        fmt.Sprintf("GraphQL field: %s.%s", typeName, f.Name),
+	}
+	if d := f.Directives.Get("timeout"); d != nil && isTimeoutDirective(b.schema.Directives["timeout"]) {
+		timeout, err := readTimeout(d)
+		if err != nil {
+			return nil, err
+		}
+		fe.Timeout = timeout
 	}
 
 	var out reflect.Type
@@ -376,6 +645,45 @@ func (b *execBuilder) makeFieldExec(typeName string, f *schema.Field, m reflect.
 	return fe, nil
 }
 
+// signatureError returns the error of a resolver method with the given parameters, without the
+// receiver, that can not resolve the field. It lists the signatures that are supported for the
+// field in the order in which the parameters are matched.
+func signatureError(typeName string, f *schema.Field, params []reflect.Type, method reflect.Type, reason string) error {
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.String()
+	}
+	sig := "func(" + strings.Join(names, ", ") + ")"
+	switch method.NumOut() {
+	case 0:
+	case 1:
+		sig += " " + method.Out(0).String()
+	default:
+		outs := make([]string, method.NumOut())
+		for i := range outs {
+			outs[i] = method.Out(i).String()
+		}
+		sig += " (" + strings.Join(outs, ", ") + ")"
+	}
+
+	args := ""
+	if len(f.Args) > 0 {
+		args = "args, "
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "resolver of field %q has an unsupported signature %s: %s", typeName+"."+f.Name, sig, reason)
+	b.WriteString("\n\tsupported signatures, each returning T, (T, error) or (T, *errors.QueryError):")
+	for _, params := range []string{
+		"context.Context, " + args + "graphql.SelectedFields",
+		"context.Context, " + args,
+		args + "graphql.SelectedFields",
+		args,
+	} {
+		fmt.Fprintf(&b, "\n\t\tfunc(%s)", strings.TrimSuffix(params, ", "))
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
 func findMethod(t reflect.Type, name string) int {
 	for i := 0; i < t.NumMethod(); i++ {
 		if strings.EqualFold(stripUnderscore(name), stripUnderscore(t.Method(i).Name)) {
@@ -385,6 +693,43 @@ func findMethod(t reflect.Type, name string) int {
 	return -1
 }
 
+// ambiguousMethod returns the embedded types of the struct t that declare the method of the given
+// field at the shallowest depth, if there are more than one. Go does not promote such methods, so
+// a resolver can not inherit them and has to declare the method itself.
+func ambiguousMethod(t reflect.Type, name string) []reflect.Type {
+	level := []reflect.Type{t}
+	seen := make(map[reflect.Type]bool)
+	for len(level) > 0 {
+		var found, next []reflect.Type
+		for _, st := range level {
+			if st.Kind() != reflect.Struct || seen[st] {
+				continue
+			}
+			seen[st] = true
+			for i := 0; i < st.NumField(); i++ {
+				field := st.Field(i)
+				if !field.Anonymous {
+					continue
+				}
+				ft := field.Type
+				if ft.Kind() != reflect.Ptr {
+					ft = reflect.PtrTo(ft)
+				}
+				if findMethod(ft, name) != -1 {
+					found = append(found, field.Type)
+					continue
+				}
+				next = append(next, ft.Elem())
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+		level = next
+	}
+	return nil
+}
+
 func findField(t reflect.Type, name string, index []int) []int {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
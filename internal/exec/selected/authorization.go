@@ -0,0 +1,86 @@
+package selected
+
+import (
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// AuthorizationPolicy decides what happens to a field that the request is not authorized to query.
+type AuthorizationPolicy int
+
+const (
+	// DropUnauthorized removes the field from the response and adds an error for it.
+	DropUnauthorized AuthorizationPolicy = iota
+	// NullUnauthorized resolves the field to null with an error at its path, without calling its
+	// resolver, like a resolver that returned an error.
+	NullUnauthorized
+)
+
+// Authorization guards the fields of the schema that have the directive with the given name. The
+// directive has a "role" argument with the role that a request needs to query the field. The
+// directive of an interface field guards the fields of the implementations as well.
+type Authorization struct {
+	Directive string
+	Policy    AuthorizationPolicy
+}
+
+// requiredRoles returns the roles that are needed to query the field of the object type, declared
+// by the field itself or the same field of an interface that the type implements.
+func (a *Authorization) requiredRoles(s *schema.Schema, typeName string, f *schema.Field) []string {
+	roles := a.fieldRoles(f)
+	if obj, ok := s.Types[typeName].(*schema.Object); ok {
+		for _, iface := range obj.Interfaces {
+			if ifaceField := iface.Fields.Get(f.Name); ifaceField != nil {
+				roles = append(roles, a.fieldRoles(ifaceField)...)
+			}
+		}
+	}
+	return roles
+}
+
+func (a *Authorization) fieldRoles(f *schema.Field) []string {
+	d := f.Directives.Get(a.Directive)
+	if d == nil {
+		return nil
+	}
+	lit, ok := d.Args.Get("role")
+	if !ok || lit == nil {
+		return nil
+	}
+	role, _ := lit.Value(nil).(string)
+	return []string{role}
+}
+
+// guarded reports whether the field of the object needs a role.
+func (r *Request) guarded(e *resolvable.Object, fieldName string) bool {
+	if r.Authorization == nil {
+		return false
+	}
+	fe, ok := e.Fields[fieldName]
+	return ok && len(r.Authorization.requiredRoles(r.Schema, e.Name, &fe.Field)) != 0
+}
+
+// authorize returns an error if the request lacks a role needed to query the field of the object.
+func (r *Request) authorize(e *resolvable.Object, fe *resolvable.Field) *errors.QueryError {
+	if r.Authorization == nil {
+		return nil
+	}
+	for _, role := range r.Authorization.requiredRoles(r.Schema, e.Name, &fe.Field) {
+		if !r.hasRole(role) {
+			err := errors.Errorf("not authorized to query field %q on type %q: role %q is required", fe.Name, e.Name, role)
+			err.Extensions = map[string]interface{}{"code": "FORBIDDEN"}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Request) hasRole(role string) bool {
+	for _, have := range r.Roles {
+		if have == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package selected
+
+import (
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/internal/query"
+)
+
+// Plan is the selection tree of an operation, built without the variables of a request so that
+// it can be shared by all requests of the operation. Selections whose arguments or directives
+// depend on variables are left unapplied and applied by Bind.
+type Plan struct {
+	op                   *query.Operation
+	sels                 []Selection
+	errs                 []*errors.QueryError
+	disableIntrospection bool
+	incremental          bool
+}
+
+// unbound is a selection of a plan that depends on the variables of the request.
+type unbound struct {
+	object *resolvable.Object
+	sel    query.Selection
+}
+
+func (*unbound) isSelection() {}
+
+// PlanOperation builds the plan of the operation. Only the schema, document, directive handlers,
+// authorization and flags of the request are used.
+func PlanOperation(r *Request, s *resolvable.Schema, op *query.Operation) *Plan {
+	pr := &Request{
+		Schema:               r.Schema,
+		Doc:                  r.Doc,
+		DisableIntrospection: r.DisableIntrospection,
+		DirectiveHandlers:    r.DirectiveHandlers,
+		Incremental:          r.Incremental,
+		Authorization:        r.Authorization,
+		planning:             true,
+	}
+	return &Plan{
+		op:                   op,
+		sels:                 ApplyOperation(pr, s, op),
+		errs:                 pr.Errs,
+		disableIntrospection: r.DisableIntrospection,
+		incremental:          r.Incremental,
+	}
+}
+
+// Bind returns the selections of the plan for the request. The selections that do not depend on
+// the variables of the request are shared with the plan and must not be modified.
+func Bind(r *Request, s *resolvable.Schema, p *Plan) []Selection {
+	if r.DisableIntrospection != p.disableIntrospection || r.Incremental != p.incremental || r.FieldVisible != nil {
+		// The plan does not fit the request, e.g. if introspection is disabled for it or hides fields.
+		return ApplyOperation(r, s, p.op)
+	}
+	for _, err := range p.errs {
+		// The errors of the plan are shared by its requests, which set their extensions and phase.
+		e := *err
+		r.AddError(&e)
+	}
+	sels, _ := bindSelections(r, s, p.sels)
+	return sels
+}
+
+// bindSelections applies the unbound selections of sels and reports whether any were found. The
+// slices and fields that contain no unbound selections are returned unchanged.
+func bindSelections(r *Request, s *resolvable.Schema, sels []Selection) ([]Selection, bool) {
+	var res []Selection
+	for i, sel := range sels {
+		var bound []Selection
+		switch sel := sel.(type) {
+		case *unbound:
+			bound = applySelectionSet(r, s, sel.object, []query.Selection{sel.sel})
+			if bound == nil {
+				bound = []Selection{}
+			}
+
+		case *SchemaField:
+			if fieldSels, ok := bindSelections(r, s, sel.Sels); ok {
+				f := *sel
+				f.Sels = fieldSels
+				f.Async = isAsync(&f.Field, fieldSels)
+				bound = []Selection{&f}
+			}
+
+		case *TypeAssertion:
+			if taSels, ok := bindSelections(r, s, sel.Sels); ok {
+				bound = []Selection{newTypeAssertion(&sel.TypeAssertion, taSels)}
+			}
+
+		case *DeferredFragment:
+			if fragSels, ok := bindSelections(r, s, sel.Sels); ok {
+				bound = []Selection{&DeferredFragment{Label: sel.Label, Sels: fragSels}}
+			}
+		}
+
+		if bound != nil && res == nil {
+			res = append(make([]Selection, 0, len(sels)), sels[:i]...)
+		}
+		switch {
+		case bound != nil:
+			res = append(res, bound...)
+		case res != nil:
+			res = append(res, sel)
+		}
+	}
+	if res == nil {
+		return sels, false
+	}
+	return res, true
+}
+
+// dependsOnRequest reports whether applying the selection of the object, but not its
+// sub-selections, depends on the request: on variables, on directive handlers, which may decide
+// differently each time, or on the roles of the caller.
+func dependsOnRequest(r *Request, e *resolvable.Object, sel query.Selection) bool {
+	var directives common.DirectiveList
+	switch sel := sel.(type) {
+	case *query.Field:
+		if r.guarded(e, sel.Name.Name) {
+			return true
+		}
+		for _, arg := range sel.Arguments {
+			if hasVariable(arg.Value) {
+				return true
+			}
+		}
+		directives = sel.Directives
+	case *query.InlineFragment:
+		directives = sel.Directives
+	case *query.FragmentSpread:
+		directives = sel.Directives
+	}
+	for _, d := range directives {
+		if _, ok := r.DirectiveHandlers[d.Name.Name]; ok {
+			return true
+		}
+		for _, arg := range d.Args {
+			if hasVariable(arg.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasVariable(lit common.Literal) bool {
+	switch lit := lit.(type) {
+	case *common.Variable:
+		return true
+	case *common.ListLit:
+		for _, entry := range lit.Entries {
+			if hasVariable(entry) {
+				return true
+			}
+		}
+	case *common.ObjectLit:
+		for _, f := range lit.Fields {
+			if hasVariable(f.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package selected
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/graph-gophers/graphql-go/errors"
@@ -21,14 +22,71 @@ type Request struct {
 	Mu                   sync.Mutex
 	Errs                 []*errors.QueryError
 	DisableIntrospection bool
+	// FieldVisible hides the fields for which it returns false from introspection, together with
+	// the types that are no longer reachable from the root types. All fields are visible if nil.
+	FieldVisible func(typeName, fieldName string) bool
+	// DirectiveHandlers decide whether selections with the directive of the given name are
+	// skipped. A handler for "skip" or "include" replaces the built-in behavior.
+	DirectiveHandlers map[string]DirectiveHandler
+	// Incremental turns fragments with the @defer directive into DeferredFragment selections and
+	// sets SchemaField.Stream for list fields with the @stream directive, if the schema declares
+	// the directive. Otherwise the directives are ignored.
+	Incremental bool
+	// ErrorExtensions are added to the extensions of every error passed to AddError, without
+	// replacing the entries that the error already has.
+	ErrorExtensions map[string]interface{}
+	// RecordSkipped makes the request record the selections that directives skip in Skipped, which
+	// helps to debug why a field is missing from a response.
+	RecordSkipped bool
+	Skipped       []SkippedSelection
+	// Authorization guards the fields that have its directive, see Authorization. No field is
+	// guarded if it is nil.
+	Authorization *Authorization
+	// Roles are the roles of the caller that Authorization checks.
+	Roles []string
+
+	// planning is set by PlanOperation, see unbound.
+	planning bool
 }
 
+// SkippedSelection is a selection that was skipped because of a directive.
+type SkippedSelection struct {
+	// Selection is the response key of a field, "..." followed by the name of a fragment spread
+	// or "... on" followed by the type condition of an inline fragment.
+	Selection string
+	Directive string
+	// If is the coerced "if" argument of the directive, if it has one of type Boolean.
+	If     *bool
+	Reason string
+	Loc    errors.Location
+}
+
+// DirectiveHandler receives the coerced arguments of a directive applied to a field, inline
+// fragment or fragment spread and returns whether the selection should be skipped.
+type DirectiveHandler func(args map[string]interface{}) (skip bool, err error)
+
 func (r *Request) AddError(err *errors.QueryError) {
+	if len(r.ErrorExtensions) != 0 {
+		err.Extensions = MergeExtensions(err.Extensions, r.ErrorExtensions)
+	}
 	r.Mu.Lock()
 	r.Errs = append(r.Errs, err)
 	r.Mu.Unlock()
 }
 
+// MergeExtensions returns a copy of ext with the entries of add that ext does not have. The map of
+// ext is not modified, since resolvers may share it between errors.
+func MergeExtensions(ext, add map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(ext)+len(add))
+	for k, v := range add {
+		merged[k] = v
+	}
+	for k, v := range ext {
+		merged[k] = v
+	}
+	return merged
+}
+
 func ApplyOperation(r *Request, s *resolvable.Schema, op *query.Operation) []Selection {
 	var obj *resolvable.Object
 	switch op.Type {
@@ -49,16 +107,43 @@ type Selection interface {
 type SchemaField struct {
 	resolvable.Field
 	Alias       string
+	Loc         errors.Location
 	Args        map[string]interface{}
 	PackedArgs  reflect.Value
 	Sels        []Selection
 	Async       bool
 	FixedResult reflect.Value
+	// Stream is set if the items of the list field are delivered incrementally.
+	Stream *Stream
+	// Directives holds the directives applied to the field in the query.
+	Directives []Directive
+	// Priority is the weight of the @priority directive of the field. Asynchronous fields with a
+	// higher priority are dispatched first.
+	Priority int
+	// Unauthorized is the error of a field that the request is not authorized to query, which
+	// resolves to null with the error instead of calling the resolver.
+	Unauthorized *errors.QueryError
+}
+
+// Directive is a directive applied in a query, with its arguments coerced according to the
+// declaration of the directive.
+type Directive struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// Stream holds the arguments of the @stream directive of a list field. The first InitialCount
+// items are resolved with the list, the other ones after the rest of the operation.
+type Stream struct {
+	Label        string
+	InitialCount int
 }
 
 type TypeAssertion struct {
 	resolvable.TypeAssertion
 	Sels []Selection
+	// Async caches HasAsyncSel(Sels), so that it is computed once while the selections are built.
+	Async bool
 }
 
 type TypenameField struct {
@@ -66,16 +151,29 @@ type TypenameField struct {
 	Alias string
 }
 
-func (*SchemaField) isSelection()   {}
-func (*TypeAssertion) isSelection() {}
-func (*TypenameField) isSelection() {}
+// DeferredFragment holds the selections of a fragment with the @defer directive, which are
+// executed after the rest of the operation.
+type DeferredFragment struct {
+	Label string
+	Sels  []Selection
+}
+
+func (*SchemaField) isSelection()      {}
+func (*TypeAssertion) isSelection()    {}
+func (*TypenameField) isSelection()    {}
+func (*DeferredFragment) isSelection() {}
 
 func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, sels []query.Selection) (flattenedSels []Selection) {
 	for _, sel := range sels {
+		if r.planning && dependsOnRequest(r, e, sel) {
+			flattenedSels = append(flattenedSels, &unbound{object: e, sel: sel})
+			continue
+		}
+
 		switch sel := sel.(type) {
 		case *query.Field:
 			field := sel
-			if skipByDirective(r, field.Directives) {
+			if skipByDirective(r, s, field.Directives, field.Alias.Name, field.Alias.Loc) {
 				continue
 			}
 
@@ -95,7 +193,7 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 						Alias:       field.Alias.Name,
 						Sels:        applySelectionSet(r, s, s.Meta.Schema, field.Selections),
 						Async:       true,
-						FixedResult: reflect.ValueOf(introspection.WrapSchema(r.Schema)),
+						FixedResult: reflect.ValueOf(introspection.WrapSchemaVisible(r.Schema, r.FieldVisible)),
 					})
 				}
 
@@ -108,8 +206,8 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 						return nil
 					}
 
-					t, ok := r.Schema.Types[v.String()]
-					if !ok {
+					t := introspection.WrapSchemaVisible(r.Schema, r.FieldVisible).TypeByName(v.String())
+					if t == nil {
 						return nil
 					}
 
@@ -118,20 +216,40 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 						Alias:       field.Alias.Name,
 						Sels:        applySelectionSet(r, s, s.Meta.Type, field.Selections),
 						Async:       true,
-						FixedResult: reflect.ValueOf(introspection.WrapType(t)),
+						FixedResult: reflect.ValueOf(t),
 					})
 				}
 
 			default:
 				fe := e.Fields[field.Name.Name]
 
+				if err := r.authorize(e, fe); err != nil {
+					if r.Authorization.Policy == DropUnauthorized {
+						err.Locations = []errors.Location{field.Alias.Loc}
+						r.AddError(err)
+						continue
+					}
+					flattenedSels = append(flattenedSels, &SchemaField{
+						Field:        *fe,
+						Alias:        field.Alias.Name,
+						Loc:          field.Alias.Loc,
+						Unauthorized: err,
+					})
+					continue
+				}
+
 				var args map[string]interface{}
 				var packedArgs reflect.Value
-				if fe.ArgsPacker != nil {
+				if fe.ArgsPacker != nil || fe.Resolve != nil {
 					args = make(map[string]interface{})
 					for _, arg := range field.Arguments {
+						if common.IsUnsetVariable(arg.Value, r.Vars) {
+							continue
+						}
 						args[arg.Name.Name] = arg.Value.Value(r.Vars)
 					}
+				}
+				if fe.ArgsPacker != nil {
 					var err error
 					packedArgs, err = fe.ArgsPacker.Pack(args)
 					if err != nil {
@@ -144,23 +262,41 @@ func applySelectionSet(r *Request, s *resolvable.Schema, e *resolvable.Object, s
 				flattenedSels = append(flattenedSels, &SchemaField{
 					Field:      *fe,
 					Alias:      field.Alias.Name,
+					Loc:        field.Alias.Loc,
 					Args:       args,
 					PackedArgs: packedArgs,
 					Sels:       fieldSels,
-					Async:      fe.HasContext || fe.ArgsPacker != nil || fe.HasError || HasAsyncSel(fieldSels),
+					Async:      isAsync(fe, fieldSels),
+					Stream:     streamByDirective(r, s, fe.Type, field.Directives),
+					Directives: coerceDirectives(r, s, field.Directives),
+					Priority:   priorityByDirective(r, s, fe.Directives, field.Directives),
 				})
 			}
 
 		case *query.InlineFragment:
 			frag := sel
-			if skipByDirective(r, frag.Directives) {
+			if skipByDirective(r, s, frag.Directives, inlineFragmentName(frag), frag.Loc) {
+				continue
+			}
+			if label, ok := deferByDirective(r, s, frag.Directives); ok {
+				flattenedSels = append(flattenedSels, &DeferredFragment{
+					Label: label,
+					Sels:  applyFragment(r, s, e, &frag.Fragment),
+				})
 				continue
 			}
 			flattenedSels = append(flattenedSels, applyFragment(r, s, e, &frag.Fragment)...)
 
 		case *query.FragmentSpread:
 			spread := sel
-			if skipByDirective(r, spread.Directives) {
+			if skipByDirective(r, s, spread.Directives, "..."+spread.Name.Name, spread.Loc) {
+				continue
+			}
+			if label, ok := deferByDirective(r, s, spread.Directives); ok {
+				flattenedSels = append(flattenedSels, &DeferredFragment{
+					Label: label,
+					Sels:  applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment),
+				})
 				continue
 			}
 			flattenedSels = append(flattenedSels, applyFragment(r, s, e, &r.Doc.Fragments.Get(spread.Name.Name).Fragment)...)
@@ -184,10 +320,7 @@ func applyFragment(r *Request, s *resolvable.Schema, e *resolvable.Object, frag
 			if !ok {
 				panic(fmt.Errorf("unknown type assertion for fragment %q", frag.On.Name))
 			}
-			return []Selection{&TypeAssertion{
-				TypeAssertion: *ta,
-				Sels:          applySelectionSet(r, s, ta.TypeExec.(*resolvable.Object), frag.Selections),
-			}}
+			return []Selection{newTypeAssertion(ta, applySelectionSet(r, s, ta.TypeExec.(*resolvable.Object), frag.Selections))}
 		}
 		// Otherwise, the parent can be a union or an object.
 		// If it's an object, just apply the selection set.
@@ -216,10 +349,7 @@ func applyFragment(r *Request, s *resolvable.Schema, e *resolvable.Object, frag
 			}
 			// Need to do a type assertion first, on a union, only one of the types matches,
 			// so N - 1 other types won't match and should not be selected.
-			return []Selection{&TypeAssertion{
-				TypeAssertion: *ta,
-				Sels:          applySelectionSet(r, s, ta.TypeExec.(*resolvable.Object), frag.Selections),
-			}}
+			return []Selection{newTypeAssertion(ta, applySelectionSet(r, s, ta.TypeExec.(*resolvable.Object), frag.Selections))}
 		}
 
 		// The fragment type needs to be an interface on a union at this point,
@@ -269,10 +399,7 @@ func applyFragment(r *Request, s *resolvable.Schema, e *resolvable.Object, frag
 			if !ok {
 				panic(fmt.Errorf("unknown type assertion for fragment %q", frag.On.Name))
 			}
-			selections = append(selections, &TypeAssertion{
-				TypeAssertion: *ta,
-				Sels:          applySelectionSet(r, s, ta.TypeExec.(*resolvable.Object), frag.Selections),
-			})
+			selections = append(selections, newTypeAssertion(ta, applySelectionSet(r, s, ta.TypeExec.(*resolvable.Object), frag.Selections)))
 		}
 		return selections
 	}
@@ -292,32 +419,297 @@ func applyField(r *Request, s *resolvable.Schema, e resolvable.Resolvable, sels
 	}
 }
 
-func skipByDirective(r *Request, directives common.DirectiveList) bool {
-	if d := directives.Get("skip"); d != nil {
-		p := packer.ValuePacker{ValueType: reflect.TypeOf(false)}
-		v, err := p.Pack(d.Args.MustGet("if").Value(r.Vars))
+// skipByDirective reports whether the selection with the given name and location is skipped by
+// one of its directives, and records it if r.RecordSkipped is set.
+func skipByDirective(r *Request, s *resolvable.Schema, directives common.DirectiveList, name string, loc errors.Location) bool {
+	for _, d := range directives {
+		h, ok := r.DirectiveHandlers[d.Name.Name]
+		if !ok {
+			continue
+		}
+		args, err := s.DirectiveArgs(d, r.Vars)
 		if err != nil {
 			r.AddError(errors.Errorf("%s", err))
+			continue
 		}
-		if err == nil && v.Bool() {
+		skip, err := h(args)
+		if err != nil {
+			r.AddError(errors.Errorf("%s", err))
+			continue
+		}
+		if skip {
+			r.recordSkipped(name, loc, d.Name.Name, args, "skipped by the handler of the directive")
 			return true
 		}
 	}
 
-	if d := directives.Get("include"); d != nil {
-		p := packer.ValuePacker{ValueType: reflect.TypeOf(false)}
-		v, err := p.Pack(d.Args.MustGet("if").Value(r.Vars))
-		if err != nil {
-			r.AddError(errors.Errorf("%s", err))
+	if _, ok := r.DirectiveHandlers["skip"]; !ok {
+		if d := directives.Get("skip"); d != nil {
+			args, err := s.DirectiveArgs(d, r.Vars)
+			if err != nil {
+				r.AddError(errors.Errorf("%s", err))
+			}
+			if err == nil && args["if"].(bool) {
+				r.recordSkipped(name, loc, "skip", args, `"if" is true`)
+				return true
+			}
 		}
-		if err == nil && !v.Bool() {
-			return true
+	}
+
+	if _, ok := r.DirectiveHandlers["include"]; !ok {
+		if d := directives.Get("include"); d != nil {
+			args, err := s.DirectiveArgs(d, r.Vars)
+			if err != nil {
+				r.AddError(errors.Errorf("%s", err))
+			}
+			if err == nil && !args["if"].(bool) {
+				r.recordSkipped(name, loc, "include", args, `"if" is false`)
+				return true
+			}
 		}
 	}
 
 	return false
 }
 
+func (r *Request) recordSkipped(name string, loc errors.Location, directive string, args map[string]interface{}, reason string) {
+	if !r.RecordSkipped {
+		return
+	}
+	skipped := SkippedSelection{Selection: name, Directive: directive, Reason: reason, Loc: loc}
+	if v, ok := args["if"].(bool); ok {
+		skipped.If = &v
+	}
+	r.Mu.Lock()
+	r.Skipped = append(r.Skipped, skipped)
+	r.Mu.Unlock()
+}
+
+func inlineFragmentName(frag *query.InlineFragment) string {
+	if frag.On.Name == "" {
+		return "..."
+	}
+	return "... on " + frag.On.Name
+}
+
+// Fields returns the fields selected by sels, the child selections of a field, as passed to
+// resolvers that take a resolvable.SelectedFields parameter. A field selected several times under
+// the same alias is listed once.
+func Fields(sels []Selection) resolvable.SelectedFields {
+	var fields resolvable.SelectedFields
+	collectSelectedFields(sels, &fields, "")
+	return fields
+}
+
+// collectSelectedFields adds the fields of sels to fields, to ByType if typeName is set.
+func collectSelectedFields(sels []Selection, fields *resolvable.SelectedFields, typeName string) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *SchemaField:
+			f := resolvable.SelectedField{Name: sel.Name, Alias: sel.Alias}
+			if typeName == "" {
+				fields.Fields = appendSelectedField(fields.Fields, f)
+				continue
+			}
+			if fields.ByType == nil {
+				fields.ByType = make(map[string][]resolvable.SelectedField)
+			}
+			fields.ByType[typeName] = appendSelectedField(fields.ByType[typeName], f)
+		case *TypeAssertion:
+			collectSelectedFields(sel.Sels, fields, sel.TypeExec.(*resolvable.Object).Name)
+		case *DeferredFragment:
+			collectSelectedFields(sel.Sels, fields, typeName)
+		}
+	}
+}
+
+func appendSelectedField(fields []resolvable.SelectedField, f resolvable.SelectedField) []resolvable.SelectedField {
+	for _, existing := range fields {
+		if existing.Alias == f.Alias {
+			return fields
+		}
+	}
+	return append(fields, f)
+}
+
+// FragmentTypes returns, for each field of an interface or union type in sels, the sorted names of
+// the concrete types targeted by its type-conditioned selections. The fields are keyed by their
+// response path, with the aliases separated by dots.
+func FragmentTypes(sels []Selection) map[string][]string {
+	types := make(map[string]map[string]bool)
+	collectFragmentTypes(sels, "", types)
+
+	res := make(map[string][]string, len(types))
+	for path, names := range types {
+		list := make([]string, 0, len(names))
+		for name := range names {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		res[path] = list
+	}
+	return res
+}
+
+func collectFragmentTypes(sels []Selection, prefix string, types map[string]map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *SchemaField:
+			path := prefix + sel.Alias
+			switch namedType(sel.Type).(type) {
+			case *schema.Interface, *schema.Union:
+				if types[path] == nil {
+					types[path] = make(map[string]bool)
+				}
+				collectTypeAssertions(sel.Sels, types[path])
+			}
+			collectFragmentTypes(sel.Sels, path+".", types)
+		case *TypeAssertion:
+			collectFragmentTypes(sel.Sels, prefix, types)
+		case *DeferredFragment:
+			collectFragmentTypes(sel.Sels, prefix, types)
+		}
+	}
+}
+
+func collectTypeAssertions(sels []Selection, names map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *TypeAssertion:
+			names[sel.TypeExec.(*resolvable.Object).Name] = true
+		case *DeferredFragment:
+			collectTypeAssertions(sel.Sels, names)
+		}
+	}
+}
+
+func namedType(t common.Type) common.Type {
+	for {
+		switch t2 := t.(type) {
+		case *common.List:
+			t = t2.OfType
+		case *common.NonNull:
+			t = t2.OfType
+		default:
+			return t
+		}
+	}
+}
+
+func coerceDirectives(r *Request, s *resolvable.Schema, directives common.DirectiveList) []Directive {
+	if len(directives) == 0 {
+		return nil
+	}
+	res := make([]Directive, 0, len(directives))
+	for _, d := range directives {
+		args, err := s.DirectiveArgs(d, r.Vars)
+		if err != nil {
+			r.AddError(errors.Errorf("%s", err))
+			continue
+		}
+		res = append(res, Directive{Name: d.Name.Name, Args: args})
+	}
+	return res
+}
+
+// deferByDirective reports whether a fragment with the given directives is deferred and returns
+// the label of its @defer directive.
+func deferByDirective(r *Request, s *resolvable.Schema, directives common.DirectiveList) (string, bool) {
+	if !r.Incremental {
+		return "", false
+	}
+	if _, ok := r.Schema.Directives["defer"]; !ok {
+		return "", false
+	}
+	d := directives.Get("defer")
+	if d == nil {
+		return "", false
+	}
+	args, err := s.DirectiveArgs(d, r.Vars)
+	if err != nil {
+		r.AddError(errors.Errorf("%s", err))
+		return "", false
+	}
+	if enabled, ok := args["if"].(bool); ok && !enabled {
+		return "", false
+	}
+	label, _ := args["label"].(string)
+	return label, true
+}
+
+// streamByDirective returns the arguments of the @stream directive of a field of the given type,
+// or nil if the field is not streamed.
+func streamByDirective(r *Request, s *resolvable.Schema, t common.Type, directives common.DirectiveList) *Stream {
+	if !r.Incremental {
+		return nil
+	}
+	if nn, ok := t.(*common.NonNull); ok {
+		t = nn.OfType
+	}
+	if _, ok := t.(*common.List); !ok {
+		return nil
+	}
+	if _, ok := r.Schema.Directives["stream"]; !ok {
+		return nil
+	}
+	d := directives.Get("stream")
+	if d == nil {
+		return nil
+	}
+	args, err := s.DirectiveArgs(d, r.Vars)
+	if err != nil {
+		r.AddError(errors.Errorf("%s", err))
+		return nil
+	}
+	if enabled, ok := args["if"].(bool); ok && !enabled {
+		return nil
+	}
+	initialCount, _ := args["initialCount"].(int32)
+	if initialCount < 0 {
+		r.AddError(errors.Errorf("directive %q argument %q must not be negative", "@stream", "initialCount"))
+		return nil
+	}
+	label, _ := args["label"].(string)
+	return &Stream{Label: label, InitialCount: int(initialCount)}
+}
+
+// priorityByDirective returns the weight of the @priority directive applied to a field in the query,
+// or else to its definition, if the schema declares the directive. It is 0 otherwise.
+func priorityByDirective(r *Request, s *resolvable.Schema, defDirectives common.DirectiveList, directives common.DirectiveList) int {
+	if _, ok := r.Schema.Directives["priority"]; !ok {
+		return 0
+	}
+	d := directives.Get("priority")
+	if d == nil {
+		d = defDirectives.Get("priority")
+	}
+	if d == nil {
+		return 0
+	}
+	args, err := s.DirectiveArgs(d, r.Vars)
+	if err != nil {
+		r.AddError(errors.Errorf("%s", err))
+		return 0
+	}
+	weight, _ := args["weight"].(int32)
+	return int(weight)
+}
+
+// isAsync reports whether a field with the given sub-selections is resolved asynchronously.
+func isAsync(fe *resolvable.Field, sels []Selection) bool {
+	return fe.HasContext || fe.ArgsPacker != nil || fe.HasError || fe.Resolve != nil || HasAsyncSel(sels)
+}
+
+func newTypeAssertion(ta *resolvable.TypeAssertion, sels []Selection) *TypeAssertion {
+	return &TypeAssertion{
+		TypeAssertion: *ta,
+		Sels:          sels,
+		Async:         HasAsyncSel(sels),
+	}
+}
+
+// HasAsyncSel reports whether any of the selections is resolved asynchronously. It does not walk
+// the sub-selections, since the fields and type assertions cache the result for theirs.
 func HasAsyncSel(sels []Selection) bool {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
@@ -326,11 +718,14 @@ func HasAsyncSel(sels []Selection) bool {
 				return true
 			}
 		case *TypeAssertion:
-			if HasAsyncSel(sel.Sels) {
+			if sel.Async {
 				return true
 			}
-		case *TypenameField:
+		case *TypenameField, *DeferredFragment:
 			// sync
+		case *unbound:
+			// not known before Bind
+			return true
 		default:
 			panic("unreachable")
 		}
@@ -0,0 +1,139 @@
+package selected_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+type queryResolver struct{}
+
+func (*queryResolver) Node() *nodeResolver { return nil }
+
+type nodeResolver struct{}
+
+func (*nodeResolver) Name() string                    { return "" }
+func (*nodeResolver) Node() *nodeResolver             { return nil }
+func (r *nodeResolver) ToItem() (*nodeResolver, bool) { return r, true }
+
+const nodeSchema = `
+	schema {
+		query: Query
+	}
+
+	type Query {
+		node: Node
+	}
+
+	interface Node {
+		name: String!
+		node: Node
+	}
+
+	type Item implements Node {
+		name: String!
+		node: Node
+	}
+`
+
+func BenchmarkApplyOperationDeep(b *testing.B) {
+	s := schema.New()
+	if err := s.Parse(nodeSchema, false); err != nil {
+		b.Fatal(err)
+	}
+	rs, err := resolvable.ApplyResolver(s, &queryResolver{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	const depth = 200
+	queryString := "{ " + strings.Repeat("node { name ... on Item { ", depth) + "name" + strings.Repeat(" } }", depth) + " }"
+	doc, qErr := query.Parse(queryString)
+	if qErr != nil {
+		b.Fatal(qErr)
+	}
+	op := doc.Operations[0]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := &selected.Request{Schema: s, Doc: doc}
+		sels := selected.ApplyOperation(r, rs, op)
+		if len(r.Errs) != 0 {
+			b.Fatal(r.Errs)
+		}
+		if selected.HasAsyncSel(sels) {
+			b.Fatal("unexpected async selection")
+		}
+	}
+}
+
+func TestRecordSkipped(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(nodeSchema, false); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := resolvable.ApplyResolver(s, &queryResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, qErr := query.Parse(`
+		query($skip: Boolean!) {
+			node @skip(if: $skip) {
+				name
+			}
+			other: node @include(if: false) {
+				name
+			}
+			node {
+				... on Item @include(if: true) {
+					name
+				}
+				...itemFields @skip(if: false)
+				...itemFields @include(if: false)
+			}
+		}
+
+		fragment itemFields on Item {
+			name
+		}
+	`)
+	if qErr != nil {
+		t.Fatal(qErr)
+	}
+
+	vars := map[string]interface{}{"skip": true}
+	r := &selected.Request{Schema: s, Doc: doc, Vars: vars, RecordSkipped: true}
+	selected.ApplyOperation(r, rs, doc.Operations[0])
+	if len(r.Errs) != 0 {
+		t.Fatal(r.Errs)
+	}
+
+	yes, no := true, false
+	want := []selected.SkippedSelection{
+		{Selection: "node", Directive: "skip", If: &yes, Reason: `"if" is true`},
+		{Selection: "other", Directive: "include", If: &no, Reason: `"if" is false`},
+		{Selection: "...itemFields", Directive: "include", If: &no, Reason: `"if" is false`},
+	}
+	if len(r.Skipped) != len(want) {
+		t.Fatalf("got %d skipped selections, want %d: %+v", len(r.Skipped), len(want), r.Skipped)
+	}
+	for i, got := range r.Skipped {
+		got.Loc = want[i].Loc
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("skipped selection #%d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	r = &selected.Request{Schema: s, Doc: doc, Vars: vars}
+	selected.ApplyOperation(r, rs, doc.Operations[0])
+	if r.Skipped != nil {
+		t.Errorf("expected no recordings without RecordSkipped, got %+v", r.Skipped)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/graph-gophers/graphql-go/errors"
 	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/packer"
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
@@ -29,7 +30,7 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *query
 
 		sels := selected.ApplyOperation(&r.Request, s, op)
 		var fields []*fieldToExec
-		collectFieldsToResolve(sels, s, s.Resolver, &fields, make(map[string]*fieldToExec))
+		collectFieldsToResolve(sels, s, s.Resolver, &fields, make(map[string]*fieldToExec), nil)
 
 		// TODO: move this check into validation.Validate
 		if len(fields) != 1 {
@@ -40,18 +41,19 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *query
 
 		var in []reflect.Value
 		if f.field.HasContext {
-			in = append(in, reflect.ValueOf(ctx))
+			in = append(in, reflect.ValueOf(withFieldInfo(ctx, f.field, fieldSegment(nil, f.field))))
 		}
 		if f.field.ArgsPacker != nil {
-			in = append(in, f.field.PackedArgs)
+			in = append(in, packer.DeepCopy(f.field.PackedArgs))
+		}
+		if f.field.HasSelectedFields {
+			in = append(in, reflect.ValueOf(selected.Fields(f.sels)))
 		}
 		callOut := f.resolver.Method(f.field.MethodIndex).Call(in)
 		result = callOut[0]
 
 		if f.field.HasError && !callOut[1].IsNil() {
-			resolverErr := callOut[1].Interface().(error)
-			err = errors.Errorf("%s", resolverErr)
-			err.ResolverError = resolverErr
+			err = r.resolverError(callOut[1].Interface().(error), nil, f.field)
 		}
 	}()
 
@@ -105,13 +107,19 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *query
 
 				subR := &Request{
 					Request: selected.Request{
-						Doc:    r.Request.Doc,
-						Vars:   r.Request.Vars,
-						Schema: r.Request.Schema,
+						Doc:             r.Request.Doc,
+						Vars:            r.Request.Vars,
+						Schema:          r.Request.Schema,
+						ErrorExtensions: r.Request.ErrorExtensions,
 					},
-					Limiter: r.Limiter,
-					Tracer:  r.Tracer,
-					Logger:  r.Logger,
+					Limiter:               r.Limiter,
+					GlobalLimiter:         r.GlobalLimiter,
+					Tracer:                r.Tracer,
+					Logger:                r.Logger,
+					NonFiniteFloatsAsNull: r.NonFiniteFloatsAsNull,
+					IncludeErrorLocations: r.IncludeErrorLocations,
+					NonNullError:          r.NonNullError,
+					ResolverErrorMapper:   r.ResolverErrorMapper,
 				}
 				var out bytes.Buffer
 				func() {
@@ -124,7 +132,7 @@ func (r *Request) Subscribe(ctx context.Context, s *resolvable.Schema, op *query
 						defer subR.handlePanic(subCtx)
 
 						var buf bytes.Buffer
-						subR.execSelectionSet(subCtx, f.sels, f.field.Type, &pathSegment{nil, f.field.Alias}, s, resp, &buf)
+						subR.execSelectionSet(subCtx, f.sels, f.field.Type, fieldSegment(nil, f.field), s, resp, &buf)
 
 						propagateChildError := false
 						if _, nonNullChild := f.field.Type.(*common.NonNull); nonNullChild && resolvedToNull(&buf) {
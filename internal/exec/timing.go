@@ -0,0 +1,93 @@
+package exec
+
+import (
+	"sync"
+	"time"
+)
+
+// TimingNode is a node of the timing tree of a request. The root node stands for the execution of
+// the operation and has the root fields as children, every other node stands for a resolved field
+// and has the fields selected on its value as children, in the order of the query.
+type TimingNode struct {
+	// Path is the response path of the field. It is nil for the root node.
+	Path       []interface{}
+	TypeName   string
+	FieldName  string
+	ReturnType string
+	// Async is set if the resolver of the field ran in its own goroutine.
+	Async bool
+	// StartOffset is the time between the start of the execution and the start of the field.
+	StartOffset time.Duration
+	// Duration is the time spent resolving the field, including the fields below it.
+	Duration time.Duration
+	Children []*TimingNode
+}
+
+// Timing builds the timing tree of a request from the same path and timing instrumentation that is
+// used for tracing.
+type Timing struct {
+	mu    sync.Mutex
+	start time.Time
+	root  *TimingNode
+	nodes map[*pathSegment]*TimingNode
+}
+
+// Tree returns the root of the timing tree. It must only be called once the request is executed.
+func (t *Timing) Tree() *TimingNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.root
+}
+
+func (t *Timing) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = time.Now()
+	t.root = &TimingNode{}
+	t.nodes = make(map[*pathSegment]*TimingNode)
+}
+
+func (t *Timing) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.root.Duration = time.Since(t.start)
+}
+
+// addFields adds nodes for fields to the node of the field that path belongs to, so that the
+// children are in the order of the query regardless of the order in which they are resolved.
+func (t *Timing) addFields(path *pathSegment, fields []*fieldToExec) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent := t.root
+	for p := path; p != nil; p = p.parent {
+		if n, ok := t.nodes[p]; ok {
+			parent = n
+			break
+		}
+	}
+	for _, f := range fields {
+		f.timing = &TimingNode{
+			TypeName:   f.field.TypeName,
+			FieldName:  f.field.Name,
+			ReturnType: f.field.Type.String(),
+			Async:      f.field.Async,
+		}
+		parent.Children = append(parent.Children, f.timing)
+	}
+}
+
+// startField records the start of the field of node at path. The returned function records its
+// end.
+func (t *Timing) startField(node *TimingNode, path *pathSegment) func() {
+	start := time.Now()
+	t.mu.Lock()
+	t.nodes[path] = node
+	node.Path = path.toSlice()
+	node.StartOffset = start.Sub(t.start)
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		node.Duration = time.Since(start)
+		t.mu.Unlock()
+	}
+}
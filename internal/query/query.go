@@ -94,7 +94,14 @@ func (InlineFragment) isSelection() {}
 func (FragmentSpread) isSelection() {}
 
 func Parse(queryString string) (*Document, *errors.QueryError) {
+	return ParseMaxTokens(queryString, 0)
+}
+
+// ParseMaxTokens is like Parse, but fails with a syntax error as soon as the lexer scans more than
+// maxTokens tokens, without parsing the rest of the query. There is no limit if maxTokens is 0.
+func ParseMaxTokens(queryString string, maxTokens int) (*Document, *errors.QueryError) {
 	l := common.NewLexer(queryString, false)
+	l.SetMaxTokens(maxTokens)
 
 	var doc *Document
 	err := l.CatchSyntaxError(func() { doc = parseDocument(l) })
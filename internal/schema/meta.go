@@ -51,7 +51,10 @@ var metaSrc = `
 		# for how to access supported similar data. Formatted in
 		# [Markdown](https://daringfireball.net/projects/markdown/).
 		reason: String = "No longer supported"
-	) on FIELD_DEFINITION | ENUM_VALUE
+	) on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION | ENUM_VALUE
+
+	# Indicates exactly one field must be supplied and this field must not be ` + "`" + `null` + "`" + `.
+	directive @oneOf on INPUT_OBJECT
 
 	# A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document.
 	#
@@ -137,6 +140,8 @@ var metaSrc = `
 		type: __Type!
 		# A GraphQL-formatted string representing the default value for this input value.
 		defaultValue: String
+		isDeprecated: Boolean!
+		deprecationReason: String
 	}
 
 	# A GraphQL Schema defines the capabilities of a GraphQL server. It exposes all
@@ -173,6 +178,7 @@ var metaSrc = `
 		enumValues(includeDeprecated: Boolean = false): [__EnumValue!]
 		inputFields: [__InputValue!]
 		ofType: __Type
+		isOneOf: Boolean
 	}
 
 	# An enum describing what kind of type a given ` + "`" + `__Type` + "`" + ` is.
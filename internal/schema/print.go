@@ -0,0 +1,254 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go/internal/common"
+)
+
+// builtinTypes are the scalars that every schema declares implicitly.
+var builtinTypes = map[string]bool{
+	"Int":     true,
+	"Float":   true,
+	"String":  true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// builtinDirectives are the directives that every schema declares implicitly.
+var builtinDirectives = map[string]bool{
+	"include":    true,
+	"skip":       true,
+	"deprecated": true,
+	"oneOf":      true,
+}
+
+// Fprint writes the schema in the schema definition language to w. Built-in types and directives
+// are omitted, the other ones are written in alphabetical order. Descriptions are written as
+// strings, or as block strings if they span multiple lines. Arguments of directive applications
+// that were filled in with their defaults are omitted. The first error returned by w is returned.
+func (s *Schema) Fprint(w io.Writer) error {
+	p := &printer{w: w, decls: s.Directives}
+
+	if len(s.EntryPoints) != 0 {
+		p.printf("schema")
+		p.directives(s.SchemaDirectives)
+		p.printf(" {\n")
+		for _, op := range []string{"query", "mutation", "subscription"} {
+			if t, ok := s.EntryPoints[op]; ok {
+				p.printf("  %s: %s\n", op, t.TypeName())
+			}
+		}
+		p.printf("}\n")
+	}
+
+	directiveNames := make([]string, 0, len(s.Directives))
+	for name := range s.Directives {
+		if !builtinDirectives[name] {
+			directiveNames = append(directiveNames, name)
+		}
+	}
+	sort.Strings(directiveNames)
+	for _, name := range directiveNames {
+		d := s.Directives[name]
+		p.printf("\n")
+		p.description(d.Desc, "")
+		p.printf("directive @%s", d.Name)
+		p.arguments(d.Args, "")
+		p.printf(" on %s\n", strings.Join(d.Locs, " | "))
+	}
+
+	typeNames := make([]string, 0, len(s.Types))
+	for name := range s.Types {
+		if !builtinTypes[name] && !strings.HasPrefix(name, "__") {
+			typeNames = append(typeNames, name)
+		}
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		p.printf("\n")
+		p.namedType(s.Types[name])
+	}
+
+	return p.err
+}
+
+// printer writes to w until the first error.
+type printer struct {
+	w   io.Writer
+	err error
+	// decls are the declarations of the directives, whose default arguments are omitted from
+	// directive applications.
+	decls map[string]*DirectiveDecl
+}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func (p *printer) namedType(t NamedType) {
+	p.description(t.Description(), "")
+	switch t := t.(type) {
+	case *Scalar:
+		p.printf("scalar %s", t.Name)
+		p.directives(t.Directives)
+		p.printf("\n")
+
+	case *Object:
+		p.printf("type %s", t.Name)
+		if len(t.Interfaces) != 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, iface := range t.Interfaces {
+				names[i] = iface.Name
+			}
+			p.printf(" implements %s", strings.Join(names, " & "))
+		}
+		p.directives(t.Directives)
+		p.fields(t.Fields)
+
+	case *Interface:
+		p.printf("interface %s", t.Name)
+		p.directives(t.Directives)
+		p.fields(t.Fields)
+
+	case *Union:
+		p.printf("union %s", t.Name)
+		p.directives(t.Directives)
+		names := make([]string, len(t.PossibleTypes))
+		for i, o := range t.PossibleTypes {
+			names[i] = o.Name
+		}
+		p.printf(" = %s\n", strings.Join(names, " | "))
+
+	case *Enum:
+		p.printf("enum %s", t.Name)
+		p.directives(t.Directives)
+		p.printf(" {\n")
+		for _, v := range t.Values {
+			p.description(v.Desc, "  ")
+			p.printf("  %s", v.Name)
+			p.directives(v.Directives)
+			p.printf("\n")
+		}
+		p.printf("}\n")
+
+	case *InputObject:
+		p.printf("input %s", t.Name)
+		p.directives(t.Directives)
+		p.printf(" {\n")
+		for _, v := range t.Values {
+			p.description(v.Desc, "  ")
+			p.printf("  ")
+			p.inputValue(v)
+			p.printf("\n")
+		}
+		p.printf("}\n")
+	}
+}
+
+func (p *printer) fields(fields FieldList) {
+	p.printf(" {\n")
+	for _, f := range fields {
+		p.description(f.Desc, "  ")
+		p.printf("  %s", f.Name)
+		p.arguments(f.Args, "  ")
+		p.printf(": %s", f.Type)
+		p.directives(f.Directives)
+		p.printf("\n")
+	}
+	p.printf("}\n")
+}
+
+// arguments writes the arguments on one line, or one per line if any of them has a description.
+func (p *printer) arguments(args common.InputValueList, indent string) {
+	if len(args) == 0 {
+		return
+	}
+	multiline := false
+	for _, arg := range args {
+		if arg.Desc != "" {
+			multiline = true
+		}
+	}
+
+	p.printf("(")
+	for i, arg := range args {
+		if multiline {
+			p.printf("\n")
+			p.description(arg.Desc, indent+"  ")
+			p.printf("%s  ", indent)
+		} else if i > 0 {
+			p.printf(", ")
+		}
+		p.inputValue(arg)
+	}
+	if multiline {
+		p.printf("\n%s", indent)
+	}
+	p.printf(")")
+}
+
+func (p *printer) inputValue(v *common.InputValue) {
+	p.printf("%s: %s", v.Name.Name, v.Type)
+	if v.Default != nil {
+		p.printf(" = %s", v.Default)
+	}
+	p.directives(v.Directives)
+}
+
+func (p *printer) directives(directives common.DirectiveList) {
+	for _, d := range directives {
+		p.printf(" @%s", d.Name.Name)
+		var args []string
+		for _, arg := range d.Args {
+			// Arguments that were omitted from the source have the default of the declaration as
+			// value, or no value if there is none.
+			if arg.Value == nil || p.isDefault(d.Name.Name, arg) {
+				continue
+			}
+			args = append(args, arg.Name.Name+": "+arg.Value.String())
+		}
+		if len(args) > 0 {
+			p.printf("(%s)", strings.Join(args, ", "))
+		}
+	}
+}
+
+// isDefault reports whether the argument of the directive was filled in with the default of its
+// declaration when the schema was parsed, as opposed to being given with the same value.
+func (p *printer) isDefault(directive string, arg common.Argument) bool {
+	decl, ok := p.decls[directive]
+	if !ok {
+		return false
+	}
+	v := decl.Args.Get(arg.Name.Name)
+	return v != nil && v.Default != nil && v.Default == arg.Value
+}
+
+func (p *printer) description(desc string, indent string) {
+	if desc == "" {
+		return
+	}
+	if !strings.Contains(desc, "\n") {
+		desc = strings.Replace(desc, `\`, `\\`, -1)
+		desc = strings.Replace(desc, `"`, `\"`, -1)
+		p.printf("%s\"%s\"\n", indent, desc)
+		return
+	}
+	desc = strings.Replace(desc, `"""`, `\"""`, -1)
+	p.printf("%s\"\"\"\n", indent)
+	for _, line := range strings.Split(desc, "\n") {
+		if line == "" {
+			p.printf("\n")
+			continue
+		}
+		p.printf("%s%s\n", indent, line)
+	}
+	p.printf("%s\"\"\"\n", indent)
+}
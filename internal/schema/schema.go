@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"strings"
 	"text/scanner"
 
 	"github.com/graph-gophers/graphql-go/errors"
@@ -41,8 +42,22 @@ type Schema struct {
 	// http://facebook.github.io/graphql/draft/#sec-Type-System.Directives
 	Directives map[string]*DirectiveDecl
 
+	// SchemaDirectives are the directives applied to the schema definition.
+	SchemaDirectives common.DirectiveList
+
 	UseFieldResolvers bool
 
+	// StrictCoercion rejects lossy coercions of input values instead of applying them.
+	StrictCoercion bool
+
+	// MaxStringLength is the default maximum length in bytes of string input values. Zero means
+	// no limit.
+	MaxStringLength int
+
+	// MaxInputDepth is the maximum nesting depth of input objects in the arguments of a field.
+	// Zero means no limit.
+	MaxInputDepth int
+
 	entryPointNames map[string]string
 	objects         []*Object
 	unions          []*Union
@@ -55,6 +70,48 @@ func (s *Schema) Resolve(name string) common.Type {
 	return s.Types[name]
 }
 
+// FieldCostDirective returns the @cost directive of the field f of t. If the field has none and t is
+// an object, the directive of the same field of the first interface of t that has one is returned.
+func FieldCostDirective(t NamedType, f *Field) *common.Directive {
+	if d := f.Directives.Get("cost"); d != nil {
+		return d
+	}
+	if obj, ok := t.(*Object); ok {
+		for _, iface := range obj.Interfaces {
+			if ifaceF := iface.Fields.Get(f.Name); ifaceF != nil {
+				if d := ifaceF.Directives.Get("cost"); d != nil {
+					return d
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CostDirective returns the @cost directive that sets the complexity of the field f of t. It is
+// the directive of the field, see FieldCostDirective, else the one of t, else the one of the schema
+// definition. The @cost directive of an interface type does not apply to the fields of the objects
+// that implement it. Introspection fields have none.
+func (s *Schema) CostDirective(t NamedType, f *Field) *common.Directive {
+	if d := FieldCostDirective(t, f); d != nil {
+		return d
+	}
+	if strings.HasPrefix(f.Name, "__") || strings.HasPrefix(t.TypeName(), "__") {
+		return nil
+	}
+	switch t := t.(type) {
+	case *Object:
+		if d := t.Directives.Get("cost"); d != nil {
+			return d
+		}
+	case *Interface:
+		if d := t.Directives.Get("cost"); d != nil {
+			return d
+		}
+	}
+	return s.SchemaDirectives.Get("cost")
+}
+
 // NamedType represents a type with a name.
 //
 // http://facebook.github.io/graphql/draft/#NamedType
@@ -75,6 +132,14 @@ type Scalar struct {
 	Name       string
 	Desc       string
 	Directives common.DirectiveList
+	// Coercion converts input and output values of the scalar, if it is set.
+	Coercion ScalarCoercion
+}
+
+// ScalarCoercion converts the input and output values of a scalar type.
+type ScalarCoercion interface {
+	CoerceInput(value interface{}) (interface{}, error)
+	CoerceOutput(value interface{}) (interface{}, error)
 }
 
 // Object types represent a list of named fields, each of which yield a value of a specific type.
@@ -230,6 +295,10 @@ func (t *Union) Description() string       { return t.Desc }
 func (t *Enum) Description() string        { return t.Desc }
 func (t *InputObject) Description() string { return t.Desc }
 
+// IsOneOf reports whether the input object has the @oneOf directive, which requires exactly one
+// of its fields to be given with a non-null value.
+func (t *InputObject) IsOneOf() bool { return t.Directives.Get("oneOf") != nil }
+
 // Field is a conceptual function which yields values.
 // http://facebook.github.io/graphql/draft/#FieldDefinition
 type Field struct {
@@ -313,6 +382,10 @@ func (s *Schema) Parse(schemaString string, useStringDescriptions bool) error {
 			s.entryPointNames["subscription"] = "Subscription"
 		}
 	}
+	if err := resolveDirectives(s, s.SchemaDirectives, "SCHEMA"); err != nil {
+		return err
+	}
+
 	s.EntryPoints = make(map[string]NamedType)
 	for key, name := range s.entryPointNames {
 		t, ok := s.Types[name]
@@ -482,9 +555,22 @@ func resolveNamedType(s *Schema, t NamedType) error {
 			}
 		}
 	case *InputObject:
-		if err := resolveInputObject(s, t.Values); err != nil {
+		if err := resolveDirectives(s, t.Directives, "INPUT_OBJECT"); err != nil {
+			return err
+		}
+		if err := resolveInputObject(s, t.Values, "INPUT_FIELD_DEFINITION"); err != nil {
 			return err
 		}
+		if t.IsOneOf() {
+			for _, v := range t.Values {
+				if _, ok := v.Type.(*common.NonNull); ok {
+					return errors.Errorf("field %q of oneOf input object %q must be nullable", v.Name.Name, t.Name)
+				}
+				if v.Default != nil {
+					return errors.Errorf("field %q of oneOf input object %q must not have a default value", v.Name.Name, t.Name)
+				}
+			}
+		}
 	}
 	return nil
 }
@@ -498,7 +584,7 @@ func resolveField(s *Schema, f *Field) error {
 	if err := resolveDirectives(s, f.Directives, "FIELD_DEFINITION"); err != nil {
 		return err
 	}
-	return resolveInputObject(s, f.Args)
+	return resolveInputObject(s, f.Args, "ARGUMENT_DEFINITION")
 }
 
 func resolveDirectives(s *Schema, directives common.DirectiveList, loc string) error {
@@ -532,13 +618,16 @@ func resolveDirectives(s *Schema, directives common.DirectiveList, loc string) e
 	return nil
 }
 
-func resolveInputObject(s *Schema, values common.InputValueList) error {
+func resolveInputObject(s *Schema, values common.InputValueList, loc string) error {
 	for _, v := range values {
 		t, err := common.ResolveType(v.Type, s.Resolve)
 		if err != nil {
 			return err
 		}
 		v.Type = t
+		if err := resolveDirectives(s, v.Directives, loc); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -551,6 +640,7 @@ func parseSchema(s *Schema, l *common.Lexer) {
 		switch x := l.ConsumeIdent(); x {
 
 		case "schema":
+			s.SchemaDirectives = append(s.SchemaDirectives, common.ParseDirectives(l)...)
 			l.ConsumeToken('{')
 			for l.Peek() != '}' {
 				name := l.ConsumeIdent()
@@ -728,6 +818,7 @@ func parseDirectiveDef(l *common.Lexer) *DirectiveDecl {
 func parseExtension(s *Schema, l *common.Lexer) {
 	switch x := l.ConsumeIdent(); x {
 	case "schema":
+		s.SchemaDirectives = append(s.SchemaDirectives, common.ParseDirectives(l)...)
 		l.ConsumeToken('{')
 		for l.Peek() != '}' {
 			name := l.ConsumeIdent()
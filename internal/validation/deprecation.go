@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"fmt"
+	"text/scanner"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// DeprecationWarnings returns a warning for every use of a deprecated field, argument, input field
+// or enum value in the operations of the document. Fragments are followed where they are spread,
+// and the uses in a fragment are reported once, even if it is spread more than once. The warnings
+// do not fail a request, they are meant to be logged or returned to the client.
+func DeprecationWarnings(s *schema.Schema, doc *query.Document) []*errors.QueryError {
+	w := &deprecationWalker{reported: make(map[errors.Location]bool)}
+	for _, op := range doc.Operations {
+		// The walk stops at the fragments that can not be followed in an invalid document, whose
+		// errors are reported by the validation.
+		_ = Walk(s, doc, op, nil, w)
+	}
+	return w.warnings
+}
+
+type deprecationWalker struct {
+	// reported holds the locations of the warnings, so that the uses in a fragment are reported
+	// once.
+	reported map[errors.Location]bool
+	warnings []*errors.QueryError
+}
+
+func (w *deprecationWalker) EnterField(wf *WalkField) error {
+	sel, f := wf.Selection, wf.Field
+	if reason, ok := deprecationReason(f.Directives); ok {
+		w.warn("DeprecatedField", sel.Name.Loc, "Field %q is deprecated: %s", wf.ParentType.TypeName()+"."+f.Name, reason)
+	}
+	for _, arg := range sel.Arguments {
+		argDecl := f.Args.Get(arg.Name.Name)
+		if argDecl == nil {
+			continue
+		}
+		if reason, ok := deprecationReason(argDecl.Directives); ok {
+			w.warn("DeprecatedArgument", arg.Name.Loc, "Argument %q of field %q is deprecated: %s", arg.Name.Name, wf.ParentType.TypeName()+"."+f.Name, reason)
+		}
+		w.value(arg.Value, argDecl.Type)
+	}
+	return nil
+}
+
+func (w *deprecationWalker) LeaveField(*WalkField) error {
+	return nil
+}
+
+func (w *deprecationWalker) EnterFragment(*WalkFragment) error {
+	return nil
+}
+
+func (w *deprecationWalker) LeaveFragment(*WalkFragment) error {
+	return nil
+}
+
+// value reports the deprecated input fields and enum values in a literal of the given type.
+// Variables are not checked, since their values are not part of the document.
+func (w *deprecationWalker) value(v common.Literal, t common.Type) {
+	if nn, ok := t.(*common.NonNull); ok {
+		t = nn.OfType
+	}
+	switch t := t.(type) {
+	case *common.List:
+		if lit, ok := v.(*common.ListLit); ok {
+			for _, entry := range lit.Entries {
+				w.value(entry, t.OfType)
+			}
+			return
+		}
+		// A single value is coerced to a list.
+		w.value(v, t.OfType)
+
+	case *schema.InputObject:
+		lit, ok := v.(*common.ObjectLit)
+		if !ok {
+			return
+		}
+		for _, field := range lit.Fields {
+			fieldDecl := t.Values.Get(field.Name.Name)
+			if fieldDecl == nil {
+				continue
+			}
+			if reason, ok := deprecationReason(fieldDecl.Directives); ok {
+				w.warn("DeprecatedInputField", field.Name.Loc, "Input field %q is deprecated: %s", t.Name+"."+field.Name.Name, reason)
+			}
+			w.value(field.Value, fieldDecl.Type)
+		}
+
+	case *schema.Enum:
+		lit, ok := v.(*common.BasicLit)
+		if !ok || lit.Type != scanner.Ident {
+			return
+		}
+		for _, ev := range t.Values {
+			if ev.Name != lit.Text {
+				continue
+			}
+			if reason, ok := deprecationReason(ev.Directives); ok {
+				w.warn("DeprecatedEnumValue", lit.Loc, "Enum value %q is deprecated: %s", t.Name+"."+ev.Name, reason)
+			}
+		}
+	}
+}
+
+func (w *deprecationWalker) warn(rule string, loc errors.Location, format string, a ...interface{}) {
+	if w.reported[loc] {
+		return
+	}
+	w.reported[loc] = true
+	w.warnings = append(w.warnings, &errors.QueryError{
+		Message:   fmt.Sprintf(format, a...),
+		Locations: []errors.Location{loc},
+		Rule:      rule,
+	})
+}
+
+// deprecationReason returns the reason of the @deprecated directive in the list, if any.
+func deprecationReason(directives common.DirectiveList) (string, bool) {
+	d := directives.Get("deprecated")
+	if d == nil {
+		return "", false
+	}
+	reason := "No longer supported"
+	if lit, ok := d.Args.Get("reason"); ok && lit != nil {
+		if r, ok := lit.Value(nil).(string); ok {
+			reason = r
+		}
+	}
+	return reason, true
+}
@@ -1,6 +1,9 @@
 package validation
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/graph-gophers/graphql-go/internal/query"
@@ -22,6 +25,7 @@ directive @cost(
 	type Query {
 		characters: [FriendOrEnemy]! @cost(complexity: 1)
 		friend(id: ID!): Friend!
+		version: String!
 	}
 
 	interface Friend {
@@ -45,6 +49,12 @@ directive @cost(
 		name: String! @cost(complexity: 2)
 		friends(first: Int, last: Int): [Friend]! @cost(multipliers: ["first", "last"])
 		bestFriends(first: Int): FriendConnection! @cost(multipliers: ["first"], complexity: 3)
+		pagedFriends(page: Page): [Friend]! @cost(complexity: 1, multipliers: ["page.first"])
+	}
+
+	input Page {
+		first: Int
+		after: String
 	}`
 )
 
@@ -162,6 +172,82 @@ func TestCost(t *testing.T) {
 		`,
 			wantCost: 1 + 1,
 		},
+		{
+			name: "takes complexity from type over interface",
+			query: `
+			query {
+				characters {
+				  ... on Character {
+				    name
+				  }
+				}
+			  }
+		`,
+			wantCost: 2 + 1,
+		},
+		{
+			name: "takes complexity from type for interface fragment on type",
+			query: `
+			query {
+				characters {
+				  ... on Character {
+				    ... on Friend {
+				      name
+				    }
+				    ...FriendName
+				  }
+				}
+			  }
+
+			  fragment FriendName on Friend {
+				name
+			  }
+		`,
+			wantCost: 2 + 2 + 1,
+		},
+		{
+			name: "takes complexity from type for interface fragment in fragment on type",
+			query: `
+			query {
+				characters {
+				  ...CharacterFields
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				...FriendName
+			  }
+
+			  fragment FriendName on Friend {
+				name
+			  }
+		`,
+			wantCost: 2 + 1,
+		},
+		{
+			name: "takes complexity from type condition on interface",
+			query: `
+			query {
+				friend(id: "1") {
+				  ... on Character {
+				    name
+				  }
+				}
+			  }
+		`,
+			wantCost: 2,
+		},
+		{
+			name: "takes complexity from interface without type condition",
+			query: `
+			query {
+				friend(id: "1") {
+				  name
+				}
+			  }
+		`,
+			wantCost: 1,
+		},
 		{
 			name: "sums up multipliers",
 			query: `
@@ -258,6 +344,156 @@ func TestCost(t *testing.T) {
 		`,
 			wantCost: 1 + 2 + 1,
 		},
+		{
+			name: "charges every alias of a multiplier field",
+			query: `
+			query {
+				characters { # cost 1
+					... on Character {
+						a: friends(first: 100) { # cost 100 * 1
+							name
+						}
+						b: friends(first: 100) { # cost 100 * 1
+							name
+						}
+					}
+				}
+			}
+		`,
+			wantCost: 1 + 100 + 100,
+		},
+		{
+			name: "charges every alias in union fragments",
+			query: `
+			query {
+				characters { # cost 1
+					... on Character { # cost 10 + 10
+						a: friends(first: 10) {
+							name
+						}
+						b: friends(first: 10) {
+							name
+						}
+					}
+					... on Enemy { # cost 9
+						weapon
+					}
+				}
+			}
+		`,
+			wantCost: 1 + 10 + 10,
+		},
+		{
+			name: "charges every alias in interface fragments",
+			query: `
+			query {
+				friend {
+					a: name # cost 1
+					b: name # cost 1
+					... on Character { # cost 1 + 1
+						x: id
+						y: id
+					}
+					... on Enemy { # cost 9
+						weapon
+					}
+				}
+			}
+		`,
+			wantCost: 1 + 1 + 9,
+		},
+		{
+			name: "charges inline fragments without type condition",
+			query: `
+			query {
+				characters { # cost 1
+					... on Character {
+						id # cost 1
+						... @include(if: true) {
+							name # cost 2
+						}
+					}
+				}
+			}
+		`,
+			wantCost: 1 + 1 + 2,
+		},
+		{
+			name: "multiplier from input object field",
+			query: `
+			query {
+				characters { # cost 1
+					... on Character {
+						pagedFriends(page: {first: 5}) { # cost 1
+							name # cost 1 * 5
+						}
+					}
+				}
+			}
+		`,
+			wantCost: 1 + 1 + 5,
+		},
+		{
+			name: "multiplier from input object variable",
+			query: `
+			query ($page: Page) {
+				characters { # cost 1
+					... on Character {
+						pagedFriends(page: $page) { # cost 1
+							name # cost 1 * 5
+						}
+					}
+				}
+			}
+		`,
+			variables: map[string]interface{}{"page": map[string]interface{}{"first": float64(5)}},
+			wantCost:  1 + 1 + 5,
+		},
+		{
+			name: "multiplier from variable in input object",
+			query: `
+			query ($first: Int) {
+				characters { # cost 1
+					... on Character {
+						pagedFriends(page: {first: $first}) { # cost 1
+							name # cost 1 * 5
+						}
+					}
+				}
+			}
+		`,
+			variables: map[string]interface{}{"first": 5},
+			wantCost:  1 + 1 + 5,
+		},
+		{
+			name: "multiplier path without integer",
+			query: `
+			query {
+				characters { # cost 1
+					... on Character {
+						pagedFriends(page: {after: "abc"}) { # cost 1
+							name # cost 1
+						}
+					}
+				}
+			}
+		`,
+			wantCost: 1 + 1 + 1,
+		},
+		{
+			name: "charges skip without if argument",
+			query: `
+			query {
+				characters { # costs 1
+					... on Character {
+						id # costs 1
+						name @skip # costs 2, the missing argument is reported by validation
+					}
+				}
+			}
+			`,
+			wantCost: 1 + 1 + 2,
+		},
 		{
 			name: "doesn't charge for skip true",
 			query: `
@@ -406,6 +642,120 @@ func TestCost(t *testing.T) {
 			variables: map[string]interface{}{"include": false},
 			wantCost:  1 + 1,
 		},
+		{
+			name: "doesn't charge for skip true on fragment spread",
+			query: `
+			query {
+				characters { # costs 1
+					...CharacterFields @skip(if: true) # costs 1 + 2, but should be skipped
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				id
+				name
+			  }
+		`,
+			wantCost: 1,
+		},
+		{
+			name: "doesn't charge for include false on fragment spread",
+			query: `
+			query {
+				characters { # costs 1
+					...CharacterFields @include(if: false) # costs 1 + 2, but should not be included in cost
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				id
+				name
+			  }
+		`,
+			wantCost: 1,
+		},
+		{
+			name: "does charge for skip false and include true on fragment spread",
+			query: `
+			query {
+				characters { # costs 1
+					...CharacterFields @skip(if: false) @include(if: true) # costs 1 + 2
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				id
+				name
+			  }
+		`,
+			wantCost: 1 + 2 + 1,
+		},
+		{
+			name: "doesn't charge for skip true from variable on fragment spread",
+			query: `
+			query ($skip: Boolean!) {
+				characters { # costs 1
+					...CharacterFields @skip(if: $skip) # costs 1 + 2, but should be skipped
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				id
+				name
+			  }
+		`,
+			variables: map[string]interface{}{"skip": true},
+			wantCost:  1,
+		},
+		{
+			name: "doesn't charge for include false from variable on fragment spread",
+			query: `
+			query ($include: Boolean!) {
+				characters { # costs 1
+					...CharacterFields @include(if: $include) # costs 1 + 2, but should not be included in cost
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				id
+				name
+			  }
+		`,
+			variables: map[string]interface{}{"include": false},
+			wantCost:  1,
+		},
+		{
+			name: "does charge for include true from variable on fragment spread",
+			query: `
+			query ($include: Boolean!) {
+				characters { # costs 1
+					...CharacterFields @include(if: $include) # costs 1 + 2
+				}
+			  }
+
+			  fragment CharacterFields on Character {
+				id
+				name
+			  }
+		`,
+			variables: map[string]interface{}{"include": true},
+			wantCost:  1 + 2 + 1,
+		},
+		{
+			name: "doesn't charge for skip true from variable on inline fragment",
+			query: `
+			query ($skip: Boolean!) {
+				characters { # costs 1
+					... on Character @skip(if: $skip) {
+						id # costs 1, but should be skipped
+						name # costs 2, but should be skipped
+					}
+				}
+			  }
+		`,
+			variables: map[string]interface{}{"skip": true},
+			wantCost:  1,
+		},
 	} {
 		tc.Run(t, s)
 	}
@@ -421,4 +771,285 @@ func TestCost(t *testing.T) {
 			t.Fatalf("got incorrect amount of errors back: %d", len(errs))
 		}
 	})
+
+	t.Run("reports the most expensive root field", func(t *testing.T) {
+		doc, err := query.Parse(`query {
+			cheap: characters { ... on Character { id } }
+			expensive: characters { ... on Character { friends(first: 100) { name } } }
+		}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs := Validate(s, doc, nil, 0, 100)
+		if len(errs) != 1 {
+			t.Fatalf("got incorrect amount of errors back: %d", len(errs))
+		}
+		if want := "The query cost is too high. Permitted: 100, was: 103"; errs[0].Message != want {
+			t.Errorf("got message %q, want %q", errs[0].Message, want)
+		}
+		want := map[string]interface{}{
+			"cost":              103,
+			"maxCost":           100,
+			"mostExpensivePath": []interface{}{"expensive"},
+		}
+		if !reflect.DeepEqual(errs[0].Extensions, want) {
+			t.Errorf("got extensions %v, want %v", errs[0].Extensions, want)
+		}
+	})
+}
+
+func TestMaxCostFragmentBomb(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(simpleCostSchema, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every fragment spreads the next one ten times, so the root field is selected 10^40 times.
+	var b strings.Builder
+	b.WriteString("query { ...f0 }\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "fragment f%d on Query { %s }\n", i, strings.Repeat(fmt.Sprintf("...f%d ", i+1), 10))
+	}
+	b.WriteString("fragment f40 on Query { characters { ... on Character { id } } }\n")
+
+	doc, err := query.Parse(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newContext(s, doc, 0)
+	op := doc.Operations[0]
+	// The root field is merged into one, which costs 2.
+	if validateMaxCost(&opContext{c, []*query.Operation{op}}, op, nil, 2) {
+		t.Errorf("expected the cost to be within the limit, got %v", c.errs)
+	}
+}
+
+func TestCostOverflow(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(simpleCostSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A naive accumulation would overflow and wrap around to a small or negative cost.
+	costTestCase{
+		name: "saturates instead of overflowing",
+		query: `
+		query {
+			characters {
+				... on Character {
+					friends(first: 2000000000) {
+						... on Character {
+							friends(first: 2000000000) {
+								... on Character {
+									friends(first: 2000000000, last: 2000000000) {
+										name
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	`,
+		wantCost: maxCostValue,
+	}.Run(t, s)
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		max   int
+		rules []string
+	}{
+		{
+			name:  "product within limit",
+			query: `query { characters { ... on Character { friends(first: 10) { ... on Character { friends(first: 10) { name } } } } } }`,
+			max:   100,
+		},
+		{
+			name:  "product exceeds limit",
+			query: `query { characters { ... on Character { friends(first: 10) { ... on Character { friends(first: 11) { ... on Character { friends(first: 2) { name } } } } } } } }`,
+			max:   100,
+			rules: []string{"MaxMultiplierProductExceeded"},
+		},
+		{
+			name:  "fragment cycle",
+			query: `query { characters { ...friends } } fragment friends on Character { friends(first: 10) { ...friends } }`,
+			max:   100,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rules []string
+			for _, err := range ValidateMultiplierProduct(s, doc, nil, tc.max) {
+				rules = append(rules, err.Rule)
+			}
+			if !reflect.DeepEqual(rules, tc.rules) {
+				t.Errorf("expected errors %v, got %v", tc.rules, rules)
+			}
+		})
+	}
+}
+
+func TestCostCoverage(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(simpleCostSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := CostCoverage(s, false), []string{"FriendConnection.nodes", "Query.friend", "Query.version"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("wrong uncovered fields, have=%v want=%v", have, want)
+	}
+
+	if have, want := CostCoverage(s, true), []string{"FriendConnection.nodes", "Query.friend"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("wrong uncovered composite fields, have=%v want=%v", have, want)
+	}
+}
+
+const defaultCostSchema = `
+directive @cost(
+	complexity: Int!
+	multipliers: [String!]
+	useMultipliers: Boolean = true
+) on SCHEMA | OBJECT | FIELD_DEFINITION | INTERFACE
+
+	schema @cost(complexity: 1) {
+		query: Query
+	}
+
+	type Query {
+		books(first: Int): [Book!]! @cost(complexity: 2, multipliers: ["first"])
+		author: Author!
+		node: Node
+	}
+
+	type Book @cost(complexity: 3) {
+		title: String!
+		isbn: String!
+		price: Int! @cost(complexity: 10)
+		author: Author!
+	}
+
+	type Author {
+		name: String!
+	}
+
+	interface Node @cost(complexity: 5) {
+		id: ID!
+	}
+
+	type Publisher implements Node {
+		id: ID!
+		name: String!
+	}`
+
+func TestCostDefaults(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(defaultCostSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []costTestCase{
+		{
+			name: "fields inherit the complexity of their object",
+			query: `
+			query {
+				books(first: 2) { # costs 2
+					title # costs 3
+					isbn # costs 3
+				}
+			  }
+		`,
+			wantCost: 2 + (3+3)*2,
+		},
+		{
+			name: "field annotation overrides the object",
+			query: `
+			query {
+				books(first: 2) { # costs 2
+					title # costs 3
+					price # costs 10
+				}
+			  }
+		`,
+			wantCost: 2 + (3+10)*2,
+		},
+		{
+			name: "fields inherit the complexity of the schema",
+			query: `
+			query {
+				author { # costs 1 from the schema
+					name # costs 1 from the schema
+				}
+			  }
+		`,
+			wantCost: 1 + 1,
+		},
+		{
+			name: "object annotation applies to nested objects only for their own fields",
+			query: `
+			query {
+				books { # costs 2
+					author { # costs 3 from Book
+						name # costs 1 from the schema
+					}
+				}
+			  }
+		`,
+			wantCost: 2 + 3 + 1,
+		},
+		{
+			name: "interface fields inherit the complexity of the interface",
+			query: `
+			query {
+				node { # costs 1 from the schema
+					id # costs 5 from Node
+				}
+			  }
+		`,
+			wantCost: 1 + 5,
+		},
+		{
+			name: "object fields do not inherit the annotation of the interface type",
+			query: `
+			query {
+				node { # costs 1 from the schema
+					... on Publisher {
+						id # costs 1 from the schema
+						name # costs 1 from the schema
+					}
+				}
+			  }
+		`,
+			wantCost: 1 + 1 + 1,
+		},
+		{
+			name: "introspection is not charged",
+			query: `
+			query {
+				__typename
+				author { # costs 1 from the schema
+					__typename
+				}
+			  }
+		`,
+			wantCost: 1,
+		},
+	} {
+		tc.Run(t, s)
+	}
+
+	if have := CostCoverage(s, false); len(have) != 0 {
+		t.Errorf("wrong uncovered fields, have=%v want none", have)
+	}
 }
@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+func TestMaxAliases(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(interfaceSimple, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		query   string
+		aliases int
+		message string
+	}{
+		{
+			name: "direct aliases",
+			query: `query {
+				characters {
+					a: id
+					b: id
+					c: id
+				}
+			}`,
+			aliases: 3,
+			message: `The field "Human.id" is selected with 3 aliases. Permitted: 2`,
+		},
+		{
+			name: "repeated alias",
+			query: `query {
+				characters {
+					a: id
+					a: id
+					id
+				}
+			}`,
+			aliases: 2,
+			message: `The field "Human.id" is selected with 2 aliases. Permitted: 1`,
+		},
+		{
+			name: "aliases through fragments",
+			query: `query {
+				characters {
+					name
+					...a
+					... on Human {
+						c: name
+					}
+				}
+			}
+			fragment a on Character {
+				b: name
+				...b
+			}
+			fragment b on Droid {
+				d: name
+			}`,
+			aliases: 3,
+			message: `The field "Human.name" is selected with 3 aliases. Permitted: 2`,
+		},
+		{
+			name: "aliases in merged selection sets",
+			query: `query {
+				characters {
+					friends {
+						a: name
+					}
+				}
+				characters {
+					friends {
+						b: name
+					}
+				}
+			}`,
+			aliases: 2,
+			message: `The field "Human.name" is selected with 2 aliases. Permitted: 1`,
+		},
+		{
+			name: "root fields",
+			query: `query {
+				a: characters { id }
+				b: characters { id }
+			}`,
+			aliases: 2,
+			message: `The field "Query.characters" is selected with 2 aliases. Permitted: 1`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if errs := ValidateMaxAliases(s, doc, tc.aliases); len(errs) != 0 {
+				t.Errorf("expected no errors with max %d, got %v", tc.aliases, errs)
+			}
+			errs := ValidateMaxAliases(s, doc, tc.aliases-1)
+			if len(errs) == 0 || errs[0].Rule != "MaxAliasesExceeded" || errs[0].Message != tc.message {
+				t.Errorf("expected a MaxAliasesExceeded error with max %d, got %v", tc.aliases-1, errs)
+			}
+		})
+	}
+}
+
+func TestMaxAliasesFragmentBomb(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(interfaceSimple, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every fragment spreads the next one ten times in the selection sets of two aliases, so the
+	// query has 2^40 selection sets.
+	var b strings.Builder
+	b.WriteString("query { characters { ...f0 } }\n")
+	for i := 0; i < 40; i++ {
+		spreads := strings.Repeat(fmt.Sprintf("...f%d ", i+1), 10)
+		fmt.Fprintf(&b, "fragment f%d on Character { a: friends { %s } b: friends { %s } }\n", i, spreads, spreads)
+	}
+	b.WriteString("fragment f40 on Character { a: id b: id c: id }\n")
+
+	doc, qErr := query.Parse(b.String())
+	if qErr != nil {
+		t.Fatal(qErr)
+	}
+	// Each of the two selection sets of f39 reports the id field of both implementations of
+	// Character.
+	errs := ValidateMaxAliases(s, doc, 2)
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %v", errs)
+	}
+	for _, err := range errs {
+		if err.Rule != "MaxAliasesExceeded" {
+			t.Errorf("expected a MaxAliasesExceeded error, got %v", err)
+		}
+	}
+}
@@ -447,3 +447,122 @@ func TestMaxDepthValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestOperationDepth(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		query               string
+		exemptIntrospection bool
+		depth               int
+	}{
+		{
+			name:  "single field",
+			query: `query { characters { id } }`,
+			depth: 2,
+		}, {
+			name: "fragments don't add a level",
+			query: `fragment friend on Character {
+				friends {
+					name
+				}
+			}
+			query {
+				characters {
+					... on Character {
+						...friend
+					}
+				}
+			}`,
+			depth: 3,
+		}, {
+			name:  "meta-fields are counted",
+			query: `query { __schema { types { name } } }`,
+			depth: 3,
+		}, {
+			name:                "introspection fields can be exempted",
+			query:               `query { characters { id } __schema { types { fields { name } } } }`,
+			exemptIntrospection: true,
+			depth:               2,
+		}, {
+			name: "fragment cycles are ignored",
+			query: `fragment a on Character { friends { ...b } }
+			fragment b on Character { friends { ...a } }
+			query { characters { ...a } }`,
+			depth: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if depth := OperationDepth(doc, doc.Operations[0], tc.exemptIntrospection); depth != tc.depth {
+				t.Errorf("expected depth %d, got %d", tc.depth, depth)
+			}
+		})
+	}
+}
+
+func TestValidateMaxDepth(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(simpleSchema, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name                string
+		query               string
+		maxDepth            int
+		exemptIntrospection bool
+		rules               []string
+	}{
+		{
+			name:     "within limit",
+			query:    `query { characters { friends { name } } }`,
+			maxDepth: 3,
+		},
+		{
+			name:     "exceeded",
+			query:    `query { characters { friends { name } } }`,
+			maxDepth: 2,
+			rules:    []string{"MaxDepthExceeded"},
+		},
+		{
+			name:     "introspection is counted",
+			query:    `query { __schema { types { fields { name } } } }`,
+			maxDepth: 3,
+			rules:    []string{"MaxDepthExceeded"},
+		},
+		{
+			name:                "introspection is exempted",
+			query:               `query { __schema { types { fields { name } } } }`,
+			maxDepth:            3,
+			exemptIntrospection: true,
+		},
+		{
+			name: "fragment cycle",
+			query: `fragment a on Character { ...b }
+			fragment b on Character { ...a }
+			query { characters { ...a } }`,
+			maxDepth: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rules []string
+			for _, err := range ValidateMaxDepth(s, doc, tc.maxDepth, tc.exemptIntrospection) {
+				rules = append(rules, err.Rule)
+			}
+			if len(rules) != len(tc.rules) || (len(rules) > 0 && rules[0] != tc.rules[0]) {
+				t.Errorf("expected errors %v, got %v", tc.rules, rules)
+			}
+		})
+	}
+}
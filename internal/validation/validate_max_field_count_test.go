@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+func TestMaxFieldCount(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(interfaceSimple, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		count int
+	}{
+		{
+			name: "plain fields",
+			query: `query {
+				characters { # 1
+					id # 2
+					name # 3
+				}
+			}`,
+			count: 3,
+		},
+		{
+			name: "merged fields",
+			query: `query {
+				characters { # 1
+					id # 2
+					id
+					friends { # 3
+						name # 4
+					}
+					friends {
+						id # 5
+						name
+					}
+				}
+			}`,
+			count: 5,
+		},
+		{
+			name: "aliases",
+			query: `query {
+				characters { # 1
+					a: id # 2
+					b: id # 3
+				}
+			}`,
+			count: 3,
+		},
+		{
+			name: "fragments",
+			query: `fragment names on Character {
+				name
+				friends {
+					name
+				}
+			}
+			fragment ids on Character {
+				id
+				...names
+			}
+			query {
+				characters { # 1
+					...ids # id, name, friends, friends.name: 5
+					...names
+					... on Character {
+						...names
+					}
+				}
+			}`,
+			count: 5,
+		},
+		{
+			name: "fragments on different types",
+			query: `query {
+				characters { # 1
+					name # 2
+					... on Human {
+						name # 3
+						totalCredits # 4
+					}
+					... on Droid {
+						name # 5
+						primaryFunction # 6
+					}
+					... on Droid {
+						primaryFunction
+					}
+				}
+			}`,
+			count: 6,
+		},
+		{
+			name: "introspection",
+			query: `query {
+				__typename # 1
+				__schema { # 2
+					types { # 3
+						name # 4
+					}
+				}
+			}`,
+			count: 4,
+		},
+		{
+			name: "fragment cycle",
+			query: `fragment a on Character { friends { ...b } }
+			fragment b on Character { friends { ...a } }
+			query {
+				characters { # 1
+					...a # friends, friends.friends: 3
+				}
+			}`,
+			count: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if errs := ValidateMaxFieldCount(s, doc, tc.count); len(errs) != 0 {
+				t.Errorf("expected no errors with max %d, got %v", tc.count, errs)
+			}
+			errs := ValidateMaxFieldCount(s, doc, tc.count-1)
+			if len(errs) != 1 || errs[0].Rule != "MaxFieldCountExceeded" {
+				t.Errorf("expected a MaxFieldCountExceeded error with max %d, got %v", tc.count-1, errs)
+			}
+		})
+	}
+}
+
+func TestMaxFieldCountFragmentBomb(t *testing.T) {
+	s := schema.New()
+
+	err := s.Parse(interfaceSimple, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every fragment spreads the next one ten times in its own selection set and in the ones of
+	// two aliases, so the query selects 2^40 distinct fields.
+	var b strings.Builder
+	b.WriteString("query { characters { ...f0 } }\n")
+	for i := 0; i < 40; i++ {
+		spreads := strings.Repeat(fmt.Sprintf("...f%d ", i+1), 10)
+		fmt.Fprintf(&b, "fragment f%d on Character { a: friends { %s } b: friends { %s } %s }\n", i, spreads, spreads, spreads)
+	}
+	b.WriteString("fragment f40 on Character { id }\n")
+
+	doc, qErr := query.Parse(b.String())
+	if qErr != nil {
+		t.Fatal(qErr)
+	}
+	errs := ValidateMaxFieldCount(s, doc, 10000)
+	if len(errs) != 1 || errs[0].Rule != "MaxFieldCountExceeded" {
+		t.Errorf("expected a MaxFieldCountExceeded error, got %v", errs)
+	}
+}
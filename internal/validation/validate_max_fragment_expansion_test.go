@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/query"
+)
+
+func TestMaxFragmentExpansion(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		count int
+	}{
+		{
+			name: "plain fields",
+			query: `query {
+				characters { # 1
+					id # 2
+					id # 3
+				}
+			}`,
+			count: 3,
+		},
+		{
+			name: "fragments are expanded at every spread",
+			query: `query {
+				characters { # 1
+					...a # 2, 3
+					friends { # 4
+						...a # 5, 6
+					}
+				}
+			}
+
+			fragment a on Character {
+				id
+				name
+			}`,
+			count: 6,
+		},
+		{
+			name: "inline fragments and nested spreads",
+			query: `query {
+				characters { # 1
+					... on Human {
+						...b # 2, 3, 4
+					}
+				}
+			}
+
+			fragment a on Character {
+				id
+			}
+
+			fragment b on Character {
+				...a
+				friends {
+					...a
+				}
+			}`,
+			count: 4,
+		},
+		{
+			name: "fragment cycles are not followed",
+			query: `query {
+				characters { # 1
+					...a # 2
+				}
+			}
+
+			fragment a on Character {
+				id
+				...b
+			}
+
+			fragment b on Character {
+				...a
+			}`,
+			count: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if errs := ValidateMaxFragmentExpansion(doc, tc.count); len(errs) != 0 {
+				t.Errorf("expected no errors with max %d, got %v", tc.count, errs)
+			}
+			errs := ValidateMaxFragmentExpansion(doc, tc.count-1)
+			if len(errs) != 1 || errs[0].Rule != "MaxFragmentExpansionExceeded" {
+				t.Errorf("expected a MaxFragmentExpansionExceeded error with max %d, got %v", tc.count-1, errs)
+			}
+		})
+	}
+}
+
+func TestMaxFragmentExpansionBomb(t *testing.T) {
+	// Every fragment spreads the next one ten times, so the query expands to 10^40 fields.
+	var b strings.Builder
+	b.WriteString("query { characters { ...f0 } }\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "fragment f%d on Character { %s }\n", i, strings.Repeat(fmt.Sprintf("...f%d ", i+1), 10))
+	}
+	b.WriteString("fragment f40 on Character { id }\n")
+
+	doc, err := query.Parse(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := ValidateMaxFragmentExpansion(doc, 10000)
+	if len(errs) != 1 || errs[0].Rule != "MaxFragmentExpansionExceeded" {
+		t.Errorf("expected a MaxFragmentExpansionExceeded error, got %v", errs)
+	}
+}
@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/internal/query"
+)
+
+func TestMaxIntrospectionFields(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		count int
+	}{
+		{
+			name: "aliased fields",
+			query: `query {
+				a: __type(name: "Query") { name } # 1
+				b: __type(name: "Character") { name } # 2
+				__schema { types { name } } # 3
+				__typename
+			}`,
+			count: 3,
+		},
+		{
+			name: "fragments are counted at every spread",
+			query: `query {
+				...a # 1
+				characters {
+					...a # 2
+				}
+			}
+
+			fragment a on Query {
+				__type(name: "Query") { name }
+			}`,
+			count: 2,
+		},
+		{
+			name: "fragment cycles are not followed",
+			query: `query {
+				...a # 1
+			}
+
+			fragment a on Query {
+				__schema { types { name } }
+				...b
+			}
+
+			fragment b on Query {
+				...a
+			}`,
+			count: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if errs := ValidateIntrospectionFields(doc, tc.count); len(errs) != 0 {
+				t.Errorf("expected no errors with max %d, got %v", tc.count, errs)
+			}
+			errs := ValidateIntrospectionFields(doc, tc.count-1)
+			if len(errs) != 1 || errs[0].Rule != "MaxIntrospectionFieldsExceeded" {
+				t.Errorf("expected a MaxIntrospectionFieldsExceeded error with max %d, got %v", tc.count-1, errs)
+			}
+		})
+	}
+}
+
+func TestMaxIntrospectionFieldsBomb(t *testing.T) {
+	// Every fragment spreads the next one ten times, so the query selects __schema 10^40 times.
+	var b strings.Builder
+	b.WriteString("query { ...f0 }\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "fragment f%d on Query { %s }\n", i, strings.Repeat(fmt.Sprintf("...f%d ", i+1), 10))
+	}
+	b.WriteString("fragment f40 on Query { __schema { types { name } } }\n")
+
+	doc, err := query.Parse(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := ValidateIntrospectionFields(doc, 10)
+	if len(errs) != 1 || errs[0].Rule != "MaxIntrospectionFieldsExceeded" {
+		t.Errorf("expected a MaxIntrospectionFieldsExceeded error, got %v", errs)
+	}
+}
@@ -3,7 +3,7 @@ package validation
 import (
 	"fmt"
 	"math"
-	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"text/scanner"
@@ -32,6 +32,13 @@ type context struct {
 	fieldMap         map[*query.Field]fieldInfo
 	overlapValidated map[selectionPair]struct{}
 	maxDepth         int
+
+	maxDepthExemptIntrospection bool
+	maxMultiplierProduct        int
+
+	// coerceScalar checks that a variable value can be coerced into a scalar type, if it is set.
+	// The value is given for decl, which is the variable or an input field of its value.
+	coerceScalar func(decl *common.InputValue, t *schema.Scalar, value interface{}) error
 }
 
 func (c *context) addErr(loc errors.Location, rule string, format string, a ...interface{}) {
@@ -115,6 +122,9 @@ func Validate(s *schema.Schema, doc *query.Document, variables map[string]interf
 		}
 
 		validateSelectionSet(opc, op.Selections, entryPoint)
+		if op.Type == query.Subscription {
+			validateSubscriptionRoot(opc, op)
+		}
 
 		fragUsed := make(map[*query.FragmentDecl]struct{})
 		markUsedFragments(c, op.Selections, fragUsed)
@@ -166,25 +176,86 @@ func Validate(s *schema.Schema, doc *query.Document, variables map[string]interf
 		}
 	}
 
-	// for _, op := range doc.Operations {
-	// 	opc := &opContext{c, []*query.Operation{op}}
-	// 	if cost := estimateCost(opc, variables, op.Selections, getEntryPoint(c.schema, op)); cost > maxCost {
-	// 		c.addErr(op.Loc, "MaxCostExceeded", "The query cost is too high. Permitted: %d, was: %d", maxCost, cost)
-	// 		return c.errs
-	// 	}
-	// }
+	// The cost is only checked for valid documents, as the estimation relies on the validity.
+	// The max cost checking is turned off when maxCost is 0.
+	if maxCost > 0 && len(c.errs) == 0 {
+		for _, op := range doc.Operations {
+			opc := &opContext{c, []*query.Operation{op}}
+			if validateMaxCost(opc, op, variables, maxCost) {
+				return c.errs
+			}
+		}
+	}
 
 	return c.errs
 }
 
+// validateMaxCost reports an error if the cost of the operation exceeds maxCost. The extensions of
+// the error contain the cost, the limit and the path of the most expensive root field, so that
+// clients know which part of the query to trim.
+func validateMaxCost(c *opContext, op *query.Operation, variables map[string]interface{}, maxCost int) bool {
+	entryPoint := getEntryPoint(c.schema, op)
+
+	cost := 0
+	var mostExpensive *query.Field
+	mostExpensiveCost := -1
+	for _, f := range rootFields(c, op.Selections, entryPoint, variables) {
+		fieldCost := estimateCost(c, variables, []query.Selection{f}, entryPoint)
+		cost = addCost(cost, fieldCost)
+		if fieldCost > mostExpensiveCost {
+			mostExpensive, mostExpensiveCost = f, fieldCost
+		}
+	}
+	if cost <= maxCost {
+		return false
+	}
+
+	c.errs = append(c.errs, &errors.QueryError{
+		Message:   fmt.Sprintf("The query cost is too high. Permitted: %d, was: %d", maxCost, cost),
+		Locations: []errors.Location{op.Loc},
+		Rule:      "MaxCostExceeded",
+		Extensions: map[string]interface{}{
+			"cost":              cost,
+			"maxCost":           maxCost,
+			"mostExpensivePath": []interface{}{mostExpensive.Alias.Name},
+		},
+	})
+	return true
+}
+
+// rootFields returns the fields of the selections that are not skipped, including those of
+// fragments.
+func rootFields(c *opContext, sels []query.Selection, t schema.NamedType, variables map[string]interface{}) []*query.Field {
+	e := newSelectionExpander(c.schema, c.doc)
+	e.variables = variables
+	e.skip = true
+	var fields []*query.Field
+	for _, f := range e.fields(sels, t) {
+		fields = append(fields, f.field)
+	}
+	return fields
+}
+
 func validateValue(c *opContext, v *common.InputValue, val interface{}, t common.Type) {
+	validateValueAt(c, v, v, v.Name.Name, val, t)
+}
+
+// validateValueAt validates the value at path in the variable v, where path is the name of the
+// variable followed by the input fields and list indexes that lead to the value, e.g.
+// "filter.colors[1]". Errors of nested values name the path, so that they can be told apart. The
+// value is given for decl, which is v or the input field that holds the value.
+func validateValueAt(c *opContext, v *common.InputValue, decl *common.InputValue, path string, val interface{}, t common.Type) {
+	at := ""
+	if path != v.Name.Name {
+		at = fmt.Sprintf(" at %q", path)
+	}
 	switch t := t.(type) {
 	case *common.NonNull:
 		if val == nil {
-			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value null.\nExpected type \"%s\", found null.", v.Name.Name, t)
+			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value null%s.\nExpected type \"%s\", found null.", v.Name.Name, at, t)
 			return
 		}
-		validateValue(c, v, val, t.OfType)
+		validateValueAt(c, v, decl, path, val, t.OfType)
 	case *common.List:
 		if val == nil {
 			return
@@ -192,11 +263,11 @@ func validateValue(c *opContext, v *common.InputValue, val interface{}, t common
 		vv, ok := val.([]interface{})
 		if !ok {
 			// Input coercion rules allow single items without wrapping array
-			validateValue(c, v, val, t.OfType)
+			validateValueAt(c, v, decl, path, val, t.OfType)
 			return
 		}
-		for _, elem := range vv {
-			validateValue(c, v, elem, t.OfType)
+		for i, elem := range vv {
+			validateValueAt(c, v, decl, fmt.Sprintf("%s[%d]", path, i), elem, t.OfType)
 		}
 	case *schema.Enum:
 		if val == nil {
@@ -204,7 +275,7 @@ func validateValue(c *opContext, v *common.InputValue, val interface{}, t common
 		}
 		e, ok := val.(string)
 		if !ok {
-			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid type %T.\nExpected type \"%s\", found %v.", v.Name.Name, val, t, val)
+			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid type %T%s.\nExpected type \"%s\", found %v.", v.Name.Name, val, at, t, val)
 			return
 		}
 		for _, option := range t.Values {
@@ -212,23 +283,60 @@ func validateValue(c *opContext, v *common.InputValue, val interface{}, t common
 				return
 			}
 		}
-		c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %s.\nExpected type \"%s\", found %s.", v.Name.Name, e, t, e)
+		c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %s%s.\nExpected type \"%s\", found %s.", v.Name.Name, e, at, t, e)
 	case *schema.InputObject:
 		if val == nil {
 			return
 		}
 		in, ok := val.(map[string]interface{})
 		if !ok {
-			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid type %T.\nExpected type \"%s\", found %s.", v.Name.Name, val, t, val)
+			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid type %T%s.\nExpected type \"%s\", found %s.", v.Name.Name, val, at, t, val)
 			return
 		}
 		for _, f := range t.Values {
 			fieldVal := in[f.Name.Name]
-			validateValue(c, f, fieldVal, f.Type)
+			validateValueAt(c, v, f, path+"."+f.Name.Name, fieldVal, f.Type)
+		}
+		if t.IsOneOf() {
+			if len(in) != 1 {
+				c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %v%s.\nOneOf input object \"%s\" must specify exactly one field.", v.Name.Name, val, at, t)
+				return
+			}
+			for name, fieldVal := range in {
+				if fieldVal == nil {
+					c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value %v%s.\nIn field \"%s\": OneOf input object \"%s\" requires a non-null value.", v.Name.Name, val, at, name, t)
+				}
+			}
+		}
+	case *schema.Scalar:
+		if val == nil || c.coerceScalar == nil {
+			return
+		}
+		if err := c.coerceScalar(decl, t, val); err != nil {
+			c.addErr(v.Loc, "VariablesOfCorrectType", "Variable \"%s\" has invalid value%s.\nExpected type \"%s\": %s.", v.Name.Name, at, t, err)
 		}
 	}
 }
 
+// ValidateVariables validates the given variable values against the declared types of the
+// variables of the operation, without validating the rest of the document. The values are checked
+// the same way as by Validate before execution: omitted variables are treated as null, so a
+// missing non-null variable is reported, while a missing nullable variable uses its default value.
+// If coerceScalar is not nil, it is called for the values of scalar types with the variable or
+// input field that they are given for, so that the values that the executor can not coerce are
+// reported, too.
+func ValidateVariables(s *schema.Schema, op *query.Operation, variables map[string]interface{}, coerceScalar func(decl *common.InputValue, t *schema.Scalar, value interface{}) error) []*errors.QueryError {
+	c := newContext(s, nil, 0)
+	c.coerceScalar = coerceScalar
+	opc := &opContext{c, []*query.Operation{op}}
+	for _, v := range op.Vars {
+		if t := resolveType(c, v.Type); t != nil {
+			validateValue(opc, v, variables[v.Name.Name], t)
+		}
+	}
+	return c.errs
+}
+
 // validates the query doesn't go deeper than maxDepth (if set). Returns whether
 // or not query validated max depth to avoid excessive recursion.
 func validateMaxDepth(c *opContext, sels []query.Selection, depth int) bool {
@@ -238,34 +346,536 @@ func validateMaxDepth(c *opContext, sels []query.Selection, depth int) bool {
 	}
 
 	exceededMaxDepth := false
+	w := &depthWalker{
+		doc:                 c.doc,
+		exemptIntrospection: c.maxDepthExemptIntrospection,
+		field: func(sel *query.Field, depth int) bool {
+			if depth > c.maxDepth {
+				exceededMaxDepth = true
+				c.addErr(sel.Alias.Loc, "MaxDepthExceeded", "Field %q has depth %d that exceeds max depth %d", sel.Name.Name, depth, c.maxDepth)
+				return false
+			}
+			return true
+		},
+		unknownFragment: func(sel *query.FragmentSpread) {
+			// In case of unknown fragment (invalid request), ignore max depth evaluation
+			c.addErr(sel.Loc, "MaxDepthEvaluationError", "Unknown fragment %q. Unable to evaluate depth.", sel.Name.Name)
+		},
+		visited: make(map[string]struct{}),
+	}
+	w.selections(sels, depth)
+	return exceededMaxDepth
+}
 
+// depthWalker walks the fields of selections with their depth, as it is limited by the max depth
+// check: every field adds a level, while inline fragments and fragment spreads have the same depth
+// as their surrounding fields.
+type depthWalker struct {
+	doc *query.Document
+	// exemptIntrospection skips the introspection fields, which start with "__", and their
+	// selections.
+	exemptIntrospection bool
+	// field is called for every field with its depth. The selections of the field are only walked
+	// if it returns true.
+	field func(sel *query.Field, depth int) bool
+	// unknownFragment is called for the spreads of fragments that the document does not define,
+	// if it is not nil.
+	unknownFragment func(sel *query.FragmentSpread)
+	// visited holds the fragments that are spread on the path from the root. Fragment cycles are
+	// reported by the NoFragmentCycles rule.
+	visited map[string]struct{}
+}
+
+func (w *depthWalker) selections(sels []query.Selection, depth int) {
 	for _, sel := range sels {
 		switch sel := sel.(type) {
 		case *query.Field:
-			if depth > c.maxDepth {
-				exceededMaxDepth = true
-				c.addErr(sel.Alias.Loc, "MaxDepthExceeded", "Field %q has depth %d that exceeds max depth %d", sel.Name.Name, depth, c.maxDepth)
+			if w.exemptIntrospection && strings.HasPrefix(sel.Name.Name, "__") {
 				continue
 			}
-			exceededMaxDepth = exceededMaxDepth || validateMaxDepth(c, sel.Selections, depth+1)
+			if w.field(sel, depth) {
+				w.selections(sel.Selections, depth+1)
+			}
 		case *query.InlineFragment:
-			// Depth is not checked because inline fragments resolve to other fields which are checked.
-			// Depth is not incremented because inline fragments have the same depth as neighboring fields
-			exceededMaxDepth = exceededMaxDepth || validateMaxDepth(c, sel.Selections, depth)
+			w.selections(sel.Selections, depth)
 		case *query.FragmentSpread:
-			// Depth is not checked because fragments resolve to other fields which are checked.
-			frag := c.doc.Fragments.Get(sel.Name.Name)
+			frag := w.doc.Fragments.Get(sel.Name.Name)
 			if frag == nil {
-				// In case of unknown fragment (invalid request), ignore max depth evaluation
-				c.addErr(sel.Loc, "MaxDepthEvaluationError", "Unknown fragment %q. Unable to evaluate depth.", sel.Name.Name)
+				if w.unknownFragment != nil {
+					w.unknownFragment(sel)
+				}
 				continue
 			}
-			// Depth is not incremented because fragments have the same depth as surrounding fields
-			exceededMaxDepth = exceededMaxDepth || validateMaxDepth(c, frag.Selections, depth)
+			if _, ok := w.visited[frag.Name.Name]; ok {
+				continue
+			}
+			w.visited[frag.Name.Name] = struct{}{}
+			w.selections(frag.Selections, depth)
+			delete(w.visited, frag.Name.Name)
 		}
 	}
+}
 
-	return exceededMaxDepth
+// ValidateMaxDepth checks every operation of the document for fields nested deeper than maxDepth,
+// the same way as Validate. If exemptIntrospection is set, the introspection fields __schema,
+// __type and __typename are not checked, so that introspection queries of tools are not rejected.
+func ValidateMaxDepth(s *schema.Schema, doc *query.Document, maxDepth int, exemptIntrospection bool) []*errors.QueryError {
+	c := newContext(s, doc, maxDepth)
+	c.maxDepthExemptIntrospection = exemptIntrospection
+	for _, op := range doc.Operations {
+		validateMaxDepth(&opContext{c, []*query.Operation{op}}, op.Selections, 1)
+	}
+	return c.errs
+}
+
+// OperationDepth returns the depth of the given operation, counted the same way as by the max depth
+// check: every field adds a level, while inline fragments and fragment spreads have the same depth
+// as their surrounding fields. If exemptIntrospection is set, the introspection fields and their
+// selections are not counted, like by ValidateMaxDepth. Unknown fragments and fragment cycles are
+// ignored, as they are reported by other validation rules.
+func OperationDepth(doc *query.Document, op *query.Operation, exemptIntrospection bool) int {
+	maxDepth := 0
+	w := &depthWalker{
+		doc:                 doc,
+		exemptIntrospection: exemptIntrospection,
+		field: func(sel *query.Field, depth int) bool {
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+			return true
+		},
+		visited: make(map[string]struct{}),
+	}
+	w.selections(op.Selections, 1)
+	return maxDepth
+}
+
+// ValidateIntrospectionFields reports every operation of the document that selects the
+// introspection meta-fields __schema and __type more than max times, e.g. by aliasing __type for
+// every type of the schema. Selections in fragments are counted once for every spread. The count of
+// each fragment is computed once, so the check is linear in the size of the document.
+func ValidateIntrospectionFields(doc *query.Document, max int) []*errors.QueryError {
+	counts := make(map[string]int)
+	var errs []*errors.QueryError
+	for _, op := range doc.Operations {
+		if n := countIntrospectionFields(doc, op.Selections, counts, max); n > max {
+			errs = append(errs, &errors.QueryError{
+				Message:   fmt.Sprintf("The operation selects introspection fields too often. Permitted: %d, was: %d", max, n),
+				Locations: []errors.Location{op.Loc},
+				Rule:      "MaxIntrospectionFieldsExceeded",
+			})
+		}
+	}
+	return errs
+}
+
+// countIntrospectionFields returns the number of introspection fields of sels with all fragments
+// expanded, but at most limit+1, so that the count can not overflow. The counts of fragments are
+// memoized in counts, where fragments that are being expanded have a count of -1.
+func countIntrospectionFields(doc *query.Document, sels []query.Selection, counts map[string]int, limit int) int {
+	n := 0
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *query.Field:
+			if sel.Name.Name == "__schema" || sel.Name.Name == "__type" {
+				n++
+			}
+			n += countIntrospectionFields(doc, sel.Selections, counts, limit)
+		case *query.InlineFragment:
+			n += countIntrospectionFields(doc, sel.Selections, counts, limit)
+		case *query.FragmentSpread:
+			count, ok := counts[sel.Name.Name]
+			if !ok {
+				frag := doc.Fragments.Get(sel.Name.Name)
+				if frag == nil {
+					continue
+				}
+				counts[frag.Name.Name] = -1
+				count = countIntrospectionFields(doc, frag.Selections, counts, limit)
+				counts[frag.Name.Name] = count
+			}
+			if count > 0 {
+				n += count
+			}
+		}
+		if n > limit {
+			return limit + 1
+		}
+	}
+	return n
+}
+
+// validateSubscriptionRoot reports introspection fields selected at the root of a subscription,
+// including those selected through fragments.
+func validateSubscriptionRoot(c *opContext, op *query.Operation) {
+	var walk func(sels []query.Selection, visited map[string]struct{})
+	walk = func(sels []query.Selection, visited map[string]struct{}) {
+		for _, sel := range sels {
+			switch sel := sel.(type) {
+			case *query.Field:
+				if strings.HasPrefix(sel.Name.Name, "__") {
+					name := "Anonymous Subscription"
+					if op.Name.Name != "" {
+						name = fmt.Sprintf("Subscription %q", op.Name.Name)
+					}
+					c.addErr(sel.Alias.Loc, "SingleFieldSubscriptions", "%s must not select an introspection top level field.", name)
+				}
+			case *query.InlineFragment:
+				walk(sel.Selections, visited)
+			case *query.FragmentSpread:
+				frag := c.doc.Fragments.Get(sel.Name.Name)
+				if frag == nil {
+					continue
+				}
+				if _, ok := visited[frag.Name.Name]; ok {
+					continue
+				}
+				visited[frag.Name.Name] = struct{}{}
+				walk(frag.Selections, visited)
+			}
+		}
+	}
+	walk(op.Selections, make(map[string]struct{}))
+}
+
+// selectionExpander expands the fragments of the selection sets of a document. The fields of every
+// fragment are collected once, and a field is only returned once for a selection set, even if
+// fragments spread it many times. This keeps the expansion of documents that spread fragments many
+// times, like fragment bombs, polynomial in the size of the document. Fragment cycles are not
+// followed.
+type selectionExpander struct {
+	schema *schema.Schema
+	doc    *query.Document
+	// skip leaves out the selections that are excluded by @skip or @include with the variables.
+	skip      bool
+	variables map[string]interface{}
+	// fragments holds the fields of the fragments that were expanded. It holds nil for the
+	// fragments that are being expanded.
+	fragments map[string][]typedField
+}
+
+// typedField is a field of an expanded selection set with the type that it is selected on.
+type typedField struct {
+	field *query.Field
+	t     schema.NamedType
+}
+
+func newSelectionExpander(s *schema.Schema, doc *query.Document) *selectionExpander {
+	return &selectionExpander{
+		schema:    s,
+		doc:       doc,
+		fragments: make(map[string][]typedField),
+	}
+}
+
+// fields returns the fields of the selection set of type t, with the fragments expanded, in the
+// order of their first occurrence. The selections of the fields are not expanded.
+func (e *selectionExpander) fields(sels []query.Selection, t schema.NamedType) []typedField {
+	var fields []typedField
+	seen := make(map[*query.Field]struct{})
+	e.collect(sels, t, seen, &fields)
+	return fields
+}
+
+func (e *selectionExpander) collect(sels []query.Selection, t schema.NamedType, seen map[*query.Field]struct{}, fields *[]typedField) {
+	add := func(f typedField) {
+		if _, ok := seen[f.field]; !ok {
+			seen[f.field] = struct{}{}
+			*fields = append(*fields, f)
+		}
+	}
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *query.Field:
+			if e.skipped(sel.Directives) {
+				continue
+			}
+			add(typedField{field: sel, t: t})
+		case *query.InlineFragment:
+			if e.skipped(sel.Directives) {
+				continue
+			}
+			fragType := t
+			if sel.On.Name != "" {
+				fragType = e.schema.Types[sel.On.Name]
+			}
+			e.collect(sel.Selections, fragType, seen, fields)
+		case *query.FragmentSpread:
+			if e.skipped(sel.Directives) {
+				continue
+			}
+			for _, f := range e.fragment(sel.Name.Name) {
+				add(f)
+			}
+		}
+	}
+}
+
+// fragment returns the expanded fields of the fragment with the given name, which are collected
+// once. Unknown fragments and fragments that spread themselves have no fields.
+func (e *selectionExpander) fragment(name string) []typedField {
+	if fields, ok := e.fragments[name]; ok {
+		return fields
+	}
+	frag := e.doc.Fragments.Get(name)
+	if frag == nil {
+		return nil
+	}
+	e.fragments[name] = nil
+	fields := e.fields(frag.Selections, e.schema.Types[frag.On.Name])
+	e.fragments[name] = fields
+	return fields
+}
+
+func (e *selectionExpander) skipped(directives common.DirectiveList) bool {
+	return e.skip && (readSkip(directives, e.variables) || !readInclude(directives, e.variables))
+}
+
+// ValidateMaxFieldCount reports every operation of the document that selects more than max fields,
+// which protects against wide queries that are cheap per field. Fields are counted after expanding
+// fragments and merging fields with the same response key, as it is done by execution. Fields of
+// fragments on different types are counted once per type. Introspection fields are counted too.
+func ValidateMaxFieldCount(s *schema.Schema, doc *query.Document, max int) []*errors.QueryError {
+	e := newSelectionExpander(s, doc)
+	counts := make(map[string]int)
+	var errs []*errors.QueryError
+	for _, op := range doc.Operations {
+		if n := countFields(e, op.Selections, getEntryPoint(s, op), counts, max); n > max {
+			errs = append(errs, &errors.QueryError{
+				Message:   fmt.Sprintf("The operation selects too many fields. Permitted: %d, was: %d", max, n),
+				Locations: []errors.Location{op.Loc},
+				Rule:      "MaxFieldCountExceeded",
+			})
+		}
+	}
+	return errs
+}
+
+// countFields counts the fields of the merged selection set of type t, but at most limit+1, so that
+// the count can not overflow. The counts of the selection sets of merged fields are memoized in
+// counts by their key, where the selection sets that are being counted have a count of -1. Fields
+// whose selection set is being counted repeat a fragment cycle, so they are not counted again.
+func countFields(e *selectionExpander, sels []query.Selection, t schema.NamedType, counts map[string]int, limit int) int {
+	keys, merged := mergeFields(e, sels, t, nil)
+
+	n := 0
+	for _, key := range keys {
+		f := merged[key]
+		if len(f.sels) == 0 {
+			n++
+		} else {
+			k := f.key()
+			count, ok := counts[k]
+			if !ok {
+				counts[k] = -1
+				count = countFields(e, f.sels, f.t, counts, limit)
+				counts[k] = count
+			}
+			if count >= 0 {
+				n += 1 + count
+			}
+		}
+		if n > limit {
+			return limit + 1
+		}
+	}
+	return n
+}
+
+// mergedField is a field of a selection set, merged with the fields of the same response key.
+type mergedField struct {
+	t       schema.NamedType
+	sels    []query.Selection
+	sources []*query.Field
+}
+
+// key identifies the selection set of the merged field by its type and the fields of the document
+// that were merged, so that selection sets that fragments repeat are recognized.
+func (f *mergedField) key() string {
+	var b strings.Builder
+	if f.t != nil {
+		b.WriteString(f.t.TypeName())
+	}
+	for _, src := range f.sources {
+		fmt.Fprintf(&b, " %p", src)
+	}
+	return b.String()
+}
+
+// mergeFields expands the fragments of the selection set of type t and merges the fields by type
+// and response key. The keys are returned in the order of their first occurrence. If visit is not
+// nil, it is called for every field with the type it is selected on.
+func mergeFields(e *selectionExpander, sels []query.Selection, t schema.NamedType, visit func(f *query.Field, t schema.NamedType)) ([]string, map[string]*mergedField) {
+	var keys []string
+	merged := make(map[string]*mergedField)
+	for _, tf := range e.fields(sels, t) {
+		if visit != nil {
+			visit(tf.field, tf.t)
+		}
+		var typeName string
+		if tf.t != nil {
+			typeName = tf.t.TypeName()
+		}
+		key := typeName + "." + tf.field.Alias.Name
+		f, ok := merged[key]
+		if !ok {
+			f = &mergedField{t: fieldType(e.schema, tf.t, tf.field.Name.Name)}
+			merged[key] = f
+			keys = append(keys, key)
+		}
+		f.sels = append(f.sels, tf.field.Selections...)
+		f.sources = append(f.sources, tf.field)
+	}
+	return keys, merged
+}
+
+// ValidateMaxFragmentExpansion reports every operation of the document whose selections exceed max
+// when all fragments are expanded, without merging fields. This rejects "fragment bombs", where
+// fragments spread other fragments many times and a small document expands combinatorially. The
+// size of each fragment is computed once, so the check itself is linear in the size of the
+// document. Fragment spreads that form a cycle are not followed; they are reported by
+// NoFragmentCycles.
+func ValidateMaxFragmentExpansion(doc *query.Document, max int) []*errors.QueryError {
+	sizes := make(map[string]int)
+	var errs []*errors.QueryError
+	for _, op := range doc.Operations {
+		if n := expandedSize(doc, op.Selections, sizes, max); n > max {
+			errs = append(errs, &errors.QueryError{
+				Message:   fmt.Sprintf("The operation expands to more than %d selections.", max),
+				Locations: []errors.Location{op.Loc},
+				Rule:      "MaxFragmentExpansionExceeded",
+			})
+		}
+	}
+	return errs
+}
+
+// expandedSize returns the number of fields of sels with all fragments expanded, but at most
+// limit+1, so that the count can not overflow. The sizes of fragments are memoized in sizes, where
+// fragments that are being expanded have a size of -1.
+func expandedSize(doc *query.Document, sels []query.Selection, sizes map[string]int, limit int) int {
+	n := 0
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *query.Field:
+			n += 1 + expandedSize(doc, sel.Selections, sizes, limit)
+		case *query.InlineFragment:
+			n += expandedSize(doc, sel.Selections, sizes, limit)
+		case *query.FragmentSpread:
+			size, ok := sizes[sel.Name.Name]
+			if !ok {
+				frag := doc.Fragments.Get(sel.Name.Name)
+				if frag == nil {
+					continue
+				}
+				sizes[frag.Name.Name] = -1
+				size = expandedSize(doc, frag.Selections, sizes, limit)
+				sizes[frag.Name.Name] = size
+			}
+			if size > 0 {
+				n += size
+			}
+		}
+		if n > limit {
+			return limit + 1
+		}
+	}
+	return n
+}
+
+// ValidateMaxAliases checks that no field of a concrete object type is selected with more than max
+// distinct response keys in a selection set, after expanding fragments. Aliasing a field many
+// times multiplies the work of its resolver, without selecting many fields.
+func ValidateMaxAliases(s *schema.Schema, doc *query.Document, max int) []*errors.QueryError {
+	e := newSelectionExpander(s, doc)
+	var errs []*errors.QueryError
+	for _, op := range doc.Operations {
+		errs = append(errs, validateAliases(e, op.Selections, getEntryPoint(s, op), max, make(map[string]struct{}))...)
+	}
+	return errs
+}
+
+// validateAliases checks the merged selection set of type t and the selection sets of its fields.
+// The keys of the selection sets of merged fields that were checked are held in checked, so that a
+// selection set that fragments repeat is checked once and fragment cycles are not followed.
+func validateAliases(e *selectionExpander, sels []query.Selection, t schema.NamedType, max int, checked map[string]struct{}) []*errors.QueryError {
+	type aliasedField struct {
+		aliases map[string]struct{}
+		loc     errors.Location
+	}
+	var fieldKeys []string
+	fields := make(map[string]*aliasedField)
+
+	keys, merged := mergeFields(e, sels, t, func(f *query.Field, t schema.NamedType) {
+		for _, typeName := range concreteTypeNames(t) {
+			key := typeName + "." + f.Name.Name
+			af, ok := fields[key]
+			if !ok {
+				af = &aliasedField{aliases: make(map[string]struct{}), loc: f.Alias.Loc}
+				fields[key] = af
+				fieldKeys = append(fieldKeys, key)
+			}
+			af.aliases[f.Alias.Name] = struct{}{}
+		}
+	})
+
+	var errs []*errors.QueryError
+	for _, key := range fieldKeys {
+		if n := len(fields[key].aliases); n > max {
+			errs = append(errs, &errors.QueryError{
+				Message:   fmt.Sprintf("The field %q is selected with %d aliases. Permitted: %d", key, n, max),
+				Locations: []errors.Location{fields[key].loc},
+				Rule:      "MaxAliasesExceeded",
+			})
+		}
+	}
+	for _, key := range keys {
+		f := merged[key]
+		if len(f.sels) == 0 {
+			continue
+		}
+		k := f.key()
+		if _, ok := checked[k]; ok {
+			continue
+		}
+		checked[k] = struct{}{}
+		errs = append(errs, validateAliases(e, f.sels, f.t, max, checked)...)
+	}
+	return errs
+}
+
+// concreteTypeNames returns the names of the object types a value of type t can have.
+func concreteTypeNames(t schema.NamedType) []string {
+	var possible []*schema.Object
+	switch t := t.(type) {
+	case nil:
+		return nil
+	case *schema.Interface:
+		possible = t.PossibleTypes
+	case *schema.Union:
+		possible = t.PossibleTypes
+	default:
+		return []string{t.TypeName()}
+	}
+	names := make([]string, len(possible))
+	for i, o := range possible {
+		names[i] = o.Name
+	}
+	return names
+}
+
+// fieldType returns the named type of the field of t, including meta-fields, or nil if unknown.
+func fieldType(s *schema.Schema, t schema.NamedType, name string) schema.NamedType {
+	switch name {
+	case "__schema":
+		return s.Types["__Schema"]
+	case "__type":
+		return s.Types["__Type"]
+	}
+	if f := fields(t).Get(name); f != nil {
+		return unwrapType(f.Type)
+	}
+	return nil
 }
 
 func validateSelectionSet(c *opContext, sels []query.Selection, t schema.NamedType) {
@@ -365,7 +975,8 @@ func validateSelection(c *opContext, sel query.Selection, t schema.NamedType) {
 			return
 		}
 		fragTyp := c.schema.Types[frag.On.Name]
-		if !compatible(t, fragTyp) {
+		// an unknown type condition is reported by KnownTypeNames
+		if fragTyp != nil && !compatible(t, fragTyp) {
 			c.addErr(sel.Loc, "PossibleFragmentSpreads", "Fragment %q cannot be spread here as objects of type %q can never be of type %q.", frag.Name.Name, t, fragTyp)
 		}
 
@@ -575,13 +1186,82 @@ func argumentsConflict(a, b common.ArgumentList) bool {
 	}
 	for _, argA := range a {
 		valB, ok := b.Get(argA.Name.Name)
-		if !ok || !reflect.DeepEqual(argA.Value.Value(nil), valB.Value(nil)) {
+		// Arguments are compared syntactically, so that different variables and variables
+		// compared to literals conflict even if they might have the same value at runtime.
+		if !ok || !sameLiteral(argA.Value, valB) {
 			return true
 		}
 	}
 	return false
 }
 
+// sameLiteral reports whether the literals a and b have the same value, regardless of how they are
+// written, e.g. the order of the fields of input objects or the escapes of strings.
+func sameLiteral(a, b common.Literal) bool {
+	switch a := a.(type) {
+	case *common.BasicLit:
+		b, ok := b.(*common.BasicLit)
+		return ok && a.Type == b.Type && basicLitValue(a) == basicLitValue(b)
+
+	case *common.ListLit:
+		b, ok := b.(*common.ListLit)
+		if !ok || len(a.Entries) != len(b.Entries) {
+			return false
+		}
+		for i := range a.Entries {
+			if !sameLiteral(a.Entries[i], b.Entries[i]) {
+				return false
+			}
+		}
+		return true
+
+	case *common.ObjectLit:
+		b, ok := b.(*common.ObjectLit)
+		if !ok || len(a.Fields) != len(b.Fields) {
+			return false
+		}
+		for _, fa := range a.Fields {
+			var value common.Literal
+			for _, fb := range b.Fields {
+				if fb.Name.Name == fa.Name.Name {
+					value = fb.Value
+					break
+				}
+			}
+			if value == nil || !sameLiteral(fa.Value, value) {
+				return false
+			}
+		}
+		return true
+
+	case *common.NullLit:
+		_, ok := b.(*common.NullLit)
+		return ok
+
+	case *common.Variable:
+		b, ok := b.(*common.Variable)
+		return ok && a.Name == b.Name
+
+	default:
+		return false
+	}
+}
+
+// basicLitValue returns the value of a number or string literal, or its text if it is not valid.
+func basicLitValue(lit *common.BasicLit) interface{} {
+	switch lit.Type {
+	case scanner.Int, scanner.Float:
+		if v, err := strconv.ParseFloat(lit.Text, 64); err == nil {
+			return v
+		}
+	case scanner.String:
+		if v, err := strconv.Unquote(lit.Text); err == nil {
+			return v
+		}
+	}
+	return lit.Text
+}
+
 func fields(t common.Type) schema.FieldList {
 	switch t := t.(type) {
 	case *schema.Object:
@@ -808,12 +1488,37 @@ func validateValueType(c *opContext, v common.Literal, t common.Type) (bool, str
 				}
 			}
 		}
+		if t.IsOneOf() {
+			return validateOneOfLiteral(c, v, t)
+		}
 		return true, ""
 	}
 
 	return false, fmt.Sprintf("Expected type %q, found %s.", t, v)
 }
 
+// validateOneOfLiteral checks that exactly one field of a @oneOf input object is given and that
+// its value can not be null, so a variable used for it must be of a non-null type.
+func validateOneOfLiteral(c *opContext, v *common.ObjectLit, t *schema.InputObject) (bool, string) {
+	if len(v.Fields) != 1 {
+		return false, fmt.Sprintf("OneOf input object %q must specify exactly one field.", t)
+	}
+	f := v.Fields[0]
+	if isNull(f.Value) {
+		return false, fmt.Sprintf("In field %q: OneOf input object %q requires a non-null value.", f.Name.Name, t)
+	}
+	if variable, ok := f.Value.(*common.Variable); ok {
+		for _, op := range c.ops {
+			if v2 := op.Vars.Get(variable.Name); v2 != nil {
+				if _, ok := v2.Type.(*common.NonNull); !ok {
+					return false, fmt.Sprintf("In field %q: Variable %q must be non-nullable to be used for oneOf input object %q.", f.Name.Name, "$"+variable.Name, t)
+				}
+			}
+		}
+	}
+	return true, ""
+}
+
 func validateBasicLit(v *common.BasicLit, t common.Type) bool {
 	switch t := t.(type) {
 	case *schema.Scalar:
@@ -966,141 +1671,277 @@ func getEntryPoint(s *schema.Schema, op *query.Operation) schema.NamedType {
 	return entryPoint
 }
 
+// EstimateCost returns the estimated cost of the given operation of the document, as computed from
+// the @cost directives of the schema. Variables are used for the multipliers and for the @skip and
+// @include directives. The errors report fragments and types whose cost could not be evaluated.
+func EstimateCost(s *schema.Schema, doc *query.Document, op *query.Operation, variables map[string]interface{}) (int, []*errors.QueryError) {
+	c := newContext(s, doc, 0)
+	opc := &opContext{c, []*query.Operation{op}}
+	cost := estimateCost(opc, variables, op.Selections, getEntryPoint(s, op))
+	return cost, c.errs
+}
+
 func estimateCost(c *opContext, requestVariables map[string]interface{}, sels []query.Selection, t schema.NamedType) int {
-	return estimateCostImpl(c, requestVariables, sels, t, 1)
+	v := &costVisitor{c: c, vars: requestVariables}
+	v.frames = []*costFrame{{t: t, multiplier: 1, product: 1, typeCosts: make(map[string]int)}}
+	if err := walkSelections(c.schema, c.doc, sels, t, requestVariables, v); err != nil {
+		qErr := err.(*errors.QueryError)
+		c.addErrMultiLoc(qErr.Locations, "CostAnalysisError", "%s Unable to evaluate cost.", qErr.Message)
+	}
+	return v.frames[0].total()
 }
 
-func estimateCostImpl(c *opContext, requestVariables map[string]interface{}, sels []query.Selection, t schema.NamedType, parentMultiplier int) int {
-	fields := fields(t)
+// costVisitor estimates the cost of the walked selections. Costs are saturated at maxCostValue, so
+// that overflows can not make an expensive query look cheap.
+type costVisitor struct {
+	c      *opContext
+	vars   map[string]interface{}
+	frames []*costFrame
+}
+
+// costFrame accumulates the cost of the selections of a field or fragment.
+type costFrame struct {
+	// t is the type that the selections are evaluated on.
+	t schema.NamedType
+	// multiplier is the multiplier of the field, which applies to its selections.
+	multiplier int
+	// product is the product of all multipliers on the path from the root, which is checked
+	// against the configured maximum.
+	product int
+	cost    int
+	// typeCosts holds the costs of the fragments on the possible types of a union or interface,
+	// grouped by their type condition. Only the most expensive group is charged, as a value has
+	// exactly one of these types.
+	typeCosts map[string]int
+
+	fieldCost      int
+	useMultipliers bool
+}
 
-	// Unions must have explicit fragments defined, so we need to watch for the most expensive union member.
-	_, isUnion := t.(*schema.Union)
+func (f *costFrame) total() int {
+	maxTypeCost := 0
+	for _, c := range f.typeCosts {
+		if c > maxTypeCost {
+			maxTypeCost = c
+		}
+	}
+	return addCost(maxTypeCost, f.cost)
+}
 
-	// _, isInterface := t.(*schema.Interface)
+func (v *costVisitor) top() *costFrame {
+	return v.frames[len(v.frames)-1]
+}
 
-	unionCosts := make([]int, 0)
-	cost := 0
+func (v *costVisitor) pop() *costFrame {
+	f := v.top()
+	v.frames = v.frames[:len(v.frames)-1]
+	return f
+}
 
-	for _, sel := range sels {
-		switch sel := sel.(type) {
-		case *query.Field:
-			if isUnion {
-				// Field access on a Union is not allowed, validator should complain.
-				continue
-			}
-			if readSkip(sel.Directives, requestVariables) {
-				continue
-			}
-			if !readInclude(sel.Directives, requestVariables) {
-				continue
-			}
-			fieldName := sel.Name.Name
-			switch fieldName {
-			case "__typename", "__type", "__schema":
-				continue
-			}
-			var fieldCost int32 = 0
-			var multiplier int32 = 1
-
-			if f := fields.Get(fieldName); f != nil {
-				useMultipliers := true
-				d := f.Directives.Get("cost")
-				// If no annotation found, check if we are looking at something that implements an interface,
-				// then we need to check if the interface property has an annotation.
-				if d == nil {
-					if parentObj, ok := t.(*schema.Object); ok {
-						for _, iface := range parentObj.Interfaces {
-							ifaceF := iface.Fields.Get(fieldName)
-							if ifaceF != nil {
-								d = ifaceF.Directives.Get("cost")
-								if d != nil {
-									break
-								}
-							}
-						}
-					}
-				}
-				if d != nil {
-					fieldCost = readComplexity(d)
-					if m, ok := d.Args.Get("multipliers"); ok && m != nil {
-						mps := m.Value(map[string]interface{}{})
-						multipliers := mps.([]interface{})
-						hasMultiplier := false
-						for _, m := range multipliers {
-							parsedM := m.(string)
-							if arg, ok := sel.Arguments.Get(parsedM); ok {
-								hasMultiplier = true
-								v := arg.Value(map[string]interface{}{})
-								multiplier += v.(int32)
-							}
-						}
-						if hasMultiplier {
-							multiplier--
-						}
-					}
-					useMultipliers = readUseMultipliers(d)
-				}
+func (v *costVisitor) EnterField(wf *WalkField) error {
+	parent := v.top()
+	sel := wf.Selection
+	// The field of an object type is used, even if it is selected through a fragment on one of
+	// its interfaces.
+	f := fields(parent.t).Get(wf.Field.Name)
+	if f == nil {
+		f = wf.Field
+	}
 
-				childCost := estimateCostImpl(c, requestVariables, sel.Selections, unwrapType(f.Type), int(multiplier))
-				oldCost := cost
-				selCost := childCost + int(fieldCost)
-				if useMultipliers {
-					selCost = selCost * parentMultiplier
+	frame := &costFrame{t: unwrapType(f.Type), multiplier: 1, useMultipliers: true, typeCosts: make(map[string]int)}
+	if d := v.c.schema.CostDirective(parent.t, f); d != nil {
+		frame.fieldCost = int(readComplexity(d))
+		if m, ok := d.Args.Get("multipliers"); ok && m != nil {
+			mps := m.Value(map[string]interface{}{})
+			multipliers := mps.([]interface{})
+			hasMultiplier := false
+			for _, m := range multipliers {
+				parsedM := m.(string)
+				if mv, ok := readMultiplier(sel.Arguments, parsedM, v.vars); ok {
+					hasMultiplier = true
+					frame.multiplier = addCost(frame.multiplier, mv)
 				}
-				cost += selCost
-				fmt.Printf("Field: %q, Field cost: %d, parent multiplier: %d, multiplier: %d, child cost: %d, old cost: %d, new cost: %d\n", f.Name, fieldCost, parentMultiplier, multiplier, childCost, oldCost, cost)
 			}
-		case *query.InlineFragment:
-			if readSkip(sel.Directives, requestVariables) {
-				continue
+			if hasMultiplier {
+				frame.multiplier--
 			}
-			if !readInclude(sel.Directives, requestVariables) {
-				continue
-			}
-			frag := c.schema.Types[sel.On.Name]
-			if frag == nil {
-				fmt.Printf(" NO INLINE FRAG FOUND %q\n", sel.On.Name)
-				c.addErr(sel.Loc, "CostAnalysisError", "Unknown fragment %q. Unable to evaluate cost.", sel.On.Name)
-				continue
-			}
-			unionCost := estimateCostImpl(c, requestVariables, sel.Selections, frag, parentMultiplier)
-			if isUnion {
-				unionCosts = append(unionCosts, unionCost)
-			} else {
-				cost += unionCost
-			}
-		case *query.FragmentSpread:
-			if readSkip(sel.Directives, requestVariables) {
-				continue
-			}
-			if !readInclude(sel.Directives, requestVariables) {
+		}
+		frame.useMultipliers = readUseMultipliers(d)
+	}
+
+	frame.product = mulCost(parent.product, frame.multiplier)
+	if max := v.c.maxMultiplierProduct; max > 0 && frame.product > max && parent.product <= max {
+		v.c.addErr(sel.Alias.Loc, "MaxMultiplierProductExceeded", "The product of the multipliers of field %q is too high. Permitted: %d, was: %d", sel.Alias.Name, max, frame.product)
+	}
+	v.frames = append(v.frames, frame)
+	return nil
+}
+
+func (v *costVisitor) LeaveField(wf *WalkField) error {
+	frame := v.pop()
+	parent := v.top()
+	selCost := addCost(frame.total(), frame.fieldCost)
+	if frame.useMultipliers {
+		selCost = mulCost(selCost, parent.multiplier)
+	}
+	parent.cost = addCost(parent.cost, selCost)
+	return nil
+}
+
+func (v *costVisitor) EnterFragment(wf *WalkFragment) error {
+	parent := v.top()
+	v.frames = append(v.frames, &costFrame{
+		t:          fragmentType(parent.t, wf.TypeCondition),
+		multiplier: parent.multiplier,
+		product:    parent.product,
+		typeCosts:  make(map[string]int),
+	})
+	return nil
+}
+
+func (v *costVisitor) LeaveFragment(wf *WalkFragment) error {
+	fragCost := v.pop().total()
+	parent := v.top()
+	switch parent.t.(type) {
+	case *schema.Interface, *schema.Union:
+		if wf.TypeCondition != parent.t {
+			name := wf.TypeCondition.TypeName()
+			parent.typeCosts[name] = addCost(parent.typeCosts[name], fragCost)
+			return nil
+		}
+	}
+	parent.cost = addCost(parent.cost, fragCost)
+	return nil
+}
+
+// fragmentType returns the type that the selections of a fragment on fragType are evaluated on when
+// the fragment is selected on t. A fragment on an interface or union that is selected on an object
+// type selects the fields of the object, so the @cost directives of the object's fields take
+// precedence over the ones of its interfaces.
+func fragmentType(t, fragType schema.NamedType) schema.NamedType {
+	if _, ok := t.(*schema.Object); ok {
+		switch fragType.(type) {
+		case *schema.Interface, *schema.Union:
+			return t
+		}
+	}
+	return fragType
+}
+
+// maxCostValue is the largest cost, at which all cost computations saturate.
+const maxCostValue = int(^uint(0) >> 1)
+
+func addCost(a, b int) int {
+	if b > 0 && a > maxCostValue-b {
+		return maxCostValue
+	}
+	return a + b
+}
+
+func mulCost(a, b int) int {
+	if a > 0 && b > maxCostValue/a {
+		return maxCostValue
+	}
+	return a * b
+}
+
+// ValidateMultiplierProduct reports fields of the document's operations where the product of all
+// @cost multipliers on the path from the root exceeds max, e.g. for several nested lists that are
+// each paginated with a large page size.
+func ValidateMultiplierProduct(s *schema.Schema, doc *query.Document, variables map[string]interface{}, max int) []*errors.QueryError {
+	c := newContext(s, doc, 0)
+	c.maxMultiplierProduct = max
+	for _, op := range doc.Operations {
+		estimateCost(&opContext{c, []*query.Operation{op}}, variables, op.Selections, getEntryPoint(s, op))
+	}
+
+	var errs []*errors.QueryError
+	for _, err := range c.errs {
+		if err.Rule == "MaxMultiplierProductExceeded" {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// CostCoverage returns the coordinates (e.g. "Query.users") of all fields of object and interface
+// types that have no @cost annotation, neither on the field itself nor on the field of an
+// implemented interface, their type or the schema definition, and are therefore estimated with the
+// default complexity. If compositeOnly is set, only fields returning an object, interface or union
+// type (or a list of them) are reported.
+func CostCoverage(s *schema.Schema, compositeOnly bool) []string {
+	var names []string
+	for name := range s.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var uncovered []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		t := s.Types[name]
+		switch t.(type) {
+		case *schema.Object, *schema.Interface:
+		default:
+			continue
+		}
+		for _, f := range fields(t) {
+			if compositeOnly && !hasSubfields(f.Type) {
 				continue
 			}
-			frag := c.doc.Fragments.Get(sel.Name.Name)
-			if frag == nil {
-				println("####################### OG NO")
-				c.addErr(sel.Loc, "CostAnalysisError", "Unknown fragment %q. Unable to evaluate cost.", sel.Name.Name)
+			if s.CostDirective(t, f) != nil {
 				continue
 			}
-			unionCost := estimateCostImpl(c, requestVariables, frag.Selections, c.schema.Types[frag.On.Name], parentMultiplier)
-			if isUnion {
-				unionCosts = append(unionCosts, unionCost)
-			} else {
-				cost += unionCost
-			}
+			uncovered = append(uncovered, name+"."+f.Name)
 		}
 	}
-	if isUnion {
-		maxCost := 0
-		for _, c := range unionCosts {
-			if c > maxCost {
-				maxCost = c
-			}
+	return uncovered
+}
+
+// readMultiplier reads the value of a multiplier from the arguments of a field. The multiplier is
+// the name of an argument or a dotted path to a field of an input object argument, e.g.
+// "page.first". It returns false if the path does not resolve to an integer.
+func readMultiplier(args common.ArgumentList, path string, variables map[string]interface{}) (int, bool) {
+	names := strings.Split(path, ".")
+	arg, ok := args.Get(names[0])
+	if !ok {
+		return 0, false
+	}
+	v := arg.Value(variables)
+	for _, name := range names[1:] {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return 0, false
 		}
-		fmt.Printf("Was union, returning biggest potential cost. maxCost=%d, unionCosts=%+v\n", maxCost, unionCosts)
-		return maxCost + cost
+		v = obj[name]
+	}
+	return multiplierValue(v)
+}
+
+// multiplierValue converts the value of a multiplier argument, which is an int32 if given as a
+// literal or any number if given as a variable. Negative values are treated as 0.
+func multiplierValue(v interface{}) (int, bool) {
+	var m int
+	switch v := v.(type) {
+	case int32:
+		m = int(v)
+	case int:
+		m = v
+	case float64:
+		if v >= float64(maxCostValue) {
+			return maxCostValue, true
+		}
+		m = int(v)
+	default:
+		return 0, false
+	}
+	// Negative multipliers would allow to lower the cost of a query.
+	if m < 0 {
+		m = 0
 	}
-	return cost
+	return m, true
 }
 
 func readComplexity(d *common.Directive) int32 {
@@ -74,3 +74,397 @@ func sortLocations(errs []*errors.QueryError) {
 		sort.Slice(locs, func(i, j int) bool { return locs[i].Before(locs[j]) })
 	}
 }
+
+func TestNoUnusedFragments(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hero: Character
+		}
+
+		type Character {
+			name: String!
+			friends: [Character]
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		expected []*errors.QueryError
+	}{
+		{
+			name: "unused fragment",
+			query: `query {
+				hero { name }
+			}
+			fragment unused on Character { name }`,
+			expected: []*errors.QueryError{{
+				Message:   `Fragment "unused" is never used.`,
+				Locations: []errors.Location{{Line: 4, Column: 4}},
+				Rule:      "NoUnusedFragments",
+			}},
+		},
+		{
+			name: "fragment used through another fragment",
+			query: `query {
+				hero { ...outer }
+			}
+			fragment outer on Character { friends { ...inner } }
+			fragment inner on Character { name }`,
+		},
+		{
+			name: "fragment used only by an unused fragment",
+			query: `query {
+				hero { name }
+			}
+			fragment outer on Character { friends { ...inner } }
+			fragment inner on Character { name }`,
+			expected: []*errors.QueryError{{
+				Message:   `Fragment "outer" is never used.`,
+				Locations: []errors.Location{{Line: 4, Column: 4}},
+				Rule:      "NoUnusedFragments",
+			}, {
+				Message:   `Fragment "inner" is never used.`,
+				Locations: []errors.Location{{Line: 5, Column: 4}},
+				Rule:      "NoUnusedFragments",
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*errors.QueryError
+			for _, err := range validation.Validate(s, doc, nil, 0, 0) {
+				if err.Rule == "NoUnusedFragments" {
+					got = append(got, err)
+				}
+			}
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("wrong errors\nexpected: %v\ngot:      %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNoUnusedVariables(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hero(episode: String): Character
+		}
+
+		type Character {
+			name: String!
+			friends(first: Int): [Character]
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		expected []*errors.QueryError
+	}{
+		{
+			name: "unused variable",
+			query: `query Hero($episode: String, $first: Int) {
+				hero(episode: $episode) { name }
+			}`,
+			expected: []*errors.QueryError{{
+				Message:   `Variable "$first" is never used in operation "Hero".`,
+				Locations: []errors.Location{{Line: 1, Column: 30}},
+				Rule:      "NoUnusedVariables",
+			}},
+		},
+		{
+			name: "variable used only inside a fragment",
+			query: `query($first: Int) {
+				hero { ...outer }
+			}
+			fragment outer on Character { ...inner }
+			fragment inner on Character { friends(first: $first) { name } }`,
+		},
+		{
+			name: "variable used only in a directive argument",
+			query: `query($skip: Boolean!) {
+				hero { ...named }
+			}
+			fragment named on Character { name @skip(if: $skip) }`,
+		},
+		{
+			name: "variable used by another operation",
+			query: `query A($first: Int) {
+				hero { friends(first: $first) { name } }
+			}
+			query B($first: Int) {
+				hero { name }
+			}`,
+			expected: []*errors.QueryError{{
+				Message:   `Variable "$first" is never used in operation "B".`,
+				Locations: []errors.Location{{Line: 4, Column: 12}},
+				Rule:      "NoUnusedVariables",
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*errors.QueryError
+			for _, err := range validation.Validate(s, doc, nil, 0, 0) {
+				if err.Rule == "NoUnusedVariables" {
+					got = append(got, err)
+				}
+			}
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("wrong errors\nexpected: %v\ngot:      %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestOverlappingFieldArguments(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hero: Character
+		}
+
+		type Character {
+			name: String!
+			friends(first: Int, filter: Filter): [Character]
+		}
+
+		input Filter {
+			name: String
+			tags: [String!]
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		conflict bool
+	}{
+		{
+			name: "identical arguments",
+			query: `query {
+				hero { friends(first: 1) { name } ...friends }
+			}
+			fragment friends on Character { friends(first: 1) { name } }`,
+		},
+		{
+			name: "conflicting arguments",
+			query: `query {
+				hero { friends(first: 1) { name } ...friends }
+			}
+			fragment friends on Character { friends(first: 2) { name } }`,
+			conflict: true,
+		},
+		{
+			name: "identical variables",
+			query: `query($n: Int) {
+				hero { friends(first: $n) { name } ...friends }
+			}
+			fragment friends on Character { friends(first: $n) { name } }`,
+		},
+		{
+			name: "conflicting variables",
+			query: `query($a: Int, $b: Int) {
+				hero { friends(first: $a) { name } ...friends }
+			}
+			fragment friends on Character { friends(first: $b) { name } }`,
+			conflict: true,
+		},
+		{
+			name: "identical objects in a different order",
+			query: `query {
+				hero { friends(filter: {name: "Luke", tags: ["jedi"]}) { name } ...friends }
+			}
+			fragment friends on Character { friends(filter: {tags: [ "jedi" ] name: "\u004cuke"}) { name } }`,
+		},
+		{
+			name: "conflicting objects",
+			query: `query {
+				hero { friends(filter: {name: "Luke", tags: ["jedi"]}) { name } ...friends }
+			}
+			fragment friends on Character { friends(filter: {name: "Luke", tags: ["jedi", "pilot"]}) { name } }`,
+			conflict: true,
+		},
+		{
+			name: "object with a missing field",
+			query: `query {
+				hero { friends(filter: {name: "Luke", tags: null}) { name } ...friends }
+			}
+			fragment friends on Character { friends(filter: {name: "Luke"}) { name } }`,
+			conflict: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*errors.QueryError
+			for _, err := range validation.Validate(s, doc, nil, 0, 0) {
+				if err.Rule == "OverlappingFieldsCanBeMerged" {
+					got = append(got, err)
+				}
+			}
+			if tc.conflict != (len(got) != 0) {
+				t.Errorf("expected conflict: %t, got errors: %v", tc.conflict, got)
+			}
+		})
+	}
+}
+
+func TestValidateVariables(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			heroes(episode: Episode!, limit: Int): [String!]!
+		}
+
+		enum Episode {
+			NEWHOPE
+			EMPIRE
+			JEDI
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := query.Parse(`query($episode: Episode!, $limit: Int = 10) {
+		heroes(episode: $episode, limit: $limit)
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := doc.Operations[0]
+
+	for _, tc := range []struct {
+		name     string
+		vars     map[string]interface{}
+		messages []string
+	}{
+		{
+			name: "valid",
+			vars: map[string]interface{}{"episode": "JEDI", "limit": 3},
+		},
+		{
+			name: "default value",
+			vars: map[string]interface{}{"episode": "EMPIRE"},
+		},
+		{
+			name:     "missing required variable",
+			vars:     map[string]interface{}{"limit": 3},
+			messages: []string{"Variable \"episode\" has invalid value null.\nExpected type \"Episode!\", found null."},
+		},
+		{
+			name:     "invalid enum value",
+			vars:     map[string]interface{}{"episode": "PHANTOM"},
+			messages: []string{"Variable \"episode\" has invalid value PHANTOM.\nExpected type \"Episode\", found PHANTOM."},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var messages []string
+			for _, err := range validation.ValidateVariables(s, op, tc.vars, nil) {
+				messages = append(messages, err.Message)
+			}
+			if !reflect.DeepEqual(tc.messages, messages) {
+				t.Errorf("wrong errors\nexpected: %q\ngot:      %q", tc.messages, messages)
+			}
+		})
+	}
+}
+
+func TestSubscriptionRootIntrospection(t *testing.T) {
+	s := schema.New()
+	if err := s.Parse(`
+		schema {
+			query: Query
+			subscription: Subscription
+		}
+
+		type Query {
+			hello: String!
+		}
+
+		type Subscription {
+			helloSaid: String!
+		}
+	`, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		query    string
+		messages []string
+	}{
+		{
+			name:  "valid root field",
+			query: `subscription { helloSaid }`,
+		},
+		{
+			name:     "__typename",
+			query:    `subscription { __typename }`,
+			messages: []string{"Anonymous Subscription must not select an introspection top level field."},
+		},
+		{
+			name: "__typename in fragment",
+			query: `subscription OnHello { ...Root }
+			fragment Root on Subscription { __typename }`,
+			messages: []string{`Subscription "OnHello" must not select an introspection top level field.`},
+		},
+		{
+			name:  "__typename in query",
+			query: `query { __typename }`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := query.Parse(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var messages []string
+			for _, err := range validation.Validate(s, doc, nil, 0, 0) {
+				if err.Rule == "SingleFieldSubscriptions" {
+					messages = append(messages, err.Message)
+				}
+			}
+			if !reflect.DeepEqual(tc.messages, messages) {
+				t.Errorf("wrong errors\nexpected: %q\ngot:      %q", tc.messages, messages)
+			}
+		})
+	}
+}
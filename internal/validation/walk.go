@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// Visitor receives the fields and fragments of an operation from Walk. Walking stops at the first
+// error that a method returns, which is returned by Walk.
+type Visitor interface {
+	// EnterField is called for a field before its selections are walked.
+	EnterField(f *WalkField) error
+	// LeaveField is called for a field after its selections were walked.
+	LeaveField(f *WalkField) error
+	// EnterFragment is called for an inline fragment or fragment spread before its selections
+	// are walked.
+	EnterFragment(f *WalkFragment) error
+	// LeaveFragment is called for an inline fragment or fragment spread after its selections were
+	// walked.
+	LeaveFragment(f *WalkFragment) error
+}
+
+// WalkField is a field of an operation as passed to a Visitor.
+type WalkField struct {
+	// Selection is the field in the query.
+	Selection *query.Field
+	// Field is the declaration of the field in the schema.
+	Field *schema.Field
+	// ParentType is the object or interface type that the field is selected on.
+	ParentType schema.NamedType
+	// Depth is the number of fields on the path from the root, which is 1 for the root fields.
+	Depth int
+}
+
+// WalkFragment is an inline fragment or fragment spread of an operation as passed to a Visitor.
+type WalkFragment struct {
+	// Name is the name of a fragment spread, or empty for an inline fragment.
+	Name string
+	// TypeCondition is the type that the fragment applies to. It is the parent type for an inline
+	// fragment without type condition.
+	TypeCondition schema.NamedType
+	// ParentType is the type that the fragment is selected on.
+	ParentType schema.NamedType
+	Loc        errors.Location
+}
+
+// Walk calls the visitor for the selections of the operation in the order of the document, with
+// the fragments expanded where they are spread. Fields and fragments that are excluded by @skip or
+// @include are not walked, neither are the introspection fields __typename, __schema and __type.
+// The document is expected to be valid, so fields that the schema does not declare are ignored,
+// while unknown fragments and fragment cycles stop the walk with an error.
+func Walk(s *schema.Schema, doc *query.Document, op *query.Operation, variables map[string]interface{}, v Visitor) error {
+	return walkSelections(s, doc, op.Selections, getEntryPoint(s, op), variables, v)
+}
+
+// walkSelections is like Walk, but walks the given selections on t.
+func walkSelections(s *schema.Schema, doc *query.Document, sels []query.Selection, t schema.NamedType, variables map[string]interface{}, v Visitor) error {
+	w := &walker{schema: s, doc: doc, vars: variables, visitor: v, fragments: make(map[string]struct{})}
+	return w.selections(sels, t, 1)
+}
+
+type walker struct {
+	schema  *schema.Schema
+	doc     *query.Document
+	vars    map[string]interface{}
+	visitor Visitor
+	// fragments holds the fragments that are spread on the path from the root.
+	fragments map[string]struct{}
+}
+
+func (w *walker) selections(sels []query.Selection, t schema.NamedType, depth int) error {
+	for _, sel := range sels {
+		var err error
+		switch sel := sel.(type) {
+		case *query.Field:
+			if readSkip(sel.Directives, w.vars) || !readInclude(sel.Directives, w.vars) {
+				continue
+			}
+			err = w.field(sel, t, depth)
+
+		case *query.InlineFragment:
+			if readSkip(sel.Directives, w.vars) || !readInclude(sel.Directives, w.vars) {
+				continue
+			}
+			fragType := t
+			if sel.On.Name != "" {
+				if fragType = w.schema.Types[sel.On.Name]; fragType == nil {
+					return walkError(sel.Loc, "Unknown type %q.", sel.On.Name)
+				}
+			}
+			err = w.fragment(&WalkFragment{
+				TypeCondition: fragType,
+				ParentType:    t,
+				Loc:           sel.Loc,
+			}, sel.Selections, depth)
+
+		case *query.FragmentSpread:
+			if readSkip(sel.Directives, w.vars) || !readInclude(sel.Directives, w.vars) {
+				continue
+			}
+			frag := w.doc.Fragments.Get(sel.Name.Name)
+			if frag == nil {
+				return walkError(sel.Loc, "Unknown fragment %q.", sel.Name.Name)
+			}
+			if _, ok := w.fragments[frag.Name.Name]; ok {
+				return walkError(sel.Loc, "Cannot spread fragment %q within itself.", frag.Name.Name)
+			}
+			fragType := w.schema.Types[frag.On.Name]
+			if fragType == nil {
+				return walkError(frag.On.Loc, "Unknown type %q.", frag.On.Name)
+			}
+			w.fragments[frag.Name.Name] = struct{}{}
+			err = w.fragment(&WalkFragment{
+				Name:          sel.Name.Name,
+				TypeCondition: fragType,
+				ParentType:    t,
+				Loc:           sel.Loc,
+			}, frag.Selections, depth)
+			delete(w.fragments, frag.Name.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) field(sel *query.Field, t schema.NamedType, depth int) error {
+	switch sel.Name.Name {
+	case "__typename", "__schema", "__type":
+		return nil
+	}
+	f := fields(t).Get(sel.Name.Name)
+	if f == nil {
+		return nil
+	}
+
+	wf := &WalkField{
+		Selection:  sel,
+		Field:      f,
+		ParentType: t,
+		Depth:      depth,
+	}
+	if err := w.visitor.EnterField(wf); err != nil {
+		return err
+	}
+	if err := w.selections(sel.Selections, unwrapType(f.Type), depth+1); err != nil {
+		return err
+	}
+	return w.visitor.LeaveField(wf)
+}
+
+func (w *walker) fragment(wf *WalkFragment, sels []query.Selection, depth int) error {
+	if err := w.visitor.EnterFragment(wf); err != nil {
+		return err
+	}
+	if err := w.selections(sels, wf.TypeCondition, depth); err != nil {
+		return err
+	}
+	return w.visitor.LeaveFragment(wf)
+}
+
+func walkError(loc errors.Location, format string, a ...interface{}) *errors.QueryError {
+	return &errors.QueryError{
+		Message:   fmt.Sprintf(format, a...),
+		Locations: []errors.Location{loc},
+	}
+}
@@ -3,6 +3,8 @@ package graphql
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"strings"
 
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/introspection"
@@ -15,17 +17,31 @@ func (s *Schema) Inspect() *introspection.Schema {
 
 // ToJSON encodes the schema in a JSON format used by tools like Relay.
 func (s *Schema) ToJSON() ([]byte, error) {
-	result := s.exec(context.Background(), introspectionQuery, "", nil, &resolvable.Schema{
-		Meta:   s.res.Meta,
-		Query:  &resolvable.Object{},
-		Schema: *s.schema,
-	})
+	res := *s.res
+	res.Query = &resolvable.Object{}
+	result := s.exec(context.Background(), introspectionQuery, "", nil, &res)
 	if len(result.Errors) != 0 {
 		panic(result.Errors[0])
 	}
 	return json.MarshalIndent(result.Data, "", "\t")
 }
 
+// Fprint writes the schema in the schema definition language (SDL) to w, without building it in
+// memory. Built-in types and directives are omitted and the types are sorted by name, while fields,
+// arguments and enum values keep the order of the source. Descriptions, default values and the
+// directives applied to the elements of the schema are written as well, so the output parses to
+// an equivalent schema with UseStringDescriptions. It returns the first error returned by w.
+func (s *Schema) Fprint(w io.Writer) error {
+	return s.schema.Fprint(w)
+}
+
+// String returns the schema in the schema definition language, as written by Fprint.
+func (s *Schema) String() string {
+	var b strings.Builder
+	s.Fprint(&b)
+	return b.String()
+}
+
 var introspectionQuery = `
   query {
     __schema {
@@ -9,27 +9,52 @@ import (
 
 type Schema struct {
 	schema *schema.Schema
+	vis    *visibility
 }
 
 // WrapSchema is only used internally.
 func WrapSchema(schema *schema.Schema) *Schema {
-	return &Schema{schema}
+	return &Schema{schema: schema}
+}
+
+// WrapSchemaVisible is only used internally.
+func WrapSchemaVisible(schema *schema.Schema, visible func(typeName, fieldName string) bool) *Schema {
+	if visible == nil {
+		return WrapSchema(schema)
+	}
+	return &Schema{schema: schema, vis: &visibility{schema: schema, visible: visible}}
 }
 
 func (r *Schema) Types() []*Type {
+	var reachable map[string]bool
+	if r.vis != nil {
+		reachable = r.vis.reachableTypes()
+	}
+
 	var names []string
 	for name := range r.schema.Types {
-		names = append(names, name)
+		if reachable == nil || reachable[name] {
+			names = append(names, name)
+		}
 	}
 	sort.Strings(names)
 
 	l := make([]*Type, len(names))
 	for i, name := range names {
-		l[i] = &Type{r.schema.Types[name]}
+		l[i] = &Type{r.schema.Types[name], r.vis}
 	}
 	return l
 }
 
+// TypeByName returns the type with the given name, or nil if it is not listed by Types.
+func (r *Schema) TypeByName(name string) *Type {
+	t, ok := r.schema.Types[name]
+	if !ok || (r.vis != nil && !r.vis.reachableTypes()[name]) {
+		return nil
+	}
+	return &Type{t, r.vis}
+}
+
 func (r *Schema) Directives() []*Directive {
 	var names []string
 	for name := range r.schema.Directives {
@@ -49,7 +74,7 @@ func (r *Schema) QueryType() *Type {
 	if !ok {
 		return nil
 	}
-	return &Type{t}
+	return &Type{t, r.vis}
 }
 
 func (r *Schema) MutationType() *Type {
@@ -57,7 +82,7 @@ func (r *Schema) MutationType() *Type {
 	if !ok {
 		return nil
 	}
-	return &Type{t}
+	return &Type{t, r.vis}
 }
 
 func (r *Schema) SubscriptionType() *Type {
@@ -65,16 +90,17 @@ func (r *Schema) SubscriptionType() *Type {
 	if !ok {
 		return nil
 	}
-	return &Type{t}
+	return &Type{t, r.vis}
 }
 
 type Type struct {
 	typ common.Type
+	vis *visibility
 }
 
 // WrapType is only used internally.
 func WrapType(typ common.Type) *Type {
-	return &Type{typ}
+	return &Type{typ: typ}
 }
 
 func (r *Type) Kind() string {
@@ -101,20 +127,24 @@ func (r *Type) Description() *string {
 }
 
 func (r *Type) Fields(args *struct{ IncludeDeprecated bool }) *[]*Field {
+	var typeName string
 	var fields schema.FieldList
 	switch t := r.typ.(type) {
 	case *schema.Object:
-		fields = t.Fields
+		typeName, fields = t.Name, t.Fields
 	case *schema.Interface:
-		fields = t.Fields
+		typeName, fields = t.Name, t.Fields
 	default:
 		return nil
 	}
 
 	var l []*Field
 	for _, f := range fields {
+		if r.vis != nil && !r.vis.fieldVisible(typeName, f.Name) {
+			continue
+		}
 		if d := f.Directives.Get("deprecated"); d == nil || args.IncludeDeprecated {
-			l = append(l, &Field{f})
+			l = append(l, &Field{f, r.vis})
 		}
 	}
 	return &l
@@ -128,7 +158,7 @@ func (r *Type) Interfaces() *[]*Type {
 
 	l := make([]*Type, len(t.Interfaces))
 	for i, intf := range t.Interfaces {
-		l[i] = &Type{intf}
+		l[i] = &Type{intf, r.vis}
 	}
 	return &l
 }
@@ -146,7 +176,7 @@ func (r *Type) PossibleTypes() *[]*Type {
 
 	l := make([]*Type, len(possibleTypes))
 	for i, intf := range possibleTypes {
-		l[i] = &Type{intf}
+		l[i] = &Type{intf, r.vis}
 	}
 	return &l
 }
@@ -182,16 +212,27 @@ func (r *Type) InputFields() *[]*InputValue {
 func (r *Type) OfType() *Type {
 	switch t := r.typ.(type) {
 	case *common.List:
-		return &Type{t.OfType}
+		return &Type{t.OfType, r.vis}
 	case *common.NonNull:
-		return &Type{t.OfType}
+		return &Type{t.OfType, r.vis}
 	default:
 		return nil
 	}
 }
 
+// IsOneOf reports whether an input object has the @oneOf directive. It is nil for other types.
+func (r *Type) IsOneOf() *bool {
+	t, ok := r.typ.(*schema.InputObject)
+	if !ok {
+		return nil
+	}
+	isOneOf := t.IsOneOf()
+	return &isOneOf
+}
+
 type Field struct {
 	field *schema.Field
+	vis   *visibility
 }
 
 func (r *Field) Name() string {
@@ -214,7 +255,7 @@ func (r *Field) Args() []*InputValue {
 }
 
 func (r *Field) Type() *Type {
-	return &Type{r.field.Type}
+	return &Type{r.field.Type, r.vis}
 }
 
 func (r *Field) IsDeprecated() bool {
@@ -246,7 +287,7 @@ func (r *InputValue) Description() *string {
 }
 
 func (r *InputValue) Type() *Type {
-	return &Type{r.value.Type}
+	return &Type{typ: r.value.Type}
 }
 
 func (r *InputValue) DefaultValue() *string {
@@ -257,6 +298,19 @@ func (r *InputValue) DefaultValue() *string {
 	return &s
 }
 
+func (r *InputValue) IsDeprecated() bool {
+	return r.value.Directives.Get("deprecated") != nil
+}
+
+func (r *InputValue) DeprecationReason() *string {
+	d := r.value.Directives.Get("deprecated")
+	if d == nil {
+		return nil
+	}
+	reason := d.Args.MustGet("reason").Value(nil).(string)
+	return &reason
+}
+
 type EnumValue struct {
 	value *schema.EnumValue
 }
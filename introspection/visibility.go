@@ -0,0 +1,102 @@
+package introspection
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+)
+
+// visibility hides the fields for which visible returns false from introspection, together with
+// the types that are no longer reachable from the root types.
+type visibility struct {
+	schema  *schema.Schema
+	visible func(typeName, fieldName string) bool
+
+	once      sync.Once
+	reachable map[string]bool
+}
+
+// reachableTypes returns the names of the types that are reachable from the visible fields of the
+// root types, the built-in introspection types and the arguments of the directives.
+func (v *visibility) reachableTypes() map[string]bool {
+	v.once.Do(func() {
+		v.reachable = make(map[string]bool)
+		for _, t := range v.schema.EntryPoints {
+			v.visit(t)
+		}
+		for name, t := range v.schema.Types {
+			if strings.HasPrefix(name, "__") {
+				v.visit(t)
+			}
+		}
+		for _, d := range v.schema.Directives {
+			for _, arg := range d.Args {
+				v.visit(arg.Type)
+			}
+		}
+	})
+	return v.reachable
+}
+
+func (v *visibility) visit(t common.Type) {
+	t = unwrapType(t)
+	named, ok := t.(schema.NamedType)
+	if !ok || v.reachable[named.TypeName()] {
+		return
+	}
+	v.reachable[named.TypeName()] = true
+
+	switch t := t.(type) {
+	case *schema.Object:
+		v.visitFields(t.Name, t.Fields)
+		for _, intf := range t.Interfaces {
+			v.visit(intf)
+		}
+	case *schema.Interface:
+		v.visitFields(t.Name, t.Fields)
+		for _, pt := range t.PossibleTypes {
+			v.visit(pt)
+		}
+	case *schema.Union:
+		for _, pt := range t.PossibleTypes {
+			v.visit(pt)
+		}
+	case *schema.InputObject:
+		for _, iv := range t.Values {
+			v.visit(iv.Type)
+		}
+	}
+}
+
+// fieldVisible reports whether the field is listed by introspection. The fields of the built-in
+// introspection types are always visible.
+func (v *visibility) fieldVisible(typeName, fieldName string) bool {
+	return strings.HasPrefix(typeName, "__") || v.visible(typeName, fieldName)
+}
+
+func (v *visibility) visitFields(typeName string, fields schema.FieldList) {
+	for _, f := range fields {
+		if !v.fieldVisible(typeName, f.Name) {
+			continue
+		}
+		v.visit(f.Type)
+		for _, arg := range f.Args {
+			v.visit(arg.Type)
+		}
+	}
+}
+
+func unwrapType(t common.Type) common.Type {
+	for {
+		switch tt := t.(type) {
+		case *common.List:
+			t = tt.OfType
+		case *common.NonNull:
+			t = tt.OfType
+		default:
+			return t
+		}
+	}
+}
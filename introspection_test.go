@@ -3,7 +3,9 @@ package graphql_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"reflect"
 	"testing"
 
 	"github.com/graph-gophers/graphql-go"
@@ -87,3 +89,295 @@ func mustReadFile(filename string) []byte {
 	}
 	return b
 }
+
+func TestIntrospection_DirectiveLocations(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(`
+		directive @cost(
+			complexity: Int!
+			multipliers: [String!]
+			useMultipliers: Boolean = true
+		) on SCHEMA | SCALAR | OBJECT | FIELD_DEFINITION | ARGUMENT_DEFINITION | INTERFACE | UNION | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+
+		type Query {
+			hello: String! @cost(complexity: 1)
+		}
+	`, nil)
+
+	j, err := s.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Schema struct {
+			Directives []struct {
+				Name      string
+				Locations []string
+			}
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal(j, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE",
+		"UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION",
+	}
+	for _, d := range result.Schema.Directives {
+		if d.Name != "cost" {
+			continue
+		}
+		if !reflect.DeepEqual(d.Locations, want) {
+			t.Fatalf("wrong locations for @cost\ngot:  %v\nwant: %v", d.Locations, want)
+		}
+		return
+	}
+	t.Fatal("directive @cost missing from introspection")
+}
+
+func TestSchema_Fprint(t *testing.T) {
+	t.Parallel()
+
+	schema := graphql.MustParseSchema(`
+		directive @cost(complexity: Int!, multipliers: [String!]) on FIELD_DEFINITION
+
+		schema {
+			query: Query
+		}
+
+		"The root of all queries"
+		type Query {
+			"""
+			Finds a user.
+			Returns null if there is none.
+			"""
+			user(
+				"The \"ID\" of the user"
+				id: ID!
+				active: Boolean = true
+			): User @cost(complexity: 2)
+			search(filter: Filter, first: Int = 10): [SearchResult!]! @cost(complexity: 1, multipliers: ["first"])
+		}
+
+		interface Node {
+			id: ID!
+		}
+
+		type User implements Node {
+			id: ID!
+			name: String! @deprecated(reason: "Use fullName.")
+			fullName: String!
+			role: Role
+		}
+
+		enum Role {
+			"Can do everything"
+			ADMIN
+			USER @deprecated
+		}
+
+		union SearchResult = User
+
+		input Filter {
+			role: Role = USER
+			name: String
+		}
+
+		scalar Time
+	`, nil, graphql.UseStringDescriptions())
+
+	var buf bytes.Buffer
+	if err := schema.Fprint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `schema {
+  query: Query
+}
+
+directive @cost(complexity: Int!, multipliers: [String!]) on FIELD_DEFINITION
+
+input Filter {
+  role: Role = USER
+  name: String
+}
+
+interface Node {
+  id: ID!
+}
+
+"The root of all queries"
+type Query {
+  """
+  Finds a user.
+  Returns null if there is none.
+  """
+  user(
+    "The \"ID\" of the user"
+    id: ID!
+    active: Boolean = true
+  ): User @cost(complexity: 2)
+  search(filter: Filter, first: Int = 10): [SearchResult!]! @cost(complexity: 1, multipliers: ["first"])
+}
+
+enum Role {
+  "Can do everything"
+  ADMIN
+  USER @deprecated
+}
+
+union SearchResult = User
+
+scalar Time
+
+type User implements Node {
+  id: ID!
+  name: String! @deprecated(reason: "Use fullName.")
+  fullName: String!
+  role: Role
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := schema.String(); got != want {
+		t.Errorf("String differs from Fprint:\n%s", got)
+	}
+
+	reparsed, err := graphql.ParseSchema(want, nil, graphql.UseStringDescriptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reparsed.String(); got != want {
+		t.Errorf("reparsed schema differs:\n%s", got)
+	}
+
+	errWrite := errors.New("write failed")
+	if err := schema.Fprint(failingWriter{errWrite}); err != errWrite {
+		t.Errorf("expected the write error, got %v", err)
+	}
+}
+
+func TestSchema_FprintRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sdl := `schema @tag(name: "schema") {
+  query: Query
+}
+
+"Tags an element of the schema."
+directive @tag(name: String!, meta: Meta = {weight: 1, labels: ["default"]}) on SCHEMA | OBJECT | FIELD_DEFINITION | ARGUMENT_DEFINITION | ENUM | ENUM_VALUE | INPUT_FIELD_DEFINITION
+
+"""
+Sort order of results.
+
+  Ascending is the default.
+"""
+enum Direction @tag(name: "enum") {
+  """
+  Smallest first.
+  Ties are broken by id.
+  """
+  ASC @tag(name: "asc", meta: {weight: 1, labels: ["default"]})
+  "Largest first"
+  DESC @deprecated(reason: "Use \"ASC\" and reverse.")
+}
+
+input Meta {
+  weight: Int = 0 @tag(name: "weight")
+  labels: [String!]
+  nested: Meta = {weight: 2}
+}
+
+"The \"root\" query"
+type Query @tag(name: "query") {
+  items(
+    "How to sort, see \\"
+    order: Direction = ASC @tag(name: "order")
+    first: Int = 10
+  ): [String!]! @tag(name: "items", meta: {weight: 3, labels: []})
+  meta(filter: Meta = {weight: 1, labels: ["a", "b"], nested: {labels: null}}): Meta @tag(name: "meta")
+}
+`
+
+	schema := graphql.MustParseSchema(sdl, nil, graphql.UseStringDescriptions())
+	if got := schema.String(); got != sdl {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, sdl)
+	}
+
+	reparsed, err := graphql.ParseSchema(schema.String(), nil, graphql.UseStringDescriptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reparsed.String(); got != sdl {
+		t.Errorf("reparsed schema differs:\n%s", got)
+	}
+
+	// The reparsed schema is equivalent to the original one for introspection as well.
+	want, err := schema.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reparsed.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("introspection of the reparsed schema differs:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestIntrospection_DirectiveDescription(t *testing.T) {
+	t.Parallel()
+
+	s := graphql.MustParseSchema(`
+		"""
+		Sets the cost of a field for query cost analysis.
+		"""
+		directive @cost(complexity: Int!) on FIELD_DEFINITION
+
+		directive @internal on FIELD_DEFINITION
+
+		type Query {
+			hello: String! @cost(complexity: 1) @internal
+		}
+	`, nil, graphql.UseStringDescriptions())
+
+	j, err := s.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Schema struct {
+			Directives []struct {
+				Name        string
+				Description *string
+			}
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal(j, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions := make(map[string]*string)
+	for _, d := range result.Schema.Directives {
+		descriptions[d.Name] = d.Description
+	}
+	if d := descriptions["cost"]; d == nil || *d != "Sets the cost of a field for query cost analysis." {
+		t.Errorf("wrong description for @cost: %v", d)
+	}
+	if d, ok := descriptions["internal"]; !ok || d != nil {
+		t.Errorf("expected a null description for @internal, got %v", d)
+	}
+}
@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// PersistedQueryCache stores the queries of automatic persisted queries by the hex encoded SHA-256
+// hash of their text. Implementations must be safe for concurrent use.
+type PersistedQueryCache interface {
+	Get(hash string) (string, bool)
+	Set(hash, query string)
+}
+
+// Error codes of automatic persisted queries, which are set as the "code" extension of the errors.
+const (
+	PersistedQueryNotFoundCode     = "PERSISTED_QUERY_NOT_FOUND"
+	PersistedQueryNotSupportedCode = "PERSISTED_QUERY_NOT_SUPPORTED"
+	PersistedQueryBadRequestCode   = "BAD_REQUEST"
+)
+
+// LoadPersistedQuery returns the query of a request with the given query string and extensions,
+// following the automatic persisted queries protocol of Apollo. If the extensions contain
+// persistedQuery.sha256Hash, a request without query is looked up in the cache and a request with
+// query is stored in it, after the hash is verified. Requests without a hash are returned as they
+// are. A nil cache does not support persisted queries, but requests that contain the query are
+// still executed.
+//
+// The returned error is meant to be the only error of the response. Clients retry with the full
+// query text when its message is "PersistedQueryNotFound".
+func LoadPersistedQuery(cache PersistedQueryCache, queryString string, extensions map[string]interface{}) (string, *errors.QueryError) {
+	pq, ok := extensions["persistedQuery"]
+	if !ok {
+		return queryString, nil
+	}
+	ext, ok := pq.(map[string]interface{})
+	if !ok {
+		return "", persistedQueryError("Invalid persisted query extension", PersistedQueryBadRequestCode)
+	}
+	if version, ok := ext["version"].(float64); !ok || version != 1 {
+		return "", persistedQueryError("Unsupported persisted query version", PersistedQueryBadRequestCode)
+	}
+	hash, ok := ext["sha256Hash"].(string)
+	if !ok || hash == "" {
+		return "", persistedQueryError("Missing persisted query hash", PersistedQueryBadRequestCode)
+	}
+	hash = strings.ToLower(hash)
+
+	if queryString == "" {
+		if cache == nil {
+			return "", persistedQueryError("PersistedQueryNotSupported", PersistedQueryNotSupportedCode)
+		}
+		q, ok := cache.Get(hash)
+		if !ok {
+			return "", persistedQueryError("PersistedQueryNotFound", PersistedQueryNotFoundCode)
+		}
+		return q, nil
+	}
+
+	sum := sha256.Sum256([]byte(queryString))
+	if hex.EncodeToString(sum[:]) != hash {
+		return "", persistedQueryError("provided sha does not match query", PersistedQueryBadRequestCode)
+	}
+	if cache != nil {
+		cache.Set(hash, queryString)
+	}
+	return queryString, nil
+}
+
+func persistedQueryError(message, code string) *errors.QueryError {
+	return &errors.QueryError{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// LRUPersistedQueryCache is a PersistedQueryCache that keeps the most recently used queries in
+// memory.
+type LRUPersistedQueryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	hash  string
+	query string
+}
+
+// NewLRUPersistedQueryCache returns a cache that holds up to size queries. The least recently used
+// query is evicted when a query is added to a full cache.
+func NewLRUPersistedQueryCache(size int) *LRUPersistedQueryCache {
+	if size < 1 {
+		panic("graphql: the size of a persisted query cache must be positive")
+	}
+	return &LRUPersistedQueryCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the query with the given hash and marks it as recently used.
+func (c *LRUPersistedQueryCache) Get(hash string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[hash]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).query, true
+}
+
+// Set stores the query with the given hash.
+func (c *LRUPersistedQueryCache) Set(hash, query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[hash]; ok {
+		e.Value.(*lruEntry).query = query
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[hash] = c.order.PushFront(&lruEntry{hash: hash, query: query})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).hash)
+	}
+}
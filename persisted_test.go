@@ -0,0 +1,83 @@
+package graphql_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+func persistedQueryExtensions(hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": float64(1), "sha256Hash": hash},
+	}
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoadPersistedQuery(t *testing.T) {
+	const query = "{ hello }"
+	hash := sha256Hex(query)
+	cache := graphql.NewLRUPersistedQueryCache(10)
+
+	// miss
+	_, err := graphql.LoadPersistedQuery(cache, "", persistedQueryExtensions(hash))
+	if err == nil || err.Message != "PersistedQueryNotFound" || err.Extensions["code"] != graphql.PersistedQueryNotFoundCode {
+		t.Fatalf("expected PersistedQueryNotFound, got %v", err)
+	}
+
+	// hash mismatch
+	_, err = graphql.LoadPersistedQuery(cache, "{ goodbye }", persistedQueryExtensions(hash))
+	if err == nil || err.Extensions["code"] != graphql.PersistedQueryBadRequestCode {
+		t.Fatalf("expected a hash mismatch error, got %v", err)
+	}
+	if _, ok := cache.Get(hash); ok {
+		t.Fatal("a query with a mismatching hash must not be stored")
+	}
+
+	// register
+	q, err := graphql.LoadPersistedQuery(cache, query, persistedQueryExtensions(hash))
+	if err != nil || q != query {
+		t.Fatalf("got %q, %v", q, err)
+	}
+
+	// hit
+	q, err = graphql.LoadPersistedQuery(cache, "", persistedQueryExtensions(hash))
+	if err != nil || q != query {
+		t.Fatalf("got %q, %v", q, err)
+	}
+
+	// without persisted query extension
+	q, err = graphql.LoadPersistedQuery(nil, "{ goodbye }", nil)
+	if err != nil || q != "{ goodbye }" {
+		t.Fatalf("got %q, %v", q, err)
+	}
+
+	// without cache
+	_, err = graphql.LoadPersistedQuery(nil, "", persistedQueryExtensions(hash))
+	if err == nil || err.Extensions["code"] != graphql.PersistedQueryNotSupportedCode {
+		t.Fatalf("expected PersistedQueryNotSupported, got %v", err)
+	}
+}
+
+func TestLRUPersistedQueryCache(t *testing.T) {
+	cache := graphql.NewLRUPersistedQueryCache(2)
+	cache.Set("a", "{ a }")
+	cache.Set("b", "{ b }")
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.Set("c", "{ c }")
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("expected the least recently used query b to be evicted")
+	}
+	for _, hash := range []string{"a", "c"} {
+		if _, ok := cache.Get(hash); !ok {
+			t.Fatalf("expected %s to be cached", hash)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/exec/selected"
+	"github.com/graph-gophers/graphql-go/internal/query"
+	"github.com/graph-gophers/graphql-go/internal/validation"
+	"github.com/graph-gophers/graphql-go/trace"
+)
+
+// PreparedQuery is an operation that is parsed, validated and planned once, so that executing it
+// only needs to bind the variables of the request. It is safe for concurrent use. Servers with
+// persisted queries can keep prepared queries in a cache keyed by query string and operation name.
+type PreparedQuery struct {
+	schema      *Schema
+	queryString string
+	doc         *query.Document
+	op          *query.Operation
+	warnings    []*errors.QueryError
+	plan        *selected.Plan
+}
+
+// variableRules are the validation rules that depend on the values of variables. They are checked
+// when a prepared query is executed.
+var variableRules = map[string]bool{
+	"VariablesOfCorrectType":       true,
+	"MaxCostExceeded":              true,
+	"MaxMultiplierProductExceeded": true,
+}
+
+// Prepare parses and validates the query and plans the execution of the given operation. If the
+// query contains more than one operation, the operation name must be given. Selections whose
+// arguments or directives use variables are planned when the prepared query is executed.
+func (s *Schema) Prepare(queryString string, operationName string) (*PreparedQuery, []*errors.QueryError) {
+	if s.res.Resolver == (reflect.Value{}) {
+		panic("schema created without resolver, can not prepare")
+	}
+
+	doc, qErr := s.parse(queryString)
+	if qErr != nil {
+		return nil, []*errors.QueryError{qErr}
+	}
+
+	validationFinish := s.validationTracer.TraceValidation()
+	all, warnings := s.validate(doc, nil)
+	var errs []*errors.QueryError
+	for _, err := range all {
+		if !variableRules[err.Rule] {
+			errs = append(errs, err)
+		}
+	}
+	validationFinish(errs)
+	s.observeValidation(doc, queryString, operationName, errs)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return nil, []*errors.QueryError{errors.Errorf("%s", err)}
+	}
+
+	r := &selected.Request{
+		Doc:                  doc,
+		Schema:               s.schema,
+		DisableIntrospection: s.disableIntrospection,
+		DirectiveHandlers:    s.directiveHandlers,
+		Authorization:        s.authorization,
+	}
+	return &PreparedQuery{
+		schema:      s,
+		queryString: queryString,
+		doc:         doc,
+		op:          op,
+		warnings:    warnings,
+		plan:        selected.PlanOperation(r, s.res, op),
+	}, nil
+}
+
+// Exec executes the prepared query with the given variables, like Schema.Exec.
+func (q *PreparedQuery) Exec(ctx context.Context, variables map[string]interface{}) *Response {
+	s := q.schema
+	phases := trace.QueryPhases{Start: time.Now()}
+	if len(q.op.Vars) != 0 {
+		validationFinish := s.validationTracer.TraceValidation()
+		errs := validation.ValidateVariables(s.schema, q.op, variables, s.coerceScalar)
+		if len(errs) == 0 && (s.maxCost > 0 || s.maxMultiplierProduct > 0) {
+			errs, _ = s.validate(q.doc, variables)
+		}
+		validationFinish(errs)
+		phases.Validation = time.Since(phases.Start)
+		s.observeValidation(q.doc, q.queryString, q.op.Name.Name, errs)
+		if len(errs) != 0 {
+			s.addOperationToErrors(q.doc, q.op.Name.Name, errs)
+			return &Response{Errors: errors.SetPhase(errs, errors.PhaseValidate)}
+		}
+	}
+
+	resp, _ := s.executeOperation(ctx, phases, q.queryString, q.doc, q.op, variables, q.warnings, s.res, false, q.plan)
+	return resp
+}
@@ -0,0 +1,170 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/example/starwars"
+)
+
+const preparedQuery = `
+	query HeroAndFriends($episode: Episode, $withFriends: Boolean!, $first: Int) {
+		hero(episode: $episode) {
+			__typename
+			name
+			... on Droid {
+				primaryFunction
+			}
+			friends @include(if: $withFriends) {
+				name
+			}
+			friendsConnection(first: $first) {
+				totalCount
+				friends {
+					name
+				}
+			}
+			appearsIn
+		}
+		human(id: "1000") {
+			name
+			height(unit: FOOT)
+		}
+	}
+`
+
+func TestPreparedQuery(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+	q, errs := schema.Prepare(preparedQuery, "")
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	for _, variables := range []map[string]interface{}{
+		{"episode": "JEDI", "withFriends": true, "first": float64(2)},
+		{"episode": "EMPIRE", "withFriends": false},
+		{"episode": "NEWHOPE", "withFriends": true, "first": float64(1)},
+	} {
+		want := schema.Exec(context.Background(), preparedQuery, "", copyVariables(variables))
+		got := q.Exec(context.Background(), copyVariables(variables))
+		if len(want.Errors) != 0 || len(got.Errors) != 0 {
+			t.Fatalf("unexpected errors: %v, %v", want.Errors, got.Errors)
+		}
+		if string(got.Data) != string(want.Data) {
+			t.Errorf("variables %v: got %s, want %s", variables, got.Data, want.Data)
+		}
+	}
+
+	got := q.Exec(graphql.WithIntrospection(context.Background(), false), map[string]interface{}{"episode": "JEDI", "withFriends": false})
+	var data struct {
+		Hero map[string]interface{}
+	}
+	if err := json.Unmarshal(got.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data.Hero["__typename"]; ok {
+		t.Errorf("__typename selected with introspection disabled: %s", got.Data)
+	}
+
+	got = q.Exec(context.Background(), nil)
+	if len(got.Errors) != 1 || got.Errors[0].Rule != "VariablesOfCorrectType" {
+		t.Errorf("expected an error for the missing variable, got %v", got.Errors)
+	}
+
+	invalid := map[string]interface{}{"withFriends": true, "first": "two"}
+	got = q.Exec(context.Background(), invalid)
+	want := schema.ValidateVariables(preparedQuery, "", invalid)
+	if len(want) != 1 || len(got.Errors) != 1 || got.Errors[0].Message != want[0].Message || got.Data != nil {
+		t.Errorf("got errors %v for the invalid variable, want %v", got.Errors, want)
+	}
+
+	if _, errs := schema.Prepare(`{ hero { nickname } }`, ""); len(errs) != 1 {
+		t.Errorf("expected a validation error, got %v", errs)
+	}
+}
+
+func TestPreparedQuery_operationInErrors(t *testing.T) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{}, graphql.OperationInErrors())
+	q, errs := schema.Prepare(preparedQuery, "")
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	got := q.Exec(context.Background(), map[string]interface{}{"episode": "JEDI"})
+	if len(got.Errors) != 1 {
+		t.Fatalf("expected an error for the missing variable, got %v", got.Errors)
+	}
+	want := map[string]interface{}{"name": "HeroAndFriends", "type": "query"}
+	if op := got.Errors[0].Extensions["operation"]; !reflect.DeepEqual(op, want) {
+		t.Errorf("got operation %v, want %v", op, want)
+	}
+}
+
+type preparedPriceResolver struct{}
+
+func (*preparedPriceResolver) Cents(args struct{ P price }) int32 {
+	return int32(args.P)
+}
+
+func TestPreparedQuery_concurrentPlanErrors(t *testing.T) {
+	schema := graphql.MustParseSchema(`
+		scalar Price
+
+		type Query {
+			cents(p: Price!): Int!
+		}
+	`, &preparedPriceResolver{}, graphql.OperationInErrors())
+	q, errs := schema.Prepare(`query Cents { cents(p: "free") }`, "")
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got := q.Exec(context.Background(), nil)
+			if len(got.Errors) != 1 || got.Errors[0].Extensions["operation"] == nil {
+				t.Errorf("expected the argument error with the operation, got %v", got.Errors)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func copyVariables(variables map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		res[k] = v
+	}
+	return res
+}
+
+func BenchmarkExec(b *testing.B) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+	variables := map[string]interface{}{"episode": "JEDI", "withFriends": true, "first": float64(2)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		schema.Exec(context.Background(), preparedQuery, "", copyVariables(variables))
+	}
+}
+
+func BenchmarkPreparedQueryExec(b *testing.B) {
+	schema := graphql.MustParseSchema(starwars.Schema, &starwars.Resolver{})
+	q, errs := schema.Prepare(preparedQuery, "")
+	if len(errs) != 0 {
+		b.Fatal(errs)
+	}
+	variables := map[string]interface{}{"episode": "JEDI", "withFriends": true, "first": float64(2)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Exec(context.Background(), copyVariables(variables))
+	}
+}
@@ -0,0 +1,137 @@
+package relay
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const cursorPrefix = "cursor:"
+
+// ConnectionArgs are the standard arguments of a connection field. Embed them in the arguments
+// struct of the resolver of the field.
+type ConnectionArgs struct {
+	First  *int32
+	After  *string
+	Last   *int32
+	Before *string
+}
+
+// Connection resolves the pageInfo and totalCount fields of a connection type over a list of
+// items, with opaque cursors that encode the offsets of the items. Embed it in the resolver of the
+// connection type and resolve the edges from the items in the range returned by Bounds, using
+// Cursor for their cursors.
+type Connection struct {
+	start int
+	end   int
+	total int
+}
+
+// NewConnection returns the connection over a list of total items that the given arguments
+// select. The items are selected as specified by the Relay cursor connections specification.
+func NewConnection(total int, args ConnectionArgs) (*Connection, error) {
+	start, end := 0, total
+	if args.After != nil {
+		offset, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		if offset+1 > start {
+			start = offset + 1
+		}
+	}
+	if args.Before != nil {
+		offset, err := decodeCursor(*args.Before)
+		if err != nil {
+			return nil, err
+		}
+		if offset < end {
+			end = offset
+		}
+	}
+	if start > end {
+		start = end
+	}
+	if args.First != nil {
+		if *args.First < 0 {
+			return nil, fmt.Errorf("relay: argument %q must not be negative", "first")
+		}
+		if end > start+int(*args.First) {
+			end = start + int(*args.First)
+		}
+	}
+	if args.Last != nil {
+		if *args.Last < 0 {
+			return nil, fmt.Errorf("relay: argument %q must not be negative", "last")
+		}
+		if start < end-int(*args.Last) {
+			start = end - int(*args.Last)
+		}
+	}
+	return &Connection{start: start, end: end, total: total}, nil
+}
+
+// Bounds returns the range of the selected items, from start inclusive to end exclusive.
+func (c *Connection) Bounds() (start, end int) {
+	return c.start, c.end
+}
+
+// Cursor returns the cursor of the item with the given index.
+func (c *Connection) Cursor(index int) string {
+	return base64.URLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(index)))
+}
+
+// TotalCount resolves the totalCount field of the connection.
+func (c *Connection) TotalCount() int32 {
+	return int32(c.total)
+}
+
+// PageInfo resolves the pageInfo field of the connection.
+func (c *Connection) PageInfo() *PageInfo {
+	p := &PageInfo{
+		hasPreviousPage: c.start > 0,
+		hasNextPage:     c.end < c.total,
+	}
+	if c.start < c.end {
+		startCursor, endCursor := c.Cursor(c.start), c.Cursor(c.end-1)
+		p.startCursor, p.endCursor = &startCursor, &endCursor
+	}
+	return p
+}
+
+// PageInfo resolves the standard PageInfo type of connections.
+type PageInfo struct {
+	startCursor     *string
+	endCursor       *string
+	hasNextPage     bool
+	hasPreviousPage bool
+}
+
+func (p *PageInfo) StartCursor() *string {
+	return p.startCursor
+}
+
+func (p *PageInfo) EndCursor() *string {
+	return p.endCursor
+}
+
+func (p *PageInfo) HasNextPage() bool {
+	return p.hasNextPage
+}
+
+func (p *PageInfo) HasPreviousPage() bool {
+	return p.hasPreviousPage
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return 0, fmt.Errorf("relay: invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(string(b[len(cursorPrefix):]))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("relay: invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
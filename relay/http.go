@@ -0,0 +1,89 @@
+package relay
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// Media types of GraphQL responses, see https://graphql.github.io/graphql-over-http/draft/.
+const (
+	MediaTypeGraphQLResponse = "application/graphql-response+json"
+	MediaTypeJSON            = "application/json"
+)
+
+// NegotiateMediaType returns the media type of the response to a request with the given Accept
+// header. It prefers application/graphql-response+json if the client accepts it and falls back to
+// application/json otherwise, which is also used by legacy clients without an Accept header.
+func NegotiateMediaType(accept string) string {
+	best, bestQ := MediaTypeJSON, 0.0
+	for _, r := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if mediaType == MediaTypeGraphQLResponse && q > 0 && q >= bestQ {
+			best, bestQ = MediaTypeGraphQLResponse, q
+		}
+		if mediaType != MediaTypeGraphQLResponse && q > bestQ && (mediaType == MediaTypeJSON || mediaType == "application/*" || mediaType == "*/*") {
+			best, bestQ = MediaTypeJSON, q
+		}
+	}
+	return best
+}
+
+// StatusCode returns the HTTP status of a response with the given media type. A request error is
+// an error that prevented the execution of the request, e.g. because the query could not be parsed
+// or is invalid, and the response of it must not contain data.
+//
+// With application/graphql-response+json, request errors are answered with 400 Bad Request, and
+// responses with data, including partial results with field errors, with 200 OK. With
+// application/json, every well-formed request is answered with 200 OK.
+func StatusCode(mediaType string, resp *graphql.Response, requestError bool) int {
+	if mediaType != MediaTypeGraphQLResponse {
+		return http.StatusOK
+	}
+	if requestError || resp.Data == nil {
+		return http.StatusBadRequest
+	}
+	return http.StatusOK
+}
+
+// WriteResponse writes resp with the given media type and the status code of StatusCode.
+func WriteResponse(w http.ResponseWriter, mediaType string, resp *graphql.Response, requestError bool) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(StatusCode(mediaType, resp, requestError))
+	_, err = w.Write(body)
+	return err
+}
+
+// IsRequestError reports whether resp is the response to a request error, i.e. whether the
+// request was not executed because of an error of the parse or validate phase. Responses with
+// errors without a phase are request errors if they have no data.
+func IsRequestError(resp *graphql.Response) bool {
+	for _, err := range resp.Errors {
+		switch err.Phase {
+		case errors.PhaseParse, errors.PhaseValidate:
+			return true
+		case errors.PhaseExecute:
+			return false
+		}
+	}
+	return resp.Data == nil && len(resp.Errors) != 0
+}
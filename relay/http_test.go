@@ -0,0 +1,156 @@
+package relay_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                                  relay.MediaTypeJSON,
+		"*/*":                               relay.MediaTypeJSON,
+		"application/json":                  relay.MediaTypeJSON,
+		"application/graphql-response+json": relay.MediaTypeGraphQLResponse,
+		"application/graphql-response+json, application/json;q=0.9": relay.MediaTypeGraphQLResponse,
+		"application/graphql-response+json;q=0.5, application/json": relay.MediaTypeJSON,
+		"application/graphql-response+json;q=0, */*":                relay.MediaTypeJSON,
+	} {
+		if got := relay.NegotiateMediaType(accept); got != want {
+			t.Errorf("NegotiateMediaType(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
+
+func TestServeHTTPGraphQLResponse(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			hero: Hero
+		}
+
+		type Hero {
+			name: String!
+			friend: Hero
+		}
+	`, &heroResolver{})
+	h := relay.Handler{Schema: s}
+
+	for _, tc := range []struct {
+		name       string
+		accept     string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "well-formed but invalid",
+			accept:     relay.MediaTypeGraphQLResponse,
+			query:      "{ villain }",
+			wantStatus: 400,
+			wantBody:   `{"errors":[{"message":"Cannot query field \"villain\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name:       "well-formed but invalid with application/json",
+			accept:     relay.MediaTypeJSON,
+			query:      "{ villain }",
+			wantStatus: 200,
+			wantBody:   `{"errors":[{"message":"Cannot query field \"villain\" on type \"Query\".","locations":[{"line":1,"column":3}]}]}`,
+		},
+		{
+			name:       "partial success",
+			accept:     relay.MediaTypeGraphQLResponse,
+			query:      "{ hero { name friend { name } } }",
+			wantStatus: 200,
+			wantBody:   `{"errors":[{"message":"graphql: no friends","path":["hero","friend"]}],"data":{"hero":{"name":"R2-D2","friend":null}}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", strings.NewReader(`{"query":"`+tc.query+`"}`))
+			r.Header.Set("Accept", tc.accept)
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status code %d, got %d.", tc.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tc.accept {
+				t.Errorf("Invalid content-type. Expected [%s], but instead got [%s]", tc.accept, got)
+			}
+			if got := w.Body.String(); got != tc.wantBody {
+				t.Errorf("Invalid response. Expected [%s], but instead got [%s]", tc.wantBody, got)
+			}
+		})
+	}
+}
+
+func TestWriteResponseStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		mediaType    string
+		resp         *graphql.Response
+		requestError bool
+		wantStatus   int
+		wantBody     string
+	}{
+		{
+			name:         "request error",
+			mediaType:    relay.MediaTypeGraphQLResponse,
+			resp:         &graphql.Response{Errors: []*errors.QueryError{{Message: "invalid"}}},
+			requestError: true,
+			wantStatus:   400,
+			wantBody:     `{"errors":[{"message":"invalid"}]}`,
+		},
+		{
+			name:       "partial data",
+			mediaType:  relay.MediaTypeGraphQLResponse,
+			resp:       &graphql.Response{Data: []byte(`{"hero":null}`), Errors: []*errors.QueryError{{Message: "failed", Path: []interface{}{"hero"}}}},
+			wantStatus: 200,
+			wantBody:   `{"errors":[{"message":"failed","path":["hero"]}],"data":{"hero":null}}`,
+		},
+		{
+			name:         "request error with application/json",
+			mediaType:    relay.MediaTypeJSON,
+			resp:         &graphql.Response{Errors: []*errors.QueryError{{Message: "invalid"}}},
+			requestError: true,
+			wantStatus:   200,
+			wantBody:     `{"errors":[{"message":"invalid"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := relay.WriteResponse(w, tc.mediaType, tc.resp, tc.requestError); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status code %d, got %d.", tc.wantStatus, w.Code)
+			}
+			if got := w.Body.String(); got != tc.wantBody {
+				t.Errorf("Invalid response. Expected [%s], but instead got [%s]", tc.wantBody, got)
+			}
+		})
+	}
+}
+
+type heroResolver struct{}
+
+func (r *heroResolver) Hero() *hero {
+	return &hero{}
+}
+
+type hero struct{}
+
+func (h *hero) Name() string {
+	return "R2-D2"
+}
+
+func (h *hero) Friend() (*hero, error) {
+	return nil, errors.Errorf("no friends")
+}
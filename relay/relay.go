@@ -1,6 +1,7 @@
 package relay
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
 )
 
 func MarshalID(kind string, spec interface{}) graphql.ID {
@@ -43,28 +45,88 @@ func UnmarshalSpec(id graphql.ID, v interface{}) error {
 	return json.Unmarshal([]byte(s[i+1:]), v)
 }
 
+// Handler serves the queries of the schema over HTTP. Responses use the
+// application/graphql-response+json media type if the client accepts it, and application/json
+// otherwise.
+//
+// A request body with a JSON array of operations is executed as a batch. Each operation is parsed,
+// validated and executed on its own, and the responses are returned as a JSON array in the same
+// order with 200 OK.
 type Handler struct {
 	Schema *graphql.Schema
+
+	// PersistedQueries enables automatic persisted queries, see graphql.LoadPersistedQuery. It may
+	// be nil.
+	PersistedQueries graphql.PersistedQueryCache
+
+	// MaxBatchSize is the maximum number of operations in a batch. Larger batches are rejected with
+	// 400 Bad Request. The default is 0 which disables the limit.
+	MaxBatchSize int
+}
+
+type params struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    map[string]interface{} `json:"extensions"`
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	var params struct {
-		Query         string                 `json:"query"`
-		OperationName string                 `json:"operationName"`
-		Variables     map[string]interface{} `json:"variables"`
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+	if len(body) != 0 && body[0] == '[' {
+		h.serveBatch(w, r, body)
+		return
+	}
+
+	var p params
+	if err := json.Unmarshal(body, &p); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	response := h.exec(r.Context(), &p)
+	WriteResponse(w, NegotiateMediaType(r.Header.Get("Accept")), response, IsRequestError(response))
+}
 
-	response := h.Schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
-	responseJSON, err := json.Marshal(response)
+func (h *Handler) serveBatch(w http.ResponseWriter, r *http.Request, body json.RawMessage) {
+	var batch []*params
+	if err := json.Unmarshal(body, &batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(batch) == 0 {
+		http.Error(w, "empty batch", http.StatusBadRequest)
+		return
+	}
+	if h.MaxBatchSize > 0 && len(batch) > h.MaxBatchSize {
+		http.Error(w, fmt.Sprintf("batch of %d operations exceeds the maximum of %d", len(batch), h.MaxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	responses := make([]*graphql.Response, len(batch))
+	for i, p := range batch {
+		if p == nil {
+			p = &params{}
+		}
+		responses[i] = h.exec(r.Context(), p)
+	}
+
+	out, err := json.Marshal(responses)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", NegotiateMediaType(r.Header.Get("Accept")))
+	w.Write(out)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(responseJSON)
+func (h *Handler) exec(ctx context.Context, p *params) *graphql.Response {
+	query, err := graphql.LoadPersistedQuery(h.PersistedQueries, p.Query, p.Extensions)
+	if err != nil {
+		return &graphql.Response{Errors: []*gqlerrors.QueryError{err}}
+	}
+	return h.Schema.Exec(ctx, query, p.OperationName, p.Variables)
 }
@@ -1,6 +1,7 @@
 package relay_test
 
 import (
+	"context"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -34,3 +35,240 @@ func TestServeHTTP(t *testing.T) {
 		t.Fatalf("Invalid response. Expected [%s], but instead got [%s]", expectedResponse, actualResponse)
 	}
 }
+
+func TestServeHTTPPersistedQuery(t *testing.T) {
+	h := relay.Handler{Schema: starwarsSchema, PersistedQueries: graphql.NewLRUPersistedQueryCache(10)}
+	// sha256 of "{ hero { name } }"
+	const hash = "aae585680c3470e4947255eafbd1eafe87d1c3f129259cf15e404d1bb7f1e8f4"
+	for _, tc := range []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "miss",
+			body: `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			want: `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`,
+		},
+		{
+			name: "register",
+			body: `{"query":"{ hero { name } }","extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			want: `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+		{
+			name: "hit",
+			body: `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}}`,
+			want: `{"data":{"hero":{"name":"R2-D2"}}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(tc.body)))
+			if w.Code != 200 {
+				t.Fatalf("Expected status code 200, got %d.", w.Code)
+			}
+			if got := w.Body.String(); got != tc.want {
+				t.Fatalf("Invalid response. Expected [%s], but instead got [%s]", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServeHTTPBatch(t *testing.T) {
+	h := relay.Handler{Schema: starwarsSchema, MaxBatchSize: 3}
+	for _, tc := range []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       string
+	}{
+		{
+			name: "mixed batch",
+			body: `[
+				{"query":"{ hero { name } }"},
+				{"query":"{ villain }"},
+				{"query":"query($id: ID!) { human(id: $id) { name } }","variables":{"id":"1000"}}
+			]`,
+			wantStatus: 200,
+			want:       `[{"data":{"hero":{"name":"R2-D2"}}},{"errors":[{"message":"Cannot query field \"villain\" on type \"Query\".","locations":[{"line":1,"column":3}]}]},{"data":{"human":{"name":"Luke Skywalker"}}}]`,
+		},
+		{
+			name:       "too large",
+			body:       `[{"query":"{ hero { name } }"},{"query":"{ hero { name } }"},{"query":"{ hero { name } }"},{"query":"{ hero { name } }"}]`,
+			wantStatus: 400,
+			want:       "batch of 4 operations exceeds the maximum of 3\n",
+		},
+		{
+			name:       "empty",
+			body:       `[]`,
+			wantStatus: 400,
+			want:       "empty batch\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(tc.body)))
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Expected status code %d, got %d.", tc.wantStatus, w.Code)
+			}
+			if got := w.Body.String(); got != tc.want {
+				t.Fatalf("Invalid response. Expected [%s], but instead got [%s]", tc.want, got)
+			}
+		})
+	}
+}
+
+func cursor(index int) *string {
+	c := (&relay.Connection{}).Cursor(index)
+	return &c
+}
+
+func count(n int32) *int32 {
+	return &n
+}
+
+func TestNewConnection(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		args            relay.ConnectionArgs
+		start, end      int
+		hasPreviousPage bool
+		hasNextPage     bool
+		wantErr         bool
+	}{
+		{name: "all items", start: 0, end: 5},
+		{name: "first", args: relay.ConnectionArgs{First: count(2)}, start: 0, end: 2, hasNextPage: true},
+		{name: "first after", args: relay.ConnectionArgs{First: count(2), After: cursor(1)}, start: 2, end: 4, hasPreviousPage: true, hasNextPage: true},
+		{name: "first exceeding the items", args: relay.ConnectionArgs{First: count(10), After: cursor(2)}, start: 3, end: 5, hasPreviousPage: true},
+		{name: "after the last item", args: relay.ConnectionArgs{First: count(2), After: cursor(4)}, start: 5, end: 5, hasPreviousPage: true},
+		{name: "last", args: relay.ConnectionArgs{Last: count(2)}, start: 3, end: 5, hasPreviousPage: true},
+		{name: "last before", args: relay.ConnectionArgs{Last: count(2), Before: cursor(3)}, start: 1, end: 3, hasPreviousPage: true, hasNextPage: true},
+		{name: "last exceeding the items", args: relay.ConnectionArgs{Last: count(10), Before: cursor(2)}, start: 0, end: 2, hasNextPage: true},
+		{name: "before the first item", args: relay.ConnectionArgs{Last: count(2), Before: cursor(0)}, start: 0, end: 0, hasNextPage: true},
+		{name: "after and before", args: relay.ConnectionArgs{After: cursor(0), Before: cursor(4)}, start: 1, end: 4, hasPreviousPage: true, hasNextPage: true},
+		{name: "first zero", args: relay.ConnectionArgs{First: count(0)}, start: 0, end: 0, hasNextPage: true},
+		{name: "negative first", args: relay.ConnectionArgs{First: count(-1)}, wantErr: true},
+		{name: "invalid cursor", args: relay.ConnectionArgs{After: &invalidCursor}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := relay.NewConnection(5, tc.args)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if start, end := c.Bounds(); start != tc.start || end != tc.end {
+				t.Fatalf("wrong bounds, have=[%d,%d) want=[%d,%d)", start, end, tc.start, tc.end)
+			}
+			p := c.PageInfo()
+			if p.HasPreviousPage() != tc.hasPreviousPage || p.HasNextPage() != tc.hasNextPage {
+				t.Fatalf("wrong page info, have previous=%t next=%t", p.HasPreviousPage(), p.HasNextPage())
+			}
+			if tc.start == tc.end {
+				if p.StartCursor() != nil || p.EndCursor() != nil {
+					t.Fatal("expected no cursors for an empty page")
+				}
+				return
+			}
+			if *p.StartCursor() != *cursor(tc.start) || *p.EndCursor() != *cursor(tc.end - 1) {
+				t.Fatalf("wrong cursors, have start=%s end=%s", *p.StartCursor(), *p.EndCursor())
+			}
+		})
+	}
+}
+
+var invalidCursor = "R2-D2"
+
+type letterConnection struct {
+	*relay.Connection
+	letters []string
+}
+
+func (c *letterConnection) Edges() []*letterEdge {
+	start, end := c.Bounds()
+	var edges []*letterEdge
+	for i := start; i < end; i++ {
+		edges = append(edges, &letterEdge{cursor: c.Cursor(i), node: c.letters[i]})
+	}
+	return edges
+}
+
+type letterEdge struct {
+	cursor string
+	node   string
+}
+
+func (e *letterEdge) Cursor() string {
+	return e.cursor
+}
+
+func (e *letterEdge) Node() string {
+	return e.node
+}
+
+type letterResolver struct{}
+
+func (r *letterResolver) Letters(args relay.ConnectionArgs) (*letterConnection, error) {
+	letters := []string{"a", "b", "c", "d"}
+	c, err := relay.NewConnection(len(letters), args)
+	if err != nil {
+		return nil, err
+	}
+	return &letterConnection{Connection: c, letters: letters}, nil
+}
+
+func TestConnectionResolver(t *testing.T) {
+	s := graphql.MustParseSchema(`
+		schema {
+			query: Query
+		}
+
+		type Query {
+			letters(first: Int, after: String, last: Int, before: String): LetterConnection!
+		}
+
+		type LetterConnection {
+			totalCount: Int!
+			edges: [LetterEdge!]!
+			pageInfo: PageInfo!
+		}
+
+		type LetterEdge {
+			cursor: String!
+			node: String!
+		}
+
+		type PageInfo {
+			startCursor: String
+			endCursor: String
+			hasNextPage: Boolean!
+			hasPreviousPage: Boolean!
+		}
+	`, &letterResolver{})
+
+	resp := s.Exec(context.Background(), `
+		query($after: String) {
+			letters(first: 2, after: $after) {
+				totalCount
+				edges {
+					node
+				}
+				pageInfo {
+					hasNextPage
+					hasPreviousPage
+				}
+			}
+		}
+	`, "", map[string]interface{}{"after": *cursor(0)})
+	if len(resp.Errors) != 0 {
+		t.Fatal(resp.Errors)
+	}
+	want := `{"letters":{"totalCount":4,"edges":[{"node":"b"},{"node":"c"}],"pageInfo":{"hasNextPage":true,"hasPreviousPage":true}}}`
+	if string(resp.Data) != want {
+		t.Fatalf("wrong data\nhave: %s\nwant: %s", resp.Data, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
 	graphql "github.com/graph-gophers/graphql-go"
@@ -164,6 +165,58 @@ func TestSchemaSubscribe(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "resolver_error_mapper",
+			Schema: graphql.MustParseSchema(schema, &rootResolver{
+				helloSaidResolver: &helloSaidResolver{
+					upstream: closedUpstream(
+						&helloSaidEventResolver{err: resolverErr},
+					),
+				},
+			}, graphql.ResolverErrorMapper(func(err error) *qerrors.QueryError {
+				return &qerrors.QueryError{Message: "mapped " + err.Error()}
+			})),
+			Query: `
+				subscription onHelloSaid {
+					helloSaid {
+						msg
+					}
+				}
+			`,
+			ExpectedResults: []gqltesting.TestResponse{
+				{
+					Data: json.RawMessage(`
+						null
+					`),
+					Errors: []*qerrors.QueryError{qerrors.Errorf("mapped %s", resolverErr)},
+				},
+			},
+		},
+		{
+			Name: "non_null_error",
+			Schema: graphql.MustParseSchema(schema, &rootResolver{
+				helloSaidResolver: &helloSaidResolver{
+					upstream: closedUpstream(nil),
+				},
+			}, graphql.NonNullError(func(path []interface{}, typeName, fieldName string) *qerrors.QueryError {
+				return &qerrors.QueryError{Message: fmt.Sprintf("%s.%s is missing", typeName, fieldName)}
+			})),
+			Query: `
+				subscription onHelloSaid {
+					helloSaid {
+						msg
+					}
+				}
+			`,
+			ExpectedResults: []gqltesting.TestResponse{
+				{
+					Data: json.RawMessage(`
+						null
+					`),
+					Errors: []*qerrors.QueryError{qerrors.Errorf("Subscription.helloSaid is missing")},
+				},
+			},
+		},
 		{
 			Name:   "parse_errors",
 			Schema: graphql.MustParseSchema(schema, &rootResolver{}),
@@ -11,7 +11,6 @@ import (
 	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
 	"github.com/graph-gophers/graphql-go/internal/exec/selected"
 	"github.com/graph-gophers/graphql-go/internal/query"
-	"github.com/graph-gophers/graphql-go/internal/validation"
 	"github.com/graph-gophers/graphql-go/introspection"
 )
 
@@ -31,45 +30,60 @@ func (s *Schema) Subscribe(ctx context.Context, queryString string, operationNam
 }
 
 func (s *Schema) subscribe(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, res *resolvable.Schema) <-chan interface{} {
-	doc, qErr := query.Parse(queryString)
+	doc, qErr := s.parse(queryString)
 	if qErr != nil {
-		return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{qErr}})
+		return sendAndReturnClosed(&Response{Errors: qerrors.SetPhase([]*qerrors.QueryError{qErr}, qerrors.PhaseParse)})
 	}
 
 	validationFinish := s.validationTracer.TraceValidation()
-	errs := validation.Validate(s.schema, doc, variables, s.maxDepth, s.maxCost)
+	errs, _ := s.validate(doc, variables)
 	validationFinish(errs)
+	s.observeValidation(doc, queryString, operationName, errs)
 	if len(errs) != 0 {
-		return sendAndReturnClosed(&Response{Errors: errs})
+		s.addOperationToErrors(doc, operationName, errs)
+		return sendAndReturnClosed(&Response{Errors: qerrors.SetPhase(errs, qerrors.PhaseValidate)})
 	}
 
 	op, err := getOperation(doc, operationName)
 	if err != nil {
-		return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{qerrors.Errorf("%s", err)}})
+		return sendAndReturnClosed(&Response{Errors: qerrors.SetPhase([]*qerrors.QueryError{qerrors.Errorf("%s", err)}, qerrors.PhaseValidate)})
 	}
 
 	r := &exec.Request{
 		Request: selected.Request{
-			Doc:    doc,
-			Vars:   variables,
-			Schema: s.schema,
+			Doc:                  doc,
+			Vars:                 variables,
+			Schema:               s.schema,
+			DisableIntrospection: s.introspectionDisabled(ctx),
+			FieldVisible:         visibleFields(ctx),
+			DirectiveHandlers:    s.directiveHandlers,
+			Authorization:        s.authorization,
+			Roles:                rolesOf(ctx),
 		},
-		Limiter: make(chan struct{}, s.maxParallelism),
-		Tracer:  s.tracer,
-		Logger:  s.logger,
+		Limiter:               make(chan struct{}, s.maxParallelism),
+		GlobalLimiter:         s.globalLimiter,
+		Tracer:                s.tracer,
+		Logger:                s.logger,
+		NonFiniteFloatsAsNull: s.nonFiniteFloatsAsNull,
+		IncludeErrorLocations: s.includeErrorLocations,
+		NonNullError:          s.nonNullError,
+		ResolverErrorMapper:   s.resolverErrorMapper,
+	}
+	if s.operationInErrors {
+		r.ErrorExtensions = operationExtensions(op)
 	}
 	varTypes := make(map[string]*introspection.Type)
 	for _, v := range op.Vars {
 		t, err := common.ResolveType(v.Type, s.schema.Resolve)
 		if err != nil {
-			return sendAndReturnClosed(&Response{Errors: []*qerrors.QueryError{err}})
+			return sendAndReturnClosed(&Response{Errors: qerrors.SetPhase([]*qerrors.QueryError{err}, qerrors.PhaseValidate)})
 		}
 		varTypes[v.Name.Name] = introspection.WrapType(t)
 	}
 
 	if op.Type == query.Query || op.Type == query.Mutation {
 		data, errs := r.Execute(ctx, res, op)
-		return sendAndReturnClosed(&Response{Data: data, Errors: errs})
+		return sendAndReturnClosed(&Response{Data: data, Errors: qerrors.SetPhase(errs, qerrors.PhaseExecute)})
 	}
 
 	responses := r.Subscribe(ctx, res, op)
@@ -78,7 +92,7 @@ func (s *Schema) subscribe(ctx context.Context, queryString string, operationNam
 		for resp := range responses {
 			c <- &Response{
 				Data:   resp.Data,
-				Errors: resp.Errors,
+				Errors: qerrors.SetPhase(resp.Errors, qerrors.PhaseExecute),
 			}
 		}
 		close(c)
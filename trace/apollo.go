@@ -0,0 +1,157 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// A ResolverTracer is a Tracer that also gets the response path and the return type of fields.
+// The executor calls TraceResolver instead of TraceField if the tracer implements it.
+type ResolverTracer interface {
+	Tracer
+	TraceResolver(ctx context.Context, path []interface{}, typeName, fieldName, returnType string, trivial bool, args map[string]interface{}) (context.Context, TraceFieldFinishFunc)
+}
+
+// An ExtensionsTracer is a Tracer that adds entries to the extensions of responses. Extensions is
+// called with the context returned by TraceQuery once the query is finished.
+type ExtensionsTracer interface {
+	Tracer
+	Extensions(ctx context.Context) map[string]interface{}
+}
+
+// QueryPhases holds the timings of parsing and validating a query.
+type QueryPhases struct {
+	Start      time.Time
+	Parsing    time.Duration
+	Validation time.Duration
+}
+
+type queryPhasesKey struct{}
+
+// ContextWithQueryPhases returns a copy of ctx that holds the given timings. The context given to
+// TraceQuery holds the timings of the query.
+func ContextWithQueryPhases(ctx context.Context, p QueryPhases) context.Context {
+	return context.WithValue(ctx, queryPhasesKey{}, p)
+}
+
+// QueryPhasesFromContext returns the timings held by ctx, if any.
+func QueryPhasesFromContext(ctx context.Context) (QueryPhases, bool) {
+	p, ok := ctx.Value(queryPhasesKey{}).(QueryPhases)
+	return p, ok
+}
+
+// ApolloTracer adds the timings of a query and of all of its resolvers to the "tracing" entry of
+// the response extensions, in the format of the Apollo Tracing specification.
+type ApolloTracer struct{}
+
+type apolloTraceKey struct{}
+
+type apolloTrace struct {
+	mu        sync.Mutex
+	phases    QueryPhases
+	end       time.Time
+	resolvers []*apolloResolver
+}
+
+type apolloTracing struct {
+	Version    int             `json:"version"`
+	StartTime  time.Time       `json:"startTime"`
+	EndTime    time.Time       `json:"endTime"`
+	Duration   time.Duration   `json:"duration"`
+	Parsing    apolloPhase     `json:"parsing"`
+	Validation apolloPhase     `json:"validation"`
+	Execution  apolloExecution `json:"execution"`
+}
+
+type apolloPhase struct {
+	StartOffset time.Duration `json:"startOffset"`
+	Duration    time.Duration `json:"duration"`
+}
+
+type apolloExecution struct {
+	Resolvers []*apolloResolver `json:"resolvers"`
+}
+
+type apolloResolver struct {
+	Path        []interface{} `json:"path"`
+	ParentType  string        `json:"parentType"`
+	FieldName   string        `json:"fieldName"`
+	ReturnType  string        `json:"returnType"`
+	StartOffset time.Duration `json:"startOffset"`
+	Duration    time.Duration `json:"duration"`
+}
+
+func (ApolloTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, TraceQueryFinishFunc) {
+	t := &apolloTrace{}
+	if p, ok := QueryPhasesFromContext(ctx); ok {
+		t.phases = p
+	} else {
+		t.phases.Start = time.Now()
+	}
+	return context.WithValue(ctx, apolloTraceKey{}, t), func(errs []*errors.QueryError) {
+		t.mu.Lock()
+		t.end = time.Now()
+		t.mu.Unlock()
+	}
+}
+
+func (tracer ApolloTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, TraceFieldFinishFunc) {
+	return tracer.TraceResolver(ctx, nil, typeName, fieldName, "", trivial, args)
+}
+
+func (ApolloTracer) TraceResolver(ctx context.Context, path []interface{}, typeName, fieldName, returnType string, trivial bool, args map[string]interface{}) (context.Context, TraceFieldFinishFunc) {
+	t, ok := ctx.Value(apolloTraceKey{}).(*apolloTrace)
+	if !ok {
+		return ctx, noop
+	}
+	start := time.Now()
+	return ctx, func(err *errors.QueryError) {
+		r := &apolloResolver{
+			Path:        path,
+			ParentType:  typeName,
+			FieldName:   fieldName,
+			ReturnType:  returnType,
+			StartOffset: start.Sub(t.phases.Start),
+			Duration:    time.Since(start),
+		}
+		t.mu.Lock()
+		t.resolvers = append(t.resolvers, r)
+		t.mu.Unlock()
+	}
+}
+
+func (ApolloTracer) Extensions(ctx context.Context) map[string]interface{} {
+	t, ok := ctx.Value(apolloTraceKey{}).(*apolloTrace)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	resolvers := t.resolvers
+	if resolvers == nil {
+		resolvers = []*apolloResolver{}
+	}
+	return map[string]interface{}{
+		"tracing": &apolloTracing{
+			Version:   1,
+			StartTime: t.phases.Start,
+			EndTime:   t.end,
+			Duration:  t.end.Sub(t.phases.Start),
+			Parsing: apolloPhase{
+				Duration: t.phases.Parsing,
+			},
+			Validation: apolloPhase{
+				StartOffset: t.phases.Parsing,
+				Duration:    t.phases.Validation,
+			},
+			Execution: apolloExecution{
+				Resolvers: resolvers,
+			},
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package graphql
+
+import (
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/internal/common"
+	"github.com/graph-gophers/graphql-go/internal/exec/resolvable"
+	"github.com/graph-gophers/graphql-go/internal/schema"
+	"github.com/graph-gophers/graphql-go/internal/validation"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// QueryVisitor receives the fields and fragments of an operation from WalkOperation, e.g. to compute
+// the complexity of a query with custom rules. Walking stops at the first error that a method
+// returns, which is returned by WalkOperation.
+type QueryVisitor interface {
+	// EnterField is called for a field before its selections are walked.
+	EnterField(f *VisitedField) error
+	// LeaveField is called for a field after its selections were walked.
+	LeaveField(f *VisitedField) error
+	// EnterFragment is called for an inline fragment or fragment spread before its selections
+	// are walked.
+	EnterFragment(f *VisitedFragment) error
+	// LeaveFragment is called for an inline fragment or fragment spread after its selections were
+	// walked.
+	LeaveFragment(f *VisitedFragment) error
+}
+
+// VisitedField is a field of an operation as passed to a QueryVisitor.
+type VisitedField struct {
+	// Alias is the response name of the field, which is its name unless the query aliases it.
+	Alias string
+	Name  string
+	// Args holds the arguments given in the query, with the variables of the request resolved.
+	// Omitted arguments are not included.
+	Args map[string]interface{}
+	// Type is the type of the field.
+	Type *introspection.Type
+	// ParentType is the name of the object or interface type that the field is selected on.
+	ParentType string
+	// SchemaDirectives holds the directives applied to the field in the schema, followed by the
+	// directives of the same field of the interfaces that the parent type implements.
+	SchemaDirectives []FieldDirective
+	// Depth is the number of fields on the path from the root, which is 1 for the root fields.
+	Depth int
+	Loc   errors.Location
+}
+
+// VisitedFragment is an inline fragment or fragment spread of an operation as passed to a
+// QueryVisitor.
+type VisitedFragment struct {
+	// Name is the name of a fragment spread, or empty for an inline fragment.
+	Name string
+	// TypeCondition is the type that the fragment applies to. It is the parent type for an inline
+	// fragment without type condition.
+	TypeCondition string
+	// ParentType is the name of the type that the fragment is selected on.
+	ParentType string
+	Loc        errors.Location
+}
+
+// WalkOperation calls the visitor for the fields and fragments of the given operation of the
+// document, in the order of the document and with the fragments expanded where they are spread,
+// like the cost analysis of MaxCost walks them. Variables are resolved in the arguments of the
+// fields and in @skip and @include, which exclude selections from the walk. The document is not
+// validated, so it should be parsed from a query that passes Validate. If the document contains
+// more than one operation, the operation name must be given.
+func WalkOperation(schema *Schema, doc *QueryDocument, operationName string, variables map[string]interface{}, visitor QueryVisitor) error {
+	op, err := getOperation(doc.doc, operationName)
+	if err != nil {
+		return err
+	}
+	w := &operationWalker{res: schema.res, vars: variables, visitor: visitor}
+	return validation.Walk(schema.schema, doc.doc, op, variables, w)
+}
+
+// operationWalker passes the fields and fragments of validation.Walk to a QueryVisitor.
+type operationWalker struct {
+	res       *resolvable.Schema
+	vars      map[string]interface{}
+	visitor   QueryVisitor
+	fields    []*VisitedField
+	fragments []*VisitedFragment
+}
+
+func (w *operationWalker) EnterField(wf *validation.WalkField) error {
+	sel := wf.Selection
+	f := &VisitedField{
+		Alias:            sel.Alias.Name,
+		Name:             sel.Name.Name,
+		Args:             make(map[string]interface{}, len(sel.Arguments)),
+		Type:             introspection.WrapType(wf.Field.Type),
+		ParentType:       wf.ParentType.TypeName(),
+		SchemaDirectives: w.schemaDirectives(wf.Field, wf.ParentType),
+		Depth:            wf.Depth,
+		Loc:              sel.Alias.Loc,
+	}
+	for _, arg := range sel.Arguments {
+		f.Args[arg.Name.Name] = arg.Value.Value(w.vars)
+	}
+	w.fields = append(w.fields, f)
+	return w.visitor.EnterField(f)
+}
+
+func (w *operationWalker) LeaveField(*validation.WalkField) error {
+	f := w.fields[len(w.fields)-1]
+	w.fields = w.fields[:len(w.fields)-1]
+	return w.visitor.LeaveField(f)
+}
+
+func (w *operationWalker) EnterFragment(wf *validation.WalkFragment) error {
+	f := &VisitedFragment{
+		Name:          wf.Name,
+		TypeCondition: wf.TypeCondition.TypeName(),
+		ParentType:    wf.ParentType.TypeName(),
+		Loc:           wf.Loc,
+	}
+	w.fragments = append(w.fragments, f)
+	return w.visitor.EnterFragment(f)
+}
+
+func (w *operationWalker) LeaveFragment(*validation.WalkFragment) error {
+	f := w.fragments[len(w.fragments)-1]
+	w.fragments = w.fragments[:len(w.fragments)-1]
+	return w.visitor.LeaveFragment(f)
+}
+
+// schemaDirectives returns the coerced directives of the field and of the same field of the
+// interfaces that t implements.
+func (w *operationWalker) schemaDirectives(f *schema.Field, t schema.NamedType) []FieldDirective {
+	lists := []common.DirectiveList{f.Directives}
+	if obj, ok := t.(*schema.Object); ok {
+		for _, iface := range obj.Interfaces {
+			if ifaceField := iface.Fields.Get(f.Name); ifaceField != nil {
+				lists = append(lists, ifaceField.Directives)
+			}
+		}
+	}
+
+	var directives []FieldDirective
+	for _, list := range lists {
+		for _, d := range list {
+			args, err := w.res.DirectiveArgs(d, nil)
+			if err != nil {
+				// Directives of the schema are checked when it is parsed.
+				continue
+			}
+			directives = append(directives, FieldDirective{Name: d.Name.Name, Args: args})
+		}
+	}
+	return directives
+}
@@ -0,0 +1,388 @@
+package graphql_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+const walkSchema = `
+	directive @cost(complexity: Int, multipliers: [String!], useMultipliers: Boolean) on FIELD_DEFINITION
+
+	schema {
+		query: Query
+	}
+
+	type Query {
+		users(first: Int, page: Page): [User!]! @cost(complexity: 2, multipliers: ["first", "page.size"])
+		node(id: ID!): Node @cost(complexity: 1)
+		search(text: String!): [SearchResult!]! @cost(complexity: 5, useMultipliers: false)
+		viewer: User
+	}
+
+	input Page {
+		size: Int
+	}
+
+	interface Node {
+		id: ID!
+		friends(first: Int): [User!]! @cost(complexity: 3, multipliers: ["first"])
+	}
+
+	type User implements Node {
+		id: ID!
+		name: String! @cost(complexity: 1)
+		friends(first: Int): [User!]!
+		posts(last: Int): [Post!]! @cost(complexity: 2, multipliers: ["last"])
+	}
+
+	type Post implements Node {
+		id: ID!
+		title: String!
+		friends(first: Int): [User!]!
+		comments: [String!]! @cost(complexity: 10)
+	}
+
+	union SearchResult = User | Post
+`
+
+// costVisitor computes the cost of an operation from the @cost directives of the schema, like
+// the built-in cost analysis, to show that QueryVisitor is sufficient for it.
+type costVisitor struct {
+	schema *graphql.Schema
+	frames []*costFrame
+}
+
+// costFrame accumulates the cost of the selections of a field or fragment.
+type costFrame struct {
+	typeName   string
+	abstract   bool
+	multiplier int
+	cost       int
+	// typeCosts holds the costs of fragments on the possible types of an abstract type, of which
+	// only the most expensive one is charged.
+	typeCosts map[string]int
+
+	fieldCost        int
+	useMultipliers   bool
+	parentMultiplier int
+}
+
+func newCostVisitor(schema *graphql.Schema) *costVisitor {
+	return &costVisitor{schema: schema, frames: []*costFrame{{typeName: "Query", multiplier: 1}}}
+}
+
+func (v *costVisitor) Cost() int {
+	return v.frames[0].total()
+}
+
+func (f *costFrame) total() int {
+	max := 0
+	for _, c := range f.typeCosts {
+		if c > max {
+			max = c
+		}
+	}
+	return f.cost + max
+}
+
+func (v *costVisitor) top() *costFrame {
+	return v.frames[len(v.frames)-1]
+}
+
+func (v *costVisitor) push(typeName string) *costFrame {
+	kind := v.schema.Inspect().TypeByName(typeName).Kind()
+	f := &costFrame{
+		typeName:   typeName,
+		abstract:   kind == "INTERFACE" || kind == "UNION",
+		multiplier: v.top().multiplier,
+		typeCosts:  make(map[string]int),
+	}
+	v.frames = append(v.frames, f)
+	return f
+}
+
+func (v *costVisitor) pop() *costFrame {
+	f := v.top()
+	v.frames = v.frames[:len(v.frames)-1]
+	return f
+}
+
+func (v *costVisitor) EnterField(f *graphql.VisitedField) error {
+	t := f.Type
+	for t.OfType() != nil {
+		t = t.OfType()
+	}
+	frame := v.push(*t.Name())
+	frame.parentMultiplier = v.frames[len(v.frames)-2].multiplier
+	frame.multiplier = 1
+	frame.useMultipliers = true
+	for _, d := range f.SchemaDirectives {
+		if d.Name != "cost" {
+			continue
+		}
+		if c, ok := d.Args["complexity"].(int32); ok {
+			frame.fieldCost = int(c)
+		}
+		if b, ok := d.Args["useMultipliers"].(bool); ok {
+			frame.useMultipliers = b
+		}
+		found := false
+		multipliers, _ := d.Args["multipliers"].([]interface{})
+		for _, path := range multipliers {
+			if m, ok := multiplier(f.Args, path.(string)); ok {
+				found = true
+				frame.multiplier += m
+			}
+		}
+		if found {
+			frame.multiplier--
+		}
+		break
+	}
+	return nil
+}
+
+func (v *costVisitor) LeaveField(f *graphql.VisitedField) error {
+	frame := v.pop()
+	cost := frame.total() + frame.fieldCost
+	if frame.useMultipliers {
+		cost *= frame.parentMultiplier
+	}
+	v.top().cost += cost
+	return nil
+}
+
+func (v *costVisitor) EnterFragment(f *graphql.VisitedFragment) error {
+	v.push(f.TypeCondition)
+	return nil
+}
+
+func (v *costVisitor) LeaveFragment(f *graphql.VisitedFragment) error {
+	cost := v.pop().total()
+	parent := v.top()
+	if parent.abstract && f.TypeCondition != parent.typeName {
+		parent.typeCosts[f.TypeCondition] += cost
+	} else {
+		parent.cost += cost
+	}
+	return nil
+}
+
+// multiplier reads the value of an argument or a field of an input object argument.
+func multiplier(args map[string]interface{}, path string) (int, bool) {
+	names := strings.Split(path, ".")
+	v := args[names[0]]
+	for _, name := range names[1:] {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		v = obj[name]
+	}
+	switch v := v.(type) {
+	case int32:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func TestWalkOperation_cost(t *testing.T) {
+	schema := graphql.MustParseSchema(walkSchema, nil)
+
+	for _, tt := range []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+	}{
+		{
+			name:  "multipliers",
+			query: `{ users(first: 10) { name posts(last: 5) { title } } }`,
+		},
+		{
+			name:      "variables and input objects",
+			query:     `query($n: Int, $skip: Boolean!) { users(page: {size: $n}) { name @skip(if: $skip) friends(first: 3) { id } } }`,
+			variables: map[string]interface{}{"n": 7.0, "skip": true},
+		},
+		{
+			name: "interface fragments",
+			query: `
+				{
+					node(id: "1") {
+						id
+						friends(first: 4) { name }
+						... on User { posts(last: 2) { title } }
+						... on Post { comments }
+						...postFields
+					}
+				}
+
+				fragment postFields on Post {
+					title
+					comments
+				}
+			`,
+		},
+		{
+			name: "union fragments",
+			query: `
+				{
+					search(text: "x") {
+						... on User { name posts(last: 3) { id } }
+						... on Post { comments }
+					}
+					viewer { ... { name } }
+				}
+			`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, qErr := graphql.ParseQuery(tt.query)
+			if qErr != nil {
+				t.Fatal(qErr)
+			}
+			if errs := schema.ValidateVariables(tt.query, "", tt.variables); len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			want, err := graphql.EstimateQueryCost(schema, tt.query, "", tt.variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want == 0 {
+				t.Fatal("the query has no cost")
+			}
+
+			v := newCostVisitor(schema)
+			if err := graphql.WalkOperation(schema, doc, "", tt.variables, v); err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Cost(); got != want {
+				t.Errorf("got cost %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+// recordingVisitor records the calls of WalkOperation and fails when a field is deeper than
+// maxDepth.
+type recordingVisitor struct {
+	calls    []string
+	maxDepth int
+}
+
+func (v *recordingVisitor) EnterField(f *graphql.VisitedField) error {
+	if v.maxDepth > 0 && f.Depth > v.maxDepth {
+		return fmt.Errorf("field %q is too deep", f.Alias)
+	}
+	var directives []string
+	for _, d := range f.SchemaDirectives {
+		directives = append(directives, fmt.Sprintf("@%s%v", d.Name, d.Args["complexity"]))
+	}
+	v.calls = append(v.calls, fmt.Sprintf("enter %s.%s as %s: %s %v %v depth %d line %d", f.ParentType, f.Name, f.Alias, typeString(f.Type), f.Args, directives, f.Depth, f.Loc.Line))
+	return nil
+}
+
+func typeString(t *introspection.Type) string {
+	switch t.Kind() {
+	case "NON_NULL":
+		return typeString(t.OfType()) + "!"
+	case "LIST":
+		return "[" + typeString(t.OfType()) + "]"
+	}
+	return *t.Name()
+}
+
+func (v *recordingVisitor) LeaveField(f *graphql.VisitedField) error {
+	v.calls = append(v.calls, "leave "+f.ParentType+"."+f.Name)
+	return nil
+}
+
+func (v *recordingVisitor) EnterFragment(f *graphql.VisitedFragment) error {
+	v.calls = append(v.calls, fmt.Sprintf("enter fragment %q on %s in %s", f.Name, f.TypeCondition, f.ParentType))
+	return nil
+}
+
+func (v *recordingVisitor) LeaveFragment(f *graphql.VisitedFragment) error {
+	v.calls = append(v.calls, "leave fragment on "+f.TypeCondition)
+	return nil
+}
+
+func TestWalkOperation(t *testing.T) {
+	schema := graphql.MustParseSchema(walkSchema, nil)
+	doc, qErr := graphql.ParseQuery(`
+		query Other { viewer { id } }
+
+		query Walk($first: Int) {
+			__typename
+			people: users(first: $first) {
+				... on Node { friends(first: 2) { id } }
+				...name
+			}
+		}
+
+		fragment name on User {
+			name
+		}
+	`)
+	if qErr != nil {
+		t.Fatal(qErr)
+	}
+
+	v := &recordingVisitor{}
+	if err := graphql.WalkOperation(schema, doc, "Walk", map[string]interface{}{"first": 3.0}, v); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"enter Query.users as people: [User!]! map[first:3] [@cost2] depth 1 line 6",
+		`enter fragment "" on Node in User`,
+		"enter Node.friends as friends: [User!]! map[first:2] [@cost3] depth 2 line 7",
+		"enter User.id as id: ID! map[] [] depth 3 line 7",
+		"leave User.id",
+		"leave Node.friends",
+		"leave fragment on Node",
+		`enter fragment "name" on User in User`,
+		"enter User.name as name: String! map[] [@cost1] depth 2 line 13",
+		"leave User.name",
+		"leave fragment on User",
+		"leave Query.users",
+	}
+	if !reflect.DeepEqual(v.calls, want) {
+		t.Errorf("got calls:\n%s\nwant:\n%s", strings.Join(v.calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The directives of interface fields are listed after the ones of the field itself.
+	v = &recordingVisitor{}
+	doc, _ = graphql.ParseQuery(`{ viewer { friends(first: 1) { id } } }`)
+	if err := graphql.WalkOperation(schema, doc, "", nil, v); err != nil {
+		t.Fatal(err)
+	}
+	if want := "enter User.friends as friends: [User!]! map[first:1] [@cost3] depth 2 line 1"; v.calls[1] != want {
+		t.Errorf("got %s, want %s", v.calls[1], want)
+	}
+
+	// An error of the visitor stops the walk.
+	v = &recordingVisitor{maxDepth: 1}
+	err := graphql.WalkOperation(schema, doc, "", nil, v)
+	if err == nil || err.Error() != `field "friends" is too deep` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(v.calls) != 1 {
+		t.Errorf("the walk continued after the error: %v", v.calls)
+	}
+
+	if err := graphql.WalkOperation(schema, doc, "Missing", nil, v); err == nil {
+		t.Error("expected an error for a missing operation")
+	}
+
+	// A fragment cycle of an invalid document stops the walk with an error.
+	doc, _ = graphql.ParseQuery(`{ viewer { ...friends } } fragment friends on User { friends { ...friends } }`)
+	if err := graphql.WalkOperation(schema, doc, "", nil, &recordingVisitor{}); err == nil {
+		t.Error("expected an error for a fragment cycle")
+	}
+}